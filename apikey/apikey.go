@@ -0,0 +1,158 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"hash/crc32"
+	"math/big"
+	"strings"
+)
+
+const (
+	// RandomLength is the number of base62 characters in the random part of a key (about 178 bit).
+	RandomLength = 30
+
+	// MaxPrefixLength is the maximum length of a prefix.
+	MaxPrefixLength = 20
+
+	// separator separates prefix, body and checksum.
+	separator = "_"
+
+	// checksumLength is the length of the base62 encoded checksum. 62^6 is larger than 2^32.
+	checksumLength = 6
+
+	// base62 contains the characters used by keys in the order of big.Int.Text.
+	base62 = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+)
+
+var (
+	// ErrMalformed is returned if a key has the wrong format or a wrong checksum (e.g. because it was mistyped).
+	ErrMalformed = errors.New("apikey: malformed key")
+
+	// ErrInvalidPrefix is returned if a prefix is empty, too long or contains characters other than ASCII letters and digits.
+	ErrInvalidPrefix = errors.New("apikey: invalid prefix")
+)
+
+// Generate returns a new random key with the given prefix. The prefix must consist of 1 to MaxPrefixLength ASCII letters and digits.
+// Only Hash(key) should be stored.
+//
+// Can be used concurrent.
+func Generate(prefix string) (key string, err error) {
+	if !validPrefix(prefix) {
+		return "", ErrInvalidPrefix
+	}
+	body, err := randomBase62(RandomLength)
+	if err != nil {
+		return "", err
+	}
+	return build(prefix, body), nil
+}
+
+// Check verifies the format and checksum of key and returns its prefix.
+// It does not check whether the key exists, but allows rejecting malformed keys cheaply. ErrMalformed is returned if the check fails.
+//
+// Can be used concurrent.
+func Check(key string) (prefix string, err error) {
+	prefix, _, err = split(key)
+	return
+}
+
+// Hash returns the hash of key, which should be stored instead of the key.
+// The hash is deterministic, so it can be used to look up a key. It does not depend on the hidden value of package data and stays valid across restarts.
+//
+// Can be used concurrent.
+func Hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Equal returns whether hash is the hash of key. The comparison is done in constant time.
+//
+// Can be used concurrent.
+func Equal(key, hash string) bool {
+	return subtle.ConstantTimeCompare([]byte(Hash(key)), []byte(hash)) == 1
+}
+
+// build joins prefix, body and the checksum of both.
+func build(prefix, body string) string {
+	s := prefix + separator + body
+	return s + separator + checksum(s)
+}
+
+// split returns prefix and body of key after verifying its checksum.
+func split(key string) (prefix, body string, err error) {
+	parts := strings.Split(key, separator)
+	if len(parts) != 3 || !validPrefix(parts[0]) || parts[1] == "" || !isBase62(parts[1]) || len(parts[2]) != checksumLength {
+		err = ErrMalformed
+		return
+	}
+	s := parts[0] + separator + parts[1]
+	if subtle.ConstantTimeCompare([]byte(checksum(s)), []byte(parts[2])) == 0 {
+		err = ErrMalformed
+		return
+	}
+	return parts[0], parts[1], nil
+}
+
+// checksum returns the base62 encoded CRC-32 of s with a fixed length.
+func checksum(s string) string {
+	c := new(big.Int).SetUint64(uint64(crc32.ChecksumIEEE([]byte(s)))).Text(62)
+	return strings.Repeat("0", checksumLength-len(c)) + c
+}
+
+// randomBase62 returns a random base62 string of the given length.
+func randomBase62(length int) (string, error) {
+	result := make([]byte, 0, length)
+	b := make([]byte, length)
+	for len(result) < length {
+		_, err := rand.Read(b)
+		if err != nil {
+			return "", err
+		}
+		for i := range b {
+			// Reject values above the largest multiple of 62 to avoid modulo bias.
+			if b[i] >= 248 {
+				continue
+			}
+			result = append(result, base62[b[i]%62])
+			if len(result) == length {
+				break
+			}
+		}
+	}
+	return string(result), nil
+}
+
+func validPrefix(prefix string) bool {
+	if prefix == "" || len(prefix) > MaxPrefixLength {
+		return false
+	}
+	return isBase62(prefix)
+}
+
+func isBase62(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if strings.IndexByte(base62, s[i]) == -1 {
+			return false
+		}
+	}
+	return true
+}
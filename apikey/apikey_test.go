@@ -0,0 +1,122 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apikey
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	key, err := Generate("myapp")
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	parts := strings.Split(key, "_")
+	if len(parts) != 3 || parts[0] != "myapp" || len(parts[1]) != RandomLength || len(parts[2]) != checksumLength {
+		t.Errorf("wrong format (is: %s)", key)
+	}
+
+	prefix, err := Check(key)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if prefix != "myapp" {
+		t.Errorf("wrong prefix (is: %s, should: %s)", prefix, "myapp")
+	}
+
+	other, err := Generate("myapp")
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if key == other {
+		t.Errorf("keys are equal")
+	}
+}
+
+func TestGenerateInvalidPrefix(t *testing.T) {
+	for _, p := range []string{"", "my_app", "my-app", "äpp", strings.Repeat("a", MaxPrefixLength+1)} {
+		_, err := Generate(p)
+		if !errors.Is(err, ErrInvalidPrefix) {
+			t.Errorf("prefix %s not rejected (is: %v, should: %v)", p, err, ErrInvalidPrefix)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	key, err := Generate("test")
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+
+	// Change a single character of the random part.
+	i := len("test_") + 3
+	c := byte('a')
+	if key[i] == c {
+		c = 'b'
+	}
+	typo := key[:i] + string(c) + key[i+1:]
+
+	tests := map[string]string{
+		"empty":     "",
+		"typo":      typo,
+		"truncated": key[:len(key)-1],
+		"prefix":    "other" + key[len("test"):],
+		"no parts":  strings.ReplaceAll(key, "_", ""),
+		"extra":     key + "_a",
+		"character": key[:i] + "-" + key[i+1:],
+	}
+	for k := range tests {
+		t.Run(k, func(t *testing.T) {
+			_, err := Check(tests[k])
+			if !errors.Is(err, ErrMalformed) {
+				t.Errorf("key not rejected (is: %v, should: %v)", err, ErrMalformed)
+			}
+		})
+	}
+}
+
+func TestHash(t *testing.T) {
+	key, err := Generate("test")
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	other, err := Generate("test")
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+
+	h := Hash(key)
+	if strings.Contains(h, key[len("test_"):len("test_")+RandomLength]) {
+		t.Errorf("hash contains key")
+	}
+	if Hash(key) != h {
+		t.Errorf("hash not deterministic")
+	}
+	if !Equal(key, h) {
+		t.Errorf("key does not match its hash")
+	}
+	if Equal(other, h) {
+		t.Errorf("other key matches hash")
+	}
+}
@@ -0,0 +1,25 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package apikey contains API keys of the form prefix_random_checksum (e.g. myapp_3ZfT0...hQ_1x9Kd2).
+//
+// The prefix makes keys detectable by secret scanners and shows users which service a key belongs to.
+// The checksum (CRC-32) allows rejecting mistyped or truncated keys before any database lookup.
+// Only the hash of a key (see Hash) should be stored. Keys contain enough randomness, so a fast hash is sufficient.
+//
+// Scoped keys additionally carry scopes and an expiry, which are authenticated by package data.
+// They can be verified without any storage, but like all ids of package data they become invalid whenever the program restarts.
+// This makes them suitable for short-lived delegated keys, not as a replacement for stored keys.
+package apikey
@@ -0,0 +1,168 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apikey
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/Top-Ranger/auth/data"
+)
+
+const (
+	// scopedVersion is the first byte of the body of a scoped key. It also protects leading zero bytes in the base62 encoding.
+	scopedVersion = 1
+
+	// scopedRandomSize is the number of random bytes in a scoped key, which make every key unique (so that it can be revoked by its hash).
+	scopedRandomSize = 16
+
+	// macSize is the size of an id of package data.
+	macSize = 32
+)
+
+var (
+	// ErrInvalid is returned if the scopes or the expiry of a scoped key were not created by GenerateScoped or were modified.
+	ErrInvalid = errors.New("apikey: invalid key")
+
+	// ErrExpired is returned if a scoped key is expired.
+	ErrExpired = errors.New("apikey: key expired")
+)
+
+// Claims are the scopes and expiry contained in a scoped key.
+type Claims struct {
+	// Scopes contains the permissions of the key. Scopes must not be empty.
+	Scopes []string
+	// Expires is the time the key expires. The zero value means that the key does not expire (but it still becomes invalid on restart).
+	// Other times must be after 1970-01-01 00:00:00 UTC and are truncated to seconds.
+	Expires time.Time
+}
+
+// HasScope returns whether c contains scope.
+func (c Claims) HasScope(scope string) bool {
+	for i := range c.Scopes {
+		if c.Scopes[i] == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// GenerateScoped returns a new key with the given prefix containing claims. The claims are authenticated with package data and are not encrypted.
+// The key can be verified with VerifyScoped without any storage. Hash can still be used to revoke single keys.
+//
+// Can be used concurrent.
+func GenerateScoped(prefix string, claims Claims) (key string, err error) {
+	if !validPrefix(prefix) {
+		return "", ErrInvalidPrefix
+	}
+	if len(claims.Scopes) == 0 {
+		return "", errors.New("no scopes")
+	}
+	// 0 means no expiry, so earlier times can not be encoded.
+	if !claims.Expires.IsZero() && claims.Expires.Unix() <= 0 {
+		return "", errors.New("invalid expiry")
+	}
+
+	payload := make([]byte, 1+scopedRandomSize+8)
+	payload[0] = scopedVersion
+	_, err = rand.Read(payload[1 : 1+scopedRandomSize])
+	if err != nil {
+		return "", err
+	}
+	if !claims.Expires.IsZero() {
+		binary.BigEndian.PutUint64(payload[1+scopedRandomSize:], uint64(claims.Expires.Unix()))
+	}
+	for i := range claims.Scopes {
+		if claims.Scopes[i] == "" || len(claims.Scopes[i]) > math.MaxUint16 {
+			return "", errors.New("invalid scope")
+		}
+		payload = append(payload, byte(len(claims.Scopes[i])>>8), byte(len(claims.Scopes[i])))
+		payload = append(payload, claims.Scopes[i]...)
+	}
+
+	mac, err := data.Get(binding(prefix, payload))
+	if err != nil {
+		return "", err
+	}
+	payload = append(payload, mac...)
+	return build(prefix, new(big.Int).SetBytes(payload).Text(62)), nil
+}
+
+// VerifyScoped checks a key created by GenerateScoped and returns its claims.
+//
+// ErrMalformed is returned if the format or checksum is wrong, ErrInvalid if the claims can not be verified and ErrExpired if the key is expired.
+//
+// Can be used concurrent.
+func VerifyScoped(key string, now time.Time) (claims Claims, err error) {
+	prefix, body, err := split(key)
+	if err != nil {
+		return
+	}
+
+	// Only accept the canonical encoding, so that the hash of a key can not be changed by adding leading zeros.
+	n, ok := new(big.Int).SetString(body, 62)
+	if !ok || n.Text(62) != body {
+		err = ErrMalformed
+		return
+	}
+	payload := n.Bytes()
+	if len(payload) < 1+scopedRandomSize+8+macSize || payload[0] != scopedVersion {
+		err = ErrInvalid
+		return
+	}
+	payload, mac := payload[:len(payload)-macSize], payload[len(payload)-macSize:]
+	if !data.Verify(mac, binding(prefix, payload)) {
+		err = ErrInvalid
+		return
+	}
+
+	if expires := binary.BigEndian.Uint64(payload[1+scopedRandomSize:]); expires != 0 {
+		claims.Expires = time.Unix(int64(expires), 0)
+	}
+	scopes := payload[1+scopedRandomSize+8:]
+	for len(scopes) > 0 {
+		if len(scopes) < 2 {
+			err = ErrInvalid
+			return
+		}
+		l := int(scopes[0])<<8 | int(scopes[1])
+		if len(scopes) < 2+l {
+			err = ErrInvalid
+			return
+		}
+		claims.Scopes = append(claims.Scopes, string(scopes[2:2+l]))
+		scopes = scopes[2+l:]
+	}
+
+	if !claims.Expires.IsZero() && now.After(claims.Expires) {
+		err = ErrExpired
+	}
+	return
+}
+
+// binding returns the data the MAC of a scoped key is computed over.
+func binding(prefix string, payload []byte) []byte {
+	b := make([]byte, 0, len("apikey")+1+len(prefix)+1+len(payload))
+	b = append(b, "apikey"...)
+	b = append(b, 0)
+	b = append(b, prefix...)
+	b = append(b, 0)
+	return append(b, payload...)
+}
@@ -0,0 +1,148 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apikey
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScoped(t *testing.T) {
+	now := time.Now()
+	tests := map[string]Claims{
+		"scopes":     {Scopes: []string{"read", "write:reports", "ünicode"}, Expires: now.Add(time.Hour)},
+		"no expiry":  {Scopes: []string{"read"}},
+		"long scope": {Scopes: []string{strings.Repeat("a", 300)}},
+	}
+
+	for k := range tests {
+		t.Run(k, func(t *testing.T) {
+			key, err := GenerateScoped("svc", tests[k])
+			if err != nil {
+				t.Logf("error occured: %s", err.Error())
+				t.FailNow()
+			}
+			prefix, err := Check(key)
+			if err != nil || prefix != "svc" {
+				t.Errorf("check failed (is: %s %v, should: svc <nil>)", prefix, err)
+			}
+
+			c, err := VerifyScoped(key, now)
+			if err != nil {
+				t.Logf("error occured: %s", err.Error())
+				t.FailNow()
+			}
+			if !reflect.DeepEqual(c.Scopes, tests[k].Scopes) {
+				t.Errorf("wrong scopes (is: %v, should: %v)", c.Scopes, tests[k].Scopes)
+			}
+			if c.Expires.Unix() != tests[k].Expires.Unix() && !(c.Expires.IsZero() && tests[k].Expires.IsZero()) {
+				t.Errorf("wrong expiry (is: %v, should: %v)", c.Expires, tests[k].Expires)
+			}
+		})
+	}
+}
+
+func TestScopedExpired(t *testing.T) {
+	now := time.Now()
+	key, err := GenerateScoped("svc", Claims{Scopes: []string{"read"}, Expires: now.Add(time.Minute)})
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	_, err = VerifyScoped(key, now.Add(2*time.Minute))
+	if !errors.Is(err, ErrExpired) {
+		t.Errorf("expired key not rejected (is: %v, should: %v)", err, ErrExpired)
+	}
+}
+
+func TestScopedInvalid(t *testing.T) {
+	_, err := GenerateScoped("svc", Claims{Scopes: []string{""}})
+	if err == nil {
+		t.Errorf("empty scope not rejected")
+	}
+	_, err = GenerateScoped("svc", Claims{Expires: time.Now().Add(time.Hour)})
+	if err == nil {
+		t.Errorf("key without scopes not rejected")
+	}
+	for _, expires := range []time.Time{time.Unix(0, 0), time.Unix(-1, 0), time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC)} {
+		_, err = GenerateScoped("svc", Claims{Scopes: []string{"read"}, Expires: expires})
+		if err == nil {
+			t.Errorf("expiry %v not rejected", expires)
+		}
+	}
+	_, err = GenerateScoped("s_vc", Claims{Scopes: []string{"read"}})
+	if !errors.Is(err, ErrInvalidPrefix) {
+		t.Errorf("invalid prefix not rejected (is: %v, should: %v)", err, ErrInvalidPrefix)
+	}
+
+	key, err := GenerateScoped("svc", Claims{Scopes: []string{"read"}})
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	_, body, err := split(key)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+
+	// Keys with a valid checksum but modified content or prefix.
+	c := byte('a')
+	if body[10] == c {
+		c = 'b'
+	}
+	plain, err := Generate("svc")
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	tests := map[string]string{
+		"modified": build("svc", body[:10]+string(c)+body[11:]),
+		"prefix":   build("other", body),
+		"plain":    plain,
+	}
+	for k := range tests {
+		t.Run(k, func(t *testing.T) {
+			_, err := VerifyScoped(tests[k], time.Now())
+			if !errors.Is(err, ErrInvalid) {
+				t.Errorf("key not rejected (is: %v, should: %v)", err, ErrInvalid)
+			}
+		})
+	}
+
+	_, err = VerifyScoped(build("svc", "0"+body), time.Now())
+	if !errors.Is(err, ErrMalformed) {
+		t.Errorf("non-canonical key not rejected (is: %v, should: %v)", err, ErrMalformed)
+	}
+
+	_, err = VerifyScoped(key+"x", time.Now())
+	if !errors.Is(err, ErrMalformed) {
+		t.Errorf("malformed key not rejected (is: %v, should: %v)", err, ErrMalformed)
+	}
+}
+
+func TestClaimsHasScope(t *testing.T) {
+	c := Claims{Scopes: []string{"read", "write"}}
+	if !c.HasScope("read") || !c.HasScope("write") {
+		t.Errorf("scope not found")
+	}
+	if c.HasScope("admin") || c.HasScope("") {
+		t.Errorf("unknown scope found")
+	}
+}
@@ -0,0 +1,106 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auditlog
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+)
+
+// Backend stores the lines of a log. Records are only appended, never changed.
+//
+// All methods must be safe for concurrent use.
+type Backend interface {
+	// Append adds a line (without newline) to the end of the log.
+	Append(line []byte) error
+	// Open returns a reader for the complete log, with every line terminated by a newline.
+	Open() (io.ReadCloser, error)
+}
+
+// MemoryBackend is an in-memory Backend.
+// The log is lost whenever the program restarts.
+type MemoryBackend struct {
+	m   sync.Mutex
+	log []byte
+}
+
+// Append adds a line to the log. See Backend for more information.
+func (b *MemoryBackend) Append(line []byte) error {
+	b.m.Lock()
+	defer b.m.Unlock()
+	b.log = append(b.log, line...)
+	b.log = append(b.log, '\n')
+	return nil
+}
+
+// Open returns a reader for a copy of the log. See Backend for more information.
+func (b *MemoryBackend) Open() (io.ReadCloser, error) {
+	b.m.Lock()
+	defer b.m.Unlock()
+	return ioutil.NopCloser(bytes.NewReader(append([]byte(nil), b.log...))), nil
+}
+
+// FileBackend is a Backend writing to a file, which is only opened for appending.
+type FileBackend struct {
+	m    sync.Mutex
+	path string
+	f    *os.File
+	// Sync determines whether the file is synced to the disk after every record.
+	Sync bool
+}
+
+// NewFileBackend returns a FileBackend using the file at path. The file is created if it does not exist.
+func NewFileBackend(path string) (*FileBackend, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &FileBackend{path: path, f: f}, nil
+}
+
+// Append adds a line to the file. See Backend for more information.
+// The line is written with a single call, so that concurrent writers do not interleave.
+func (b *FileBackend) Append(line []byte) error {
+	l := make([]byte, len(line)+1)
+	copy(l, line)
+	l[len(line)] = '\n'
+
+	b.m.Lock()
+	defer b.m.Unlock()
+	_, err := b.f.Write(l)
+	if err != nil {
+		return err
+	}
+	if b.Sync {
+		return b.f.Sync()
+	}
+	return nil
+}
+
+// Open opens the file for reading. See Backend for more information.
+func (b *FileBackend) Open() (io.ReadCloser, error) {
+	return os.Open(b.path)
+}
+
+// Close closes the file. Afterwards, no more records can be appended.
+func (b *FileBackend) Close() error {
+	b.m.Lock()
+	defer b.m.Unlock()
+	return b.f.Close()
+}
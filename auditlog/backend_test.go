@@ -0,0 +1,159 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auditlog
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func readBackend(t *testing.T, b Backend) []byte {
+	rc, err := b.Open()
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	defer rc.Close()
+	content, err := ioutil.ReadAll(rc)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	return content
+}
+
+func testBackend(t *testing.T, b Backend) {
+	if content := readBackend(t, b); len(content) != 0 {
+		t.Errorf("new backend not empty (is: %s)", content)
+	}
+	for _, l := range []string{"a", "b"} {
+		err := b.Append([]byte(l))
+		if err != nil {
+			t.Logf("error occured: %s", err.Error())
+			t.FailNow()
+		}
+	}
+	if content := readBackend(t, b); string(content) != "a\nb\n" {
+		t.Errorf("wrong content (is: %q, should: %q)", content, "a\nb\n")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := b.Append(bytes.Repeat([]byte("x"), 1000))
+			if err != nil {
+				t.Errorf("error occured: %s", err.Error())
+			}
+		}()
+	}
+	wg.Wait()
+	content := readBackend(t, b)
+	for _, l := range bytes.Split(bytes.TrimSpace(content), []byte{'\n'})[2:] {
+		if len(l) != 1000 {
+			t.Errorf("lines interleaved (length: %d)", len(l))
+		}
+	}
+}
+
+func TestMemoryBackend(t *testing.T) {
+	testBackend(t, new(MemoryBackend))
+}
+
+func TestFileBackend(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auditlog")
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.log")
+
+	b, err := NewFileBackend(path)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	b.Sync = true
+	testBackend(t, b)
+	err = b.Close()
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+
+	// The log survives reopening and is continued.
+	b, err = NewFileBackend(path)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	defer b.Close()
+	before := readBackend(t, b)
+	err = b.Append([]byte("c"))
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if content := readBackend(t, b); string(content) != string(before)+"c\n" {
+		t.Errorf("file not appended")
+	}
+}
+
+func TestFileBackendLogger(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auditlog")
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.log")
+
+	for i := 0; i < 2; i++ {
+		b, err := NewFileBackend(path)
+		if err != nil {
+			t.Logf("error occured: %s", err.Error())
+			t.FailNow()
+		}
+		testLog(t, 5, &Logger{Backend: b, Key: testKey})
+		err = b.Close()
+		if err != nil {
+			t.Logf("error occured: %s", err.Error())
+			t.FailNow()
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	defer f.Close()
+	last, err := (&Verifier{Key: testKey}).Verify(f)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if last.Seq != 10 || last.Time.Before(time.Unix(1600000000, 0)) {
+		t.Errorf("wrong last record (is: %+v)", last)
+	}
+}
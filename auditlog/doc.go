@@ -0,0 +1,24 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package auditlog contains a tamper-evident audit log.
+// The log consists of JSON records, one per line. The MAC of every record covers the MAC of the previous record, so editing, deleting or reordering records breaks the chain.
+// A Verifier scans a log and reports the first broken link.
+//
+// Periodic checkpoints contain the position in the chain signed with a data.SigningKey. They allow verifying a log from the middle, e.g. after old records were archived.
+// Since only the holder of the private key can create checkpoints, they also show that the chain was not recreated from scratch by someone knowing the MAC key.
+//
+// The MAC key must be configured explicitly and stored, since the log has to be verified and continued after the program restarts.
+package auditlog
@@ -0,0 +1,165 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auditlog
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Top-Ranger/auth/data"
+)
+
+// Logger appends records to a log.
+// On first use, the existing log is read and verified, so that new records continue the chain. A broken log is not continued.
+//
+// Can be used concurrent. Only one Logger may write to a log at a time.
+type Logger struct {
+	// Backend stores the log.
+	Backend Backend
+	// Key is the MAC key of the log. It is required and must stay the same for the whole log, e.g. across restarts.
+	Key []byte
+	// SigningKey signs checkpoints. If it is nil, no checkpoints can be created.
+	SigningKey *data.SigningKey
+	// CheckpointInterval creates a checkpoint automatically after the given number of entries. If it is zero, checkpoints are only created by Checkpoint.
+	CheckpointInterval int
+
+	m               sync.Mutex
+	loaded          bool
+	key             []byte
+	seq             uint64
+	prev            []byte
+	sinceCheckpoint int
+}
+
+// Append adds an entry containing the JSON encoding of v and returns the new record.
+// If a checkpoint is due, it is appended afterwards.
+func (l *Logger) Append(now time.Time, v interface{}) (Record, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return Record{}, err
+	}
+
+	l.m.Lock()
+	defer l.m.Unlock()
+	err = l.load()
+	if err != nil {
+		return Record{}, err
+	}
+
+	r, err := l.append(Record{Time: now, Type: TypeEntry, Data: b})
+	if err != nil {
+		return Record{}, err
+	}
+	l.sinceCheckpoint++
+	if l.CheckpointInterval > 0 && l.sinceCheckpoint >= l.CheckpointInterval {
+		_, err = l.checkpoint(now)
+		if err != nil {
+			return r, err
+		}
+	}
+	return r, nil
+}
+
+// Checkpoint appends a checkpoint signed with SigningKey and returns it.
+func (l *Logger) Checkpoint(now time.Time) (Record, error) {
+	l.m.Lock()
+	defer l.m.Unlock()
+	err := l.load()
+	if err != nil {
+		return Record{}, err
+	}
+	return l.checkpoint(now)
+}
+
+// checkpoint appends a checkpoint. l.m must be held.
+func (l *Logger) checkpoint(now time.Time) (Record, error) {
+	if l.SigningKey == nil {
+		return Record{}, errors.New("auditlog: no signing key")
+	}
+	now = now.UTC()
+	id, err := l.SigningKey.Get(checkpointMessage(l.seq+1, now, l.prev))
+	if err != nil {
+		return Record{}, err
+	}
+	r, err := l.append(Record{Time: now, Type: TypeCheckpoint, Prev: encoding.EncodeToString(l.prev), Checkpoint: encoding.EncodeToString(id)})
+	if err != nil {
+		return Record{}, err
+	}
+	l.sinceCheckpoint = 0
+	return r, nil
+}
+
+// append adds r as next record. l.m must be held.
+func (l *Logger) append(r Record) (Record, error) {
+	r.Seq = l.seq + 1
+	r.Time = r.Time.UTC()
+	mac, err := r.mac(l.key, l.prev)
+	if err != nil {
+		return Record{}, err
+	}
+	r.MAC = encoding.EncodeToString(mac)
+
+	b, err := json.Marshal(r)
+	if err != nil {
+		return Record{}, err
+	}
+	err = l.Backend.Append(b)
+	if err != nil {
+		return Record{}, err
+	}
+	l.seq = r.Seq
+	l.prev = mac
+	return r, nil
+}
+
+// load reads the existing log on first use. l.m must be held.
+func (l *Logger) load() error {
+	if l.loaded {
+		return nil
+	}
+	if l.Backend == nil {
+		return errors.New("auditlog: no backend")
+	}
+	if len(l.Key) == 0 {
+		return ErrNoKey
+	}
+
+	rc, err := l.Backend.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	// Signatures of checkpoints are not checked, since they might have been created with older keys.
+	v := Verifier{Key: l.Key}
+	last, err := v.scan(rc, startAny)
+	if err != nil {
+		return err
+	}
+
+	l.key = append([]byte(nil), l.Key...)
+	l.seq = last.Seq
+	l.prev = make([]byte, hashSize)
+	if last.Seq != 0 {
+		l.prev, err = encoding.DecodeString(last.MAC)
+		if err != nil {
+			return err
+		}
+	}
+	l.loaded = true
+	return nil
+}
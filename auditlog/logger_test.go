@@ -0,0 +1,175 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auditlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/Top-Ranger/auth/data"
+)
+
+var testKey = []byte("0123456789abcdef0123456789abcdef")
+
+func testSigningKey(t *testing.T) (*data.SigningKey, *data.PublicKeySet) {
+	k, err := data.GenerateSigningKey("audit")
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	s := new(data.PublicKeySet)
+	err = s.Add(k.KeyID(), k.PublicKey())
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	return k, s
+}
+
+// testLog returns a log with n entries.
+func testLog(t *testing.T, n int, l *Logger) []byte {
+	now := time.Unix(1600000000, 0)
+	for i := 0; i < n; i++ {
+		_, err := l.Append(now.Add(time.Duration(i)*time.Second), map[string]interface{}{"event": "login", "user": "alice", "n": i, "note": "<script>&"})
+		if err != nil {
+			t.Logf("error occured: %s", err.Error())
+			t.FailNow()
+		}
+	}
+	rc, err := l.Backend.Open()
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	defer rc.Close()
+	b, err := ioutil.ReadAll(rc)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	return b
+}
+
+func TestLoggerAppend(t *testing.T) {
+	l := &Logger{Backend: new(MemoryBackend), Key: testKey}
+	r, err := l.Append(time.Now(), "first")
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if r.Seq != 1 || r.Type != TypeEntry || string(r.Data) != `"first"` || r.MAC == "" {
+		t.Errorf("wrong record (is: %+v)", r)
+	}
+	r, err = l.Append(time.Now(), "second")
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if r.Seq != 2 {
+		t.Errorf("wrong sequence number (is: %d, should: 2)", r.Seq)
+	}
+
+	_, err = l.Append(time.Now(), func() {})
+	if err == nil {
+		t.Errorf("value which can not be encoded not rejected")
+	}
+	_, err = l.Checkpoint(time.Now())
+	if err == nil {
+		t.Errorf("checkpoint without signing key not rejected")
+	}
+	_, err = (&Logger{}).Append(time.Now(), "x")
+	if err == nil {
+		t.Errorf("logger without backend not rejected")
+	}
+}
+
+func TestLoggerCheckpointInterval(t *testing.T) {
+	k, _ := testSigningKey(t)
+	l := &Logger{Backend: new(MemoryBackend), Key: testKey, SigningKey: k, CheckpointInterval: 3}
+	b := testLog(t, 7, l)
+
+	var types []string
+	for _, line := range bytes.Split(bytes.TrimSpace(b), []byte{'\n'}) {
+		var r Record
+		err := json.Unmarshal(line, &r)
+		if err != nil {
+			t.Logf("error occured: %s", err.Error())
+			t.FailNow()
+		}
+		types = append(types, r.Type)
+	}
+	should := []string{TypeEntry, TypeEntry, TypeEntry, TypeCheckpoint, TypeEntry, TypeEntry, TypeEntry, TypeCheckpoint, TypeEntry}
+	if len(types) != len(should) {
+		t.Fatalf("wrong records (is: %v, should: %v)", types, should)
+	}
+	for i := range types {
+		if types[i] != should[i] {
+			t.Errorf("wrong record %d (is: %s, should: %s)", i, types[i], should[i])
+		}
+	}
+}
+
+func TestLoggerResume(t *testing.T) {
+	backend := new(MemoryBackend)
+	testLog(t, 3, &Logger{Backend: backend, Key: testKey})
+
+	// A new logger continues the chain.
+	l := &Logger{Backend: backend, Key: testKey}
+	r, err := l.Append(time.Now(), "resumed")
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if r.Seq != 4 {
+		t.Errorf("wrong sequence number (is: %d, should: 4)", r.Seq)
+	}
+	rc, err := backend.Open()
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	last, err := (&Verifier{Key: testKey}).Verify(rc)
+	if err != nil {
+		t.Errorf("resumed log not valid: %s", err.Error())
+	}
+	if last.Seq != 4 {
+		t.Errorf("wrong last record (is: %d, should: 4)", last.Seq)
+	}
+
+	// A logger with another key does not continue the chain.
+	_, err = (&Logger{Backend: backend, Key: []byte("other key")}).Append(time.Now(), "x")
+	var le *LinkError
+	if !errors.As(err, &le) || !errors.Is(err, ErrMAC) {
+		t.Errorf("log with other key continued (is: %v, should: %v)", err, ErrMAC)
+	}
+}
+
+func TestLoggerNoKey(t *testing.T) {
+	_, err := (&Logger{Backend: new(MemoryBackend)}).Append(time.Now(), "x")
+	if !errors.Is(err, ErrNoKey) {
+		t.Errorf("logger without key not rejected (is: %v, should: %v)", err, ErrNoKey)
+	}
+
+	b := testLog(t, 3, &Logger{Backend: new(MemoryBackend), Key: testKey})
+	_, err = new(Verifier).Verify(bytes.NewReader(b))
+	if !errors.Is(err, ErrNoKey) {
+		t.Errorf("verifier without key not rejected (is: %v, should: %v)", err, ErrNoKey)
+	}
+}
@@ -0,0 +1,135 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auditlog
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+)
+
+const (
+	// TypeEntry is the type of records containing data.
+	TypeEntry = "entry"
+
+	// TypeCheckpoint is the type of checkpoint records.
+	TypeCheckpoint = "checkpoint"
+)
+
+var (
+	// ErrMalformed is returned if a record can not be parsed.
+	ErrMalformed = errors.New("auditlog: malformed record")
+
+	// ErrSequence is returned if a record has an unexpected sequence number (e.g. because records were deleted or reordered).
+	ErrSequence = errors.New("auditlog: wrong sequence number")
+
+	// ErrMAC is returned if the MAC of a record does not match (e.g. because it was edited).
+	ErrMAC = errors.New("auditlog: invalid MAC")
+
+	// ErrCheckpoint is returned if the signature of a checkpoint is invalid.
+	ErrCheckpoint = errors.New("auditlog: invalid checkpoint")
+
+	// ErrNoKey is returned if no MAC key is configured.
+	ErrNoKey = errors.New("auditlog: no MAC key")
+)
+
+var (
+	hashGenerator = sha256.New
+	hashSize      = hashGenerator().Size()
+	encoding      = base64.RawURLEncoding
+)
+
+// LinkError reports the first broken link of a log.
+type LinkError struct {
+	// Line is the line of the broken record, starting at 1.
+	Line int
+	// Seq is the sequence number of the last valid record before the broken record (0 if there is none).
+	Seq uint64
+	// Err is the reason (ErrMalformed, ErrSequence, ErrMAC or ErrCheckpoint).
+	Err error
+}
+
+// Error implements error.
+func (e *LinkError) Error() string {
+	return "auditlog: broken link at line " + strconv.Itoa(e.Line) + " after record " + strconv.FormatUint(e.Seq, 10) + ": " + e.Err.Error()
+}
+
+// Unwrap returns the reason.
+func (e *LinkError) Unwrap() error {
+	return e.Err
+}
+
+// Record is a single record of a log.
+type Record struct {
+	// Seq is the sequence number of the record, starting at 1.
+	Seq uint64 `json:"seq"`
+	// Time is the time the record was appended.
+	Time time.Time `json:"time"`
+	// Type is TypeEntry or TypeCheckpoint.
+	Type string `json:"type"`
+	// Data contains the data of entries.
+	Data json.RawMessage `json:"data,omitempty"`
+	// Prev contains the MAC of the previous record in checkpoints, so that the chain can be verified starting at the checkpoint.
+	Prev string `json:"prev,omitempty"`
+	// Checkpoint contains the signature of checkpoints.
+	Checkpoint string `json:"checkpoint,omitempty"`
+	// MAC authenticates the record and the MAC of the previous record.
+	MAC string `json:"mac"`
+}
+
+// mac returns the MAC of r following the record with the MAC prev.
+func (r *Record) mac(key, prev []byte) ([]byte, error) {
+	var d bytes.Buffer
+	if len(r.Data) != 0 {
+		err := json.Compact(&d, r.Data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	h := hmac.New(hashGenerator, key)
+	h.Write([]byte("auditlog record\x00"))
+	h.Write(prev)
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], r.Seq)
+	h.Write(b[:])
+	binary.BigEndian.PutUint64(b[:], uint64(r.Time.UnixNano()))
+	h.Write(b[:])
+	for _, f := range [][]byte{[]byte(r.Type), d.Bytes(), []byte(r.Prev), []byte(r.Checkpoint)} {
+		binary.BigEndian.PutUint64(b[:], uint64(len(f)))
+		h.Write(b[:])
+		h.Write(f)
+	}
+	return h.Sum(nil), nil
+}
+
+// checkpointMessage returns the data signed by a checkpoint.
+func checkpointMessage(seq uint64, t time.Time, prev []byte) []byte {
+	b := make([]byte, 0, 32+16+len(prev))
+	b = append(b, "auditlog checkpoint\x00"...)
+	var n [8]byte
+	binary.BigEndian.PutUint64(n[:], seq)
+	b = append(b, n[:]...)
+	binary.BigEndian.PutUint64(n[:], uint64(t.UnixNano()))
+	b = append(b, n[:]...)
+	return append(b, prev...)
+}
@@ -0,0 +1,171 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auditlog
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"encoding/json"
+	"io"
+
+	"github.com/Top-Ranger/auth/data"
+)
+
+// start determines where a scanned log may start.
+type start int
+
+const (
+	// startGenesis requires the log to start with the first record.
+	startGenesis start = iota
+	// startCheckpoint requires the log to start with a checkpoint.
+	startCheckpoint
+	// startAny allows both.
+	startAny
+)
+
+// Verifier verifies logs.
+//
+// Can be used concurrent.
+type Verifier struct {
+	// Key is the MAC key of the log. It is required.
+	Key []byte
+	// PublicKeys verifies the signatures of checkpoints. If it is nil, checkpoints are only verified as part of the chain and VerifyFromCheckpoint can not be used.
+	PublicKeys *data.PublicKeySet
+}
+
+// Verify verifies the complete log in r, which must start with the first record.
+// It returns the last record. If the log is broken, the last valid record and a *LinkError describing the first broken link are returned.
+//
+// Verify can not detect records deleted from the end of the log. Compare the returned record with a recent checkpoint or sequence number stored elsewhere to detect this.
+func (v *Verifier) Verify(r io.Reader) (last Record, err error) {
+	return v.scan(r, startGenesis)
+}
+
+// VerifyFromCheckpoint verifies a log in r starting with a checkpoint (e.g. after older records were archived).
+// The signature of the checkpoint is verified with PublicKeys, then the chain is verified starting at the checkpoint.
+// See Verify for more information.
+func (v *Verifier) VerifyFromCheckpoint(r io.Reader) (last Record, err error) {
+	if v.PublicKeys == nil {
+		return Record{}, &LinkError{Line: 1, Err: ErrCheckpoint}
+	}
+	return v.scan(r, startCheckpoint)
+}
+
+// scan verifies all records in r.
+func (v *Verifier) scan(r io.Reader, s start) (last Record, err error) {
+	if len(v.Key) == 0 {
+		return Record{}, ErrNoKey
+	}
+
+	c := chain{key: v.Key, publicKeys: v.PublicKeys, start: s}
+	br := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		b, err := br.ReadBytes('\n')
+		if len(b) == 0 && err == io.EOF {
+			return c.last, nil
+		}
+		if err != nil && err != io.EOF {
+			return c.last, err
+		}
+		err = c.next(bytes.TrimSuffix(b, []byte{'\n'}))
+		if err != nil {
+			return c.last, &LinkError{Line: line, Seq: c.last.Seq, Err: err}
+		}
+	}
+}
+
+// chain contains the state of a scanned log.
+type chain struct {
+	key        []byte
+	publicKeys *data.PublicKeySet
+	start      start
+	started    bool
+	prev       []byte
+	last       Record
+}
+
+// next verifies the record in line.
+func (c *chain) next(line []byte) error {
+	var r Record
+	d := json.NewDecoder(bytes.NewReader(line))
+	d.DisallowUnknownFields()
+	err := d.Decode(&r)
+	if err != nil || d.More() {
+		return ErrMalformed
+	}
+	mac, err := encoding.DecodeString(r.MAC)
+	if err != nil || len(mac) != hashSize {
+		return ErrMalformed
+	}
+
+	switch r.Type {
+	case TypeEntry:
+		if len(r.Data) == 0 || r.Prev != "" || r.Checkpoint != "" {
+			return ErrMalformed
+		}
+	case TypeCheckpoint:
+		if len(r.Data) != 0 || r.Prev == "" || r.Checkpoint == "" {
+			return ErrMalformed
+		}
+	default:
+		return ErrMalformed
+	}
+
+	if !c.started {
+		c.started = true
+		fromCheckpoint := c.start == startCheckpoint || (c.start == startAny && r.Type == TypeCheckpoint && r.Seq > 1)
+		if fromCheckpoint {
+			if r.Type != TypeCheckpoint || r.Seq < 1 {
+				return ErrCheckpoint
+			}
+			prev, err := encoding.DecodeString(r.Prev)
+			if err != nil || len(prev) != hashSize {
+				return ErrMalformed
+			}
+			c.last.Seq = r.Seq - 1
+			c.prev = prev
+		} else {
+			c.prev = make([]byte, hashSize)
+		}
+	}
+
+	if r.Seq != c.last.Seq+1 {
+		return ErrSequence
+	}
+	if r.Type == TypeCheckpoint {
+		if r.Prev != encoding.EncodeToString(c.prev) {
+			return ErrMAC
+		}
+		if c.publicKeys != nil {
+			id, err := encoding.DecodeString(r.Checkpoint)
+			if err != nil || !c.publicKeys.Verify(id, checkpointMessage(r.Seq, r.Time, c.prev)) {
+				return ErrCheckpoint
+			}
+		}
+	}
+
+	should, err := r.mac(c.key, c.prev)
+	if err != nil {
+		return ErrMalformed
+	}
+	if !hmac.Equal(should, mac) {
+		return ErrMAC
+	}
+	c.prev = mac
+	c.last = r
+	return nil
+}
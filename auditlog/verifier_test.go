@@ -0,0 +1,181 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auditlog
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func lines(b []byte) [][]byte {
+	return bytes.SplitAfter(bytes.TrimSuffix(b, []byte{'\n'}), []byte{'\n'})
+}
+
+func join(l [][]byte) []byte {
+	var b []byte
+	for i := range l {
+		b = append(b, l[i]...)
+		if !bytes.HasSuffix(b, []byte{'\n'}) {
+			b = append(b, '\n')
+		}
+	}
+	return b
+}
+
+func TestVerify(t *testing.T) {
+	k, keys := testSigningKey(t)
+	b := testLog(t, 10, &Logger{Backend: new(MemoryBackend), Key: testKey, SigningKey: k, CheckpointInterval: 4})
+
+	v := &Verifier{Key: testKey, PublicKeys: keys}
+	last, err := v.Verify(bytes.NewReader(b))
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if last.Seq != 12 {
+		t.Errorf("wrong last record (is: %d, should: 12)", last.Seq)
+	}
+
+	// Checkpoints are only verified as part of the chain without public keys.
+	_, err = (&Verifier{Key: testKey}).Verify(bytes.NewReader(b))
+	if err != nil {
+		t.Errorf("log not accepted without public keys: %s", err.Error())
+	}
+
+	last, err = v.Verify(bytes.NewReader(nil))
+	if err != nil || last.Seq != 0 {
+		t.Errorf("empty log not accepted (is: %d %v)", last.Seq, err)
+	}
+}
+
+func TestVerifyBroken(t *testing.T) {
+	k, keys := testSigningKey(t)
+	b := testLog(t, 10, &Logger{Backend: new(MemoryBackend), Key: testKey, SigningKey: k, CheckpointInterval: 4})
+	l := lines(b)
+
+	edited := append([][]byte(nil), l...)
+	edited[2] = bytes.Replace(l[2], []byte("alice"), []byte("mallory"), 1)
+	deleted := append(append([][]byte(nil), l[:3]...), l[4:]...)
+	reordered := append([][]byte(nil), l...)
+	reordered[2], reordered[3] = reordered[3], reordered[2]
+	duplicated := append(append([][]byte(nil), l[:4]...), l[3:]...)
+	extraField := append([][]byte(nil), l...)
+	extraField[5] = bytes.Replace(l[5], []byte(`{"seq"`), []byte(`{"extra":1,"seq"`), 1)
+	partial := append([][]byte(nil), l...)
+	partial[len(partial)-1] = l[len(l)-1][:20]
+
+	// Re-sign a checkpoint with another key. The checkpoint is the fifth record.
+	other, _ := testSigningKey(t)
+	forged := append([][]byte(nil), l...)
+	forgedBackend := &MemoryBackend{log: join(l[:4])}
+	_, err := (&Logger{Backend: forgedBackend, Key: testKey, SigningKey: other}).Checkpoint(time.Unix(1600000003, 0))
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	forged[4] = lines(forgedBackend.log)[4]
+
+	tests := []struct {
+		name string
+		log  []byte
+		line int
+		seq  uint64
+		err  error
+	}{
+		{"edited", join(edited), 3, 2, ErrMAC},
+		{"deleted", join(deleted), 4, 3, ErrSequence},
+		{"reordered", join(reordered), 3, 2, ErrSequence},
+		{"duplicated", join(duplicated), 5, 4, ErrSequence},
+		{"first deleted", join(l[1:]), 1, 0, ErrSequence},
+		{"extra field", join(extraField), 6, 5, ErrMalformed},
+		{"partial", join(partial), len(l), uint64(len(l) - 1), ErrMalformed},
+		{"forged checkpoint", join(forged), 5, 4, ErrCheckpoint},
+		{"empty line", append(append([]byte(nil), b...), '\n'), len(l) + 1, uint64(len(l)), ErrMalformed},
+	}
+	v := &Verifier{Key: testKey, PublicKeys: keys}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := v.Verify(bytes.NewReader(tc.log))
+			var le *LinkError
+			if !errors.As(err, &le) {
+				t.Fatalf("broken log accepted (is: %v)", err)
+			}
+			if le.Line != tc.line || le.Seq != tc.seq || !errors.Is(err, tc.err) {
+				t.Errorf("wrong error (is: line %d, seq %d, %v, should: line %d, seq %d, %v)", le.Line, le.Seq, le.Err, tc.line, tc.seq, tc.err)
+			}
+			if !strings.Contains(err.Error(), "line") {
+				t.Errorf("error does not contain line: %s", err.Error())
+			}
+		})
+	}
+}
+
+func TestVerifyFromCheckpoint(t *testing.T) {
+	k, keys := testSigningKey(t)
+	b := testLog(t, 10, &Logger{Backend: new(MemoryBackend), Key: testKey, SigningKey: k, CheckpointInterval: 4})
+	l := lines(b)
+	v := &Verifier{Key: testKey, PublicKeys: keys}
+
+	// The fifth record is the first checkpoint.
+	archived := join(l[4:])
+	last, err := v.VerifyFromCheckpoint(bytes.NewReader(archived))
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if last.Seq != 12 {
+		t.Errorf("wrong last record (is: %d, should: 12)", last.Seq)
+	}
+
+	_, err = v.Verify(bytes.NewReader(archived))
+	if !errors.Is(err, ErrSequence) {
+		t.Errorf("log starting with checkpoint accepted by Verify (is: %v, should: %v)", err, ErrSequence)
+	}
+	_, err = v.VerifyFromCheckpoint(bytes.NewReader(join(l[5:])))
+	if !errors.Is(err, ErrCheckpoint) {
+		t.Errorf("log not starting with checkpoint accepted (is: %v, should: %v)", err, ErrCheckpoint)
+	}
+	_, err = (&Verifier{Key: testKey}).VerifyFromCheckpoint(bytes.NewReader(archived))
+	if !errors.Is(err, ErrCheckpoint) {
+		t.Errorf("verification without public keys not rejected (is: %v, should: %v)", err, ErrCheckpoint)
+	}
+
+	// Records after the checkpoint are still chained.
+	broken := append([][]byte(nil), l[4:]...)
+	broken[2] = bytes.Replace(broken[2], []byte("alice"), []byte("mallory"), 1)
+	_, err = v.VerifyFromCheckpoint(bytes.NewReader(join(broken)))
+	if !errors.Is(err, ErrMAC) {
+		t.Errorf("edited record after checkpoint not rejected (is: %v, should: %v)", err, ErrMAC)
+	}
+
+	// A logger can continue an archived log.
+	backend := &MemoryBackend{log: archived}
+	r, err := (&Logger{Backend: backend, Key: testKey}).Append(time.Now(), "continued")
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if r.Seq != 13 {
+		t.Errorf("wrong sequence number (is: %d, should: 13)", r.Seq)
+	}
+	_, err = v.VerifyFromCheckpoint(bytes.NewReader(backend.log))
+	if err != nil {
+		t.Errorf("continued log not valid: %s", err.Error())
+	}
+}
@@ -0,0 +1,306 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package captcha
+
+// This file contains a controller which adapts the difficulty of new challenges to the observed failure rate.
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// AdaptiveLevel describes the difficulty of new challenges on one level of an Adaptive controller.
+type AdaptiveLevel struct {
+	// RandomSize is the size of captchas generated on this level.
+	RandomSize int
+	// ProofOfWorkDifficulty is the difficulty of proof-of-work challenges generated on this level.
+	ProofOfWorkDifficulty int
+	// Noise is the amount of noise or distortion used when rendering captchas of this level, e.g. as image. 0 means no noise.
+	// Its meaning depends on the AdaptiveRenderFunc, since package captcha does not render captchas itself.
+	Noise int
+}
+
+// AdaptiveRenderFunc renders a captcha of the given level so that it can be shown to humans, e.g. as an image using the noise of the level.
+type AdaptiveRenderFunc func(captcha []byte, level AdaptiveLevel) ([]byte, error)
+
+// AdaptiveConfig contains the configuration of an Adaptive controller.
+type AdaptiveConfig struct {
+	// Levels contains the difficulty levels, starting with the easiest one. New keys start on the first level.
+	// At most 256 levels are supported.
+	Levels []AdaptiveLevel
+	// Window is the length of the sliding window over which failure rates are observed. The level of a key changes at most once per window.
+	Window time.Duration
+	// MinSamples is the number of verifications which have to be observed in a window before the level is raised.
+	MinSamples int
+	// RaiseFailureRate is the failure rate (between 0 and 1) at which the level is raised.
+	RaiseFailureRate float64
+	// LowerFailureRate is the failure rate (between 0 and 1) at which the level is lowered. It must be smaller than RaiseFailureRate.
+	// The level is also lowered if less than MinSamples verifications are observed.
+	LowerFailureRate float64
+	// Render renders captchas for GetTimedRendered. It might be nil if GetTimedRendered is not used.
+	Render AdaptiveRenderFunc
+}
+
+// AdaptiveConfigDefault returns a suggested default configuration for Adaptive.
+func AdaptiveConfigDefault() AdaptiveConfig {
+	return AdaptiveConfig{
+		Levels: []AdaptiveLevel{
+			{RandomSize: RandomSizeDefault, ProofOfWorkDifficulty: ProofOfWorkDifficultyDefault, Noise: 1},
+			{RandomSize: RandomSizeDefault + 2, ProofOfWorkDifficulty: ProofOfWorkDifficultyDefault + 2, Noise: 2},
+			{RandomSize: RandomSizeDefault + 4, ProofOfWorkDifficulty: ProofOfWorkDifficultyDefault + 4, Noise: 3},
+		},
+		Window:           5 * time.Minute,
+		MinSamples:       20,
+		RaiseFailureRate: 0.5,
+		LowerFailureRate: 0.2,
+	}
+}
+
+// Adaptive watches verification outcomes per key (e.g. an endpoint or a client) and raises the difficulty of new challenges (captcha size, image noise and proof-of-work difficulty) when failures spike.
+// When the failure rate drops again, the difficulty is lowered.
+//
+// The level is encoded in the id, so verification does not depend on the current level of a key. The state of the controller is only used to decide on the difficulty of new challenges.
+// Like all captchas, ids generated by an Adaptive are only valid as long as the hidden value is the same.
+//
+// Can be used concurrent.
+type Adaptive struct {
+	config AdaptiveConfig
+
+	m         sync.Mutex
+	states    map[string]*adaptiveState
+	lastPrune time.Time
+}
+
+type adaptiveCount struct {
+	success, failure int
+}
+
+type adaptiveState struct {
+	level       int
+	windowStart time.Time
+	current     adaptiveCount
+	previous    adaptiveCount
+	lastChange  time.Time
+	lastSeen    time.Time
+}
+
+// NewAdaptive returns a new Adaptive controller.
+// An error is returned if the configuration is invalid.
+func NewAdaptive(config AdaptiveConfig) (*Adaptive, error) {
+	if len(config.Levels) == 0 || len(config.Levels) > 256 {
+		return nil, errors.New("number of levels out of range")
+	}
+	for i := range config.Levels {
+		if config.Levels[i].RandomSize < 1 {
+			return nil, errors.New("randomSize must be positive")
+		}
+		if config.Levels[i].ProofOfWorkDifficulty < 1 || config.Levels[i].ProofOfWorkDifficulty > ProofOfWorkDifficultyMax {
+			return nil, errors.New("difficulty out of range")
+		}
+		if config.Levels[i].Noise < 0 {
+			return nil, errors.New("noise must not be negative")
+		}
+	}
+	if config.Window <= 0 {
+		return nil, errors.New("window must be positive")
+	}
+	if config.MinSamples < 0 {
+		return nil, errors.New("minSamples must not be negative")
+	}
+	if config.RaiseFailureRate < 0 || config.RaiseFailureRate > 1 || config.LowerFailureRate < 0 || config.LowerFailureRate > 1 {
+		return nil, errors.New("failure rates must be between 0 and 1")
+	}
+	if config.LowerFailureRate >= config.RaiseFailureRate {
+		return nil, errors.New("lowerFailureRate must be smaller than raiseFailureRate")
+	}
+
+	levels := make([]AdaptiveLevel, len(config.Levels))
+	copy(levels, config.Levels)
+	config.Levels = levels
+
+	return &Adaptive{
+		config: config,
+		states: make(map[string]*adaptiveState),
+	}, nil
+}
+
+// Level returns the difficulty of new challenges for key.
+func (a *Adaptive) Level(key string, now time.Time) AdaptiveLevel {
+	return a.config.Levels[a.levelIndex(key, now)]
+}
+
+// Record adds the outcome of a verification for key.
+// The verification functions of Adaptive call this automatically, so you only need it when verifying by other means.
+func (a *Adaptive) Record(key string, now time.Time, success bool) {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	s, ok := a.states[key]
+	if !ok {
+		s = &adaptiveState{windowStart: now}
+		a.states[key] = s
+	}
+	a.rotate(s, now)
+	if success {
+		s.current.success++
+	} else {
+		s.current.failure++
+	}
+	s.lastSeen = now
+	a.update(s, now)
+	a.prune(now)
+}
+
+// GetTimed returns one timed random id / captcha combination with the size of the current level of key.
+// See GetTimed for more information about timed captchas.
+//
+// Can be used concurrent.
+func (a *Adaptive) GetTimed(key string, start time.Time) (id, captcha []byte, err error) {
+	level := a.levelIndex(key, start)
+	i, c, err := GetTimed(start, a.config.Levels[level].RandomSize)
+	if err != nil {
+		return
+	}
+	id = append([]byte{byte(level)}, i...)
+	captcha = c
+	return
+}
+
+// GetTimedRendered returns one timed random id / captcha combination like GetTimed, together with the captcha rendered by the Render function of the configuration.
+// The level of the captcha is passed to Render, so that e.g. the image noise can be raised together with the size.
+//
+// Can be used concurrent.
+func (a *Adaptive) GetTimedRendered(key string, start time.Time) (id, captcha, rendered []byte, err error) {
+	if a.config.Render == nil {
+		err = errors.New("no render function")
+		return
+	}
+	id, captcha, err = a.GetTimed(key, start)
+	if err != nil {
+		return
+	}
+	rendered, err = a.config.Render(captcha, a.config.Levels[id[0]])
+	if err != nil {
+		id, captcha, rendered = nil, nil, nil
+	}
+	return
+}
+
+// VerifyTimed validates whether an id / captcha combination generated by GetTimed or GetTimedRendered is valid and in date.
+// The outcome is recorded for key.
+//
+// Can be used concurrent.
+func (a *Adaptive) VerifyTimed(key string, id, captcha []byte, now time.Time, validDuration time.Duration) bool {
+	result := false
+	if len(id) > 1 && int(id[0]) < len(a.config.Levels) {
+		// The captcha is authenticated by the id, so a modified level can not lead to a shorter captcha being accepted.
+		result = VerifyTimed(id[1:], captcha, now, validDuration, a.config.Levels[id[0]].RandomSize)
+	}
+	a.Record(key, now, result)
+	return result
+}
+
+// GetProofOfWork returns one timed proof-of-work challenge with the difficulty of the current level of key.
+// See GetProofOfWork for more information about proof-of-work challenges.
+//
+// Can be used concurrent.
+func (a *Adaptive) GetProofOfWork(key string, start time.Time) (id []byte, err error) {
+	return GetProofOfWork(start, a.config.Levels[a.levelIndex(key, start)].ProofOfWorkDifficulty)
+}
+
+// VerifyProofOfWork validates whether a nonce solves a challenge generated by GetProofOfWork.
+// The outcome is recorded for key.
+//
+// Can be used concurrent.
+func (a *Adaptive) VerifyProofOfWork(key string, id, nonce []byte, now time.Time, validDuration time.Duration) bool {
+	result := VerifyProofOfWork(id, nonce, now, validDuration)
+	a.Record(key, now, result)
+	return result
+}
+
+// levelIndex returns the current level of key.
+func (a *Adaptive) levelIndex(key string, now time.Time) int {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	s, ok := a.states[key]
+	if !ok {
+		return 0
+	}
+	a.update(s, now)
+	return s.level
+}
+
+// rotate moves the window of s so that it contains now.
+// a.m must be held.
+func (a *Adaptive) rotate(s *adaptiveState, now time.Time) {
+	elapsed := now.Sub(s.windowStart)
+	switch {
+	case elapsed >= 2*a.config.Window:
+		s.previous = adaptiveCount{}
+		s.current = adaptiveCount{}
+		s.windowStart = now
+	case elapsed >= a.config.Window:
+		s.previous = s.current
+		s.current = adaptiveCount{}
+		s.windowStart = s.windowStart.Add(a.config.Window)
+	}
+}
+
+// update raises or lowers the level of s based on the failure rate in the sliding window.
+// a.m must be held.
+func (a *Adaptive) update(s *adaptiveState, now time.Time) {
+	a.rotate(s, now)
+	if now.Sub(s.lastChange) < a.config.Window {
+		return
+	}
+
+	// The previous window is weighted by how much of it is still covered by the sliding window.
+	weight := 1 - float64(now.Sub(s.windowStart))/float64(a.config.Window)
+	if weight < 0 {
+		weight = 0
+	}
+	failure := float64(s.previous.failure)*weight + float64(s.current.failure)
+	total := float64(s.previous.success+s.previous.failure)*weight + float64(s.current.success+s.current.failure)
+
+	switch {
+	case total >= float64(a.config.MinSamples) && total > 0 && failure/total >= a.config.RaiseFailureRate:
+		if s.level < len(a.config.Levels)-1 {
+			s.level++
+			s.lastChange = now
+		}
+	case total < float64(a.config.MinSamples) || total == 0 || failure/total <= a.config.LowerFailureRate:
+		if s.level > 0 {
+			s.level--
+			s.lastChange = now
+		}
+	}
+}
+
+// prune removes keys which are on the first level and were not seen for some time. It runs at most once per window.
+// a.m must be held.
+func (a *Adaptive) prune(now time.Time) {
+	if now.Sub(a.lastPrune) < a.config.Window {
+		return
+	}
+	a.lastPrune = now
+	for k, s := range a.states {
+		if s.level == 0 && now.Sub(s.lastSeen) >= 2*a.config.Window {
+			delete(a.states, k)
+		}
+	}
+}
@@ -0,0 +1,325 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package captcha
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func testAdaptiveConfig() AdaptiveConfig {
+	return AdaptiveConfig{
+		Levels: []AdaptiveLevel{
+			{RandomSize: 4, ProofOfWorkDifficulty: 2, Noise: 0},
+			{RandomSize: 6, ProofOfWorkDifficulty: 4, Noise: 5},
+			{RandomSize: 8, ProofOfWorkDifficulty: 6, Noise: 10},
+		},
+		Window:           1 * time.Minute,
+		MinSamples:       10,
+		RaiseFailureRate: 0.5,
+		LowerFailureRate: 0.1,
+	}
+}
+
+func TestNewAdaptive(t *testing.T) {
+	_, err := NewAdaptive(AdaptiveConfigDefault())
+	if err != nil {
+		t.Errorf("default configuration is invalid: %s", err.Error())
+	}
+
+	_, err = NewAdaptive(testAdaptiveConfig())
+	if err != nil {
+		t.Errorf("test configuration is invalid: %s", err.Error())
+	}
+
+	c := testAdaptiveConfig()
+	c.Levels = nil
+	_, err = NewAdaptive(c)
+	if err == nil {
+		t.Error("no error for missing levels")
+	}
+
+	c = testAdaptiveConfig()
+	c.Levels[1].RandomSize = 0
+	_, err = NewAdaptive(c)
+	if err == nil {
+		t.Error("no error for invalid randomSize")
+	}
+
+	c = testAdaptiveConfig()
+	c.Levels[1].ProofOfWorkDifficulty = ProofOfWorkDifficultyMax + 1
+	_, err = NewAdaptive(c)
+	if err == nil {
+		t.Error("no error for invalid difficulty")
+	}
+
+	c = testAdaptiveConfig()
+	c.Window = 0
+	_, err = NewAdaptive(c)
+	if err == nil {
+		t.Error("no error for invalid window")
+	}
+
+	c = testAdaptiveConfig()
+	c.LowerFailureRate = c.RaiseFailureRate
+	_, err = NewAdaptive(c)
+	if err == nil {
+		t.Error("no error for invalid failure rates")
+	}
+}
+
+func TestAdaptiveLevel(t *testing.T) {
+	a, err := NewAdaptive(testAdaptiveConfig())
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	testtime := time.Now()
+
+	if l := a.Level("key", testtime); l.RandomSize != 4 {
+		t.Errorf("wrong start level (is: %d, should: %d)", l.RandomSize, 4)
+	}
+
+	// Not enough samples
+	for i := 0; i < 9; i++ {
+		a.Record("key", testtime, false)
+	}
+	if l := a.Level("key", testtime); l.RandomSize != 4 {
+		t.Errorf("level raised with too few samples (is: %d, should: %d)", l.RandomSize, 4)
+	}
+
+	// Failures spike
+	a.Record("key", testtime, false)
+	if l := a.Level("key", testtime); l.RandomSize != 6 {
+		t.Errorf("level not raised (is: %d, should: %d)", l.RandomSize, 6)
+	}
+
+	// Other keys are not affected
+	if l := a.Level("other", testtime); l.RandomSize != 4 {
+		t.Errorf("level of other key raised (is: %d, should: %d)", l.RandomSize, 4)
+	}
+
+	// Level changes at most once per window
+	for i := 0; i < 20; i++ {
+		a.Record("key", testtime, false)
+	}
+	if l := a.Level("key", testtime); l.RandomSize != 6 {
+		t.Errorf("level raised twice in one window (is: %d, should: %d)", l.RandomSize, 6)
+	}
+
+	testtime = testtime.Add(61 * time.Second)
+	for i := 0; i < 20; i++ {
+		a.Record("key", testtime, false)
+	}
+	if l := a.Level("key", testtime); l.RandomSize != 8 {
+		t.Errorf("level not raised in next window (is: %d, should: %d)", l.RandomSize, 8)
+	}
+
+	// Highest level is kept
+	testtime = testtime.Add(61 * time.Second)
+	for i := 0; i < 20; i++ {
+		a.Record("key", testtime, false)
+	}
+	if l := a.Level("key", testtime); l.RandomSize != 8 {
+		t.Errorf("level not kept (is: %d, should: %d)", l.RandomSize, 8)
+	}
+
+	// Things calm down
+	testtime = testtime.Add(3 * time.Minute)
+	for i := 0; i < 20; i++ {
+		a.Record("key", testtime, true)
+	}
+	if l := a.Level("key", testtime); l.RandomSize != 6 {
+		t.Errorf("level not lowered (is: %d, should: %d)", l.RandomSize, 6)
+	}
+
+	// No traffic
+	testtime = testtime.Add(3 * time.Minute)
+	if l := a.Level("key", testtime); l.RandomSize != 4 {
+		t.Errorf("level not lowered without traffic (is: %d, should: %d)", l.RandomSize, 4)
+	}
+}
+
+func TestAdaptiveTimed(t *testing.T) {
+	a, err := NewAdaptive(testAdaptiveConfig())
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	testtime := time.Now()
+
+	i, c, err := a.GetTimed("key", testtime)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if len(c) != 4 {
+		t.Errorf("c has wrong size (is: %d, should: %d)", len(c), 4)
+	}
+
+	for x := 0; x < 10; x++ {
+		if a.VerifyTimed("key", i, []byte{1, 2, 3, 4}, testtime, 1*time.Minute) {
+			t.Error("verification succeeded for wrong captcha")
+		}
+	}
+
+	// Level raised, old id still valid
+	i2, c2, err := a.GetTimed("key", testtime)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if len(c2) != 6 {
+		t.Errorf("c has wrong size after raise (is: %d, should: %d)", len(c2), 6)
+	}
+	if !a.VerifyTimed("key", i, c, testtime, 1*time.Minute) {
+		t.Error("verification failed for id of old level")
+	}
+	if !a.VerifyTimed("key", i2, c2, testtime, 1*time.Minute) {
+		t.Error("verification failed for id of new level")
+	}
+
+	// Modify level - uses some internal knowledge
+	forged := append([]byte{}, i2...)
+	forged[0] = 0
+	if a.VerifyTimed("key", forged, c2, testtime, 1*time.Minute) {
+		t.Error("verification succeeded for modified level")
+	}
+	forged[0] = 200
+	if a.VerifyTimed("key", forged, c2, testtime, 1*time.Minute) {
+		t.Error("verification succeeded for invalid level")
+	}
+
+	if a.VerifyTimed("key", nil, c2, testtime, 1*time.Minute) {
+		t.Error("verification succeeded for nil id")
+	}
+}
+
+func TestAdaptiveTimedRendered(t *testing.T) {
+	config := testAdaptiveConfig()
+	var noise []int
+	config.Render = func(captcha []byte, level AdaptiveLevel) ([]byte, error) {
+		if len(captcha) != level.RandomSize {
+			return nil, errors.New("wrong level")
+		}
+		noise = append(noise, level.Noise)
+		return append([]byte("rendered "), captcha...), nil
+	}
+	a, err := NewAdaptive(config)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	testtime := time.Now()
+
+	i, c, r, err := a.GetTimedRendered("key", testtime)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if !bytes.Equal(r, append([]byte("rendered "), c...)) {
+		t.Errorf("wrong rendering (is: %v, should: %v)", r, append([]byte("rendered "), c...))
+	}
+	if !a.VerifyTimed("key", i, c, testtime, 1*time.Minute) {
+		t.Error("verification failed for rendered captcha")
+	}
+
+	// The noise is raised with the level.
+	for x := 0; x < 10; x++ {
+		a.Record("key", testtime, false)
+	}
+	_, _, _, err = a.GetTimedRendered("key", testtime)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if len(noise) != 2 || noise[0] != 0 || noise[1] != 5 {
+		t.Errorf("wrong noise (is: %v, should: [0 5])", noise)
+	}
+
+	// Errors of the render function are returned.
+	config.Render = func(captcha []byte, level AdaptiveLevel) ([]byte, error) {
+		return nil, errors.New("render error")
+	}
+	a, err = NewAdaptive(config)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	_, _, _, err = a.GetTimedRendered("key", testtime)
+	if err == nil {
+		t.Error("error of render function not returned")
+	}
+
+	config.Render = nil
+	a, err = NewAdaptive(config)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	_, _, _, err = a.GetTimedRendered("key", testtime)
+	if err == nil {
+		t.Error("no error without render function")
+	}
+
+	config.Levels[0].Noise = -1
+	_, err = NewAdaptive(config)
+	if err == nil {
+		t.Error("negative noise not rejected")
+	}
+}
+
+func TestAdaptiveProofOfWork(t *testing.T) {
+	a, err := NewAdaptive(testAdaptiveConfig())
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	testtime := time.Now()
+
+	i, err := a.GetProofOfWork("key", testtime)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if d, _ := ProofOfWorkDifficulty(i); d != 2 {
+		t.Errorf("wrong difficulty (is: %d, should: %d)", d, 2)
+	}
+
+	n, err := SolveProofOfWork(i)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if !a.VerifyProofOfWork("key", i, n, testtime, 1*time.Minute) {
+		t.Error("verification failed")
+	}
+
+	for x := 0; x < 20; x++ {
+		a.VerifyProofOfWork("key", i, nil, testtime, 1*time.Minute)
+	}
+
+	i, err = a.GetProofOfWork("key", testtime)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if d, _ := ProofOfWorkDifficulty(i); d != 4 {
+		t.Errorf("wrong difficulty after raise (is: %d, should: %d)", d, 4)
+	}
+}
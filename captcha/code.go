@@ -0,0 +1,180 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package captcha
+
+// This file contains numeric codes, e.g. for email or phone verification.
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	// CodeDigitsDefault contains the suggested default number of digits of a code.
+	CodeDigitsDefault = 6
+
+	// CodeDigitsMin is the smallest number of digits of a code.
+	CodeDigitsMin = 6
+
+	// CodeDigitsMax is the largest number of digits of a code.
+	CodeDigitsMax = 8
+)
+
+// ErrUsed is returned if an id was already used successfully.
+var ErrUsed = errors.New("captcha: already used")
+
+// codeLabel separates the ids of codes from the ids of other captchas.
+var codeLabel = []byte("code")
+
+// CodeStore counts the attempts per id and marks ids as used. MemoryAttemptStore implements CodeStore.
+type CodeStore interface {
+	AttemptStore
+	UseStore
+}
+
+// GetCode returns a string representation of a timed id and a numeric code with the given number of digits (CodeDigitsMin to CodeDigitsMax).
+// The code is meant to be sent to the user (e.g. by email or SMS) and typed in. Every possible code is equally likely.
+// start determines the time from which the code is valid.
+//
+// Can be used concurrent.
+func GetCode(start time.Time, digits int) (id, code string, err error) {
+	initialisationRandomData.Do(func() {
+		setRandomData()
+	})
+
+	if digits < CodeDigitsMin || digits > CodeDigitsMax {
+		err = errors.New("invalid number of digits")
+		return
+	}
+
+	// rand.Int uses rejection sampling, so there is no modulo bias.
+	max := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(digits)), nil)
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return
+	}
+	code = n.String()
+	code = strings.Repeat("0", digits-len(code)) + code
+
+	i, err := getCodeID(code, start)
+	if err != nil {
+		return
+	}
+	id = base64.StdEncoding.EncodeToString(i)
+	return
+}
+
+// getCodeID returns the id of a code which is valid from start.
+// The hidden random data must be initialised.
+func getCodeID(code string, start time.Time) (id []byte, err error) {
+	timeEncoded, err := start.GobEncode()
+	if err != nil {
+		return
+	}
+	id = codeChecksum(code, timeEncoded)
+	return
+}
+
+// codeChecksum returns timeEncoded with the HMAC of code and timeEncoded appended.
+// The hidden random data must be initialised.
+func codeChecksum(code string, timeEncoded []byte) []byte {
+	hash := hmac.New(hashGenerator, randomData)
+	hash.Write(codeLabel)
+	hash.Write([]byte(code))
+	hash.Write(timeEncoded)
+	return hash.Sum(timeEncoded)
+}
+
+// VerifyCode validates whether an id / code combination returned by GetCode is valid and in date.
+// Spaces and dashes in code are ignored, so users can enter the code as "123 456" or "123-456".
+//
+// Every attempt is counted in store. After maxAttempts attempts the id is burned and ErrTooManyAttempts is returned.
+// After a successful verification the id is marked as used, so a code can only be used once (ErrUsed).
+// A wrong or outdated code results in ErrInvalid. Errors of the store are returned as they are.
+//
+// Can be used concurrent.
+func VerifyCode(store CodeStore, maxAttempts int, id, code string, now time.Time, validDuration time.Duration) error {
+	initialisationRandomData.Do(func() {
+		setRandomData()
+	})
+
+	if maxAttempts < 1 {
+		return errors.New("maxAttempts must be positive")
+	}
+
+	i, err := base64.StdEncoding.DecodeString(id)
+	if err != nil || len(i) <= hashSize {
+		return ErrInvalid
+	}
+	// Use the canonical encoding as key, so that the attempts can not be split between different encodings of the same id.
+	key := base64.StdEncoding.EncodeToString(i)
+
+	attempts, err := store.AddAttempt(key, now, now.Add(validDuration))
+	if err != nil {
+		return err
+	}
+	if attempts > maxAttempts {
+		return ErrTooManyAttempts
+	}
+
+	code = NormaliseAnswer(code)
+	if len(code) < CodeDigitsMin || len(code) > CodeDigitsMax || strings.Trim(code, "0123456789") != "" {
+		return ErrInvalid
+	}
+
+	timeEncoded := make([]byte, len(i)-hashSize)
+	copy(timeEncoded, i[:len(i)-hashSize]) // We need a true copy here, or else subtle.ConstantTimeCompare returns always true
+	if subtle.ConstantTimeCompare(codeChecksum(code, timeEncoded), i) == 0 {
+		return ErrInvalid
+	}
+	var t time.Time
+	err = t.GobDecode(timeEncoded)
+	if err != nil {
+		return ErrInvalid
+	}
+	if now.Before(t) || now.Sub(t) > validDuration {
+		return ErrInvalid
+	}
+
+	ok, err := store.Use(key, now, now.Add(validDuration))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrUsed
+	}
+	return nil
+}
+
+// NormaliseAnswer normalises an answer typed by a user: Spaces and dashes (which users might enter for readability) are removed and letters are converted to upper case.
+// It is used for codes and can be used for other typed answers, so that all are handled the same way.
+func NormaliseAnswer(answer string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\t', '-':
+			return -1
+		default:
+			return unicode.ToUpper(r)
+		}
+	}, answer)
+}
@@ -0,0 +1,197 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package captcha
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetCode(t *testing.T) {
+	testtime := time.Now()
+	for digits := CodeDigitsMin; digits <= CodeDigitsMax; digits++ {
+		id, code, err := GetCode(testtime, digits)
+		if err != nil {
+			t.Logf("error occured: %s", err.Error())
+			t.FailNow()
+		}
+		if len(code) != digits || strings.Trim(code, "0123456789") != "" {
+			t.Errorf("invalid code with %d digits: %s", digits, code)
+		}
+		err = VerifyCode(NewMemoryAttemptStore(), 3, id, code, testtime, time.Minute)
+		if err != nil {
+			t.Errorf("code with %d digits not accepted: %s", digits, err.Error())
+		}
+	}
+
+	for _, digits := range []int{0, 5, 9} {
+		_, _, err := GetCode(testtime, digits)
+		if err == nil {
+			t.Errorf("no error for %d digits", digits)
+		}
+	}
+}
+
+func TestGetCodeDistribution(t *testing.T) {
+	// All digits should appear in every position. With 2000 codes, a missing digit is extremely unlikely.
+	var counts [CodeDigitsDefault][10]int
+	for x := 0; x < 2000; x++ {
+		_, code, err := GetCode(time.Now(), CodeDigitsDefault)
+		if err != nil {
+			t.Logf("error occured: %s", err.Error())
+			t.FailNow()
+		}
+		for i := range code {
+			counts[i][code[i]-'0']++
+		}
+	}
+	for i := range counts {
+		for d := range counts[i] {
+			if counts[i][d] == 0 {
+				t.Errorf("digit %d never appeared at position %d", d, i)
+			}
+		}
+	}
+}
+
+func TestVerifyCode(t *testing.T) {
+	testtime := time.Now()
+	id, code, err := GetCode(testtime, CodeDigitsDefault)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	wrong := []byte(code)
+	wrong[0] = '0' + (wrong[0]-'0'+1)%10
+
+	testcases := []struct {
+		name string
+		id   string
+		code string
+		now  time.Time
+		err  error
+	}{
+		{"wrong", id, string(wrong), testtime, ErrInvalid},
+		{"outdated", id, code, testtime.Add(2 * time.Minute), ErrInvalid},
+		{"future", id, code, testtime.Add(-time.Second), ErrInvalid},
+		{"short", id, code[1:], testtime, ErrInvalid},
+		{"letters", id, "abcdef", testtime, ErrInvalid},
+		{"invalid id", "id", code, testtime, ErrInvalid},
+		{"other id", otherCodeID(t), code, testtime, ErrInvalid},
+		{"spaces", id, code[:3] + " " + code[3:], testtime, nil},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := VerifyCode(NewMemoryAttemptStore(), 3, tc.id, tc.code, tc.now, time.Minute)
+			if !errors.Is(err, tc.err) {
+				t.Errorf("wrong error (is: %v, should: %v)", err, tc.err)
+			}
+		})
+	}
+
+	// Dashes
+	err = VerifyCode(NewMemoryAttemptStore(), 3, id, code[:3]+"-"+code[3:], testtime, time.Minute)
+	if err != nil {
+		t.Errorf("code with dash not accepted: %s", err.Error())
+	}
+}
+
+// otherCodeID returns the id of another code.
+func otherCodeID(t *testing.T) string {
+	id, _, err := GetCode(time.Now(), CodeDigitsDefault)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	return id
+}
+
+func TestVerifyCodeLimits(t *testing.T) {
+	testtime := time.Now()
+	store := NewMemoryAttemptStore()
+	id, code, err := GetCode(testtime, CodeDigitsDefault)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+
+	// One-time use
+	err = VerifyCode(store, 3, id, code, testtime, time.Minute)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	err = VerifyCode(store, 3, id, code, testtime, time.Minute)
+	if !errors.Is(err, ErrUsed) {
+		t.Errorf("wrong error for used code (is: %v, should: %v)", err, ErrUsed)
+	}
+
+	// Attempt limit
+	id, code, err = GetCode(testtime, CodeDigitsDefault)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	for i := 0; i < 3; i++ {
+		err = VerifyCode(store, 3, id, "abcdef", testtime, time.Minute)
+		if !errors.Is(err, ErrInvalid) {
+			t.Errorf("wrong error for attempt %d (is: %v, should: %v)", i+1, err, ErrInvalid)
+		}
+	}
+	err = VerifyCode(store, 3, id, code, testtime, time.Minute)
+	if !errors.Is(err, ErrTooManyAttempts) {
+		t.Errorf("wrong error after too many attempts (is: %v, should: %v)", err, ErrTooManyAttempts)
+	}
+
+	err = VerifyCode(store, 0, id, code, testtime, time.Minute)
+	if err == nil {
+		t.Error("no error for invalid maxAttempts")
+	}
+}
+
+func TestCodeIDSeparated(t *testing.T) {
+	// Ids of codes can not be used as ids of timed captchas and the other way round.
+	testtime := time.Now()
+	id, code, err := GetCode(testtime, CodeDigitsDefault)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	i, _ := base64.StdEncoding.DecodeString(id)
+	if VerifyTimed(i, []byte(code), testtime, time.Minute, len(code)) {
+		t.Error("code id accepted as timed captcha")
+	}
+}
+
+func TestNormaliseAnswer(t *testing.T) {
+	testcases := map[string]string{
+		"123456":     "123456",
+		"123 456":    "123456",
+		" 123-456\t": "123456",
+		"abcd-EFGH":  "ABCDEFGH",
+		"ab cd - ef": "ABCDEF",
+		"":           "",
+	}
+	for in, should := range testcases {
+		if is := NormaliseAnswer(in); is != should {
+			t.Errorf("wrong normalisation of %q (is: %q, should: %q)", in, is, should)
+		}
+	}
+}
@@ -21,6 +21,11 @@
 // * A hidden value is used to make predictions impossible. This means that whenever you restart the program, old captchas are no longer valid.
 // * No session management is implemented. One captcha / id combination is always valid (as long as the hidden value is the same).
 //
-// Normal only captchas consist of a id / captcha combination. Therefore, you are strongly advised to use some sort of session management.
+// Normal only captchas consist of a id / captcha combination. Therefore, you are strongly advised to use some sort of session management. Package session can be used for this.
 // Timed captchas are valid for a specified amount of time. Therefore, a session management might not be needed (but you might use one, too).
+// To prevent guessing a timed captcha until it runs out of date, VerifyTimedLimited burns an id after a number of attempts. This needs an AttemptStore for counting.
+//
+// For email or phone verification, GetCode returns short numeric codes which can be typed in easily. VerifyCode limits the attempts and allows every code to be used only once.
+//
+// For clients which can not solve captchas (e.g. API clients), timed proof-of-work challenges are available. The client has to find a nonce so that the hash of id and nonce has a number of leading zero bits.
 package captcha
@@ -74,10 +74,16 @@ func Get(randomSize int) (id, captcha []byte, err error) {
 		return
 	}
 	captcha = b[:]
+	id = getID(captcha)
+	return
+}
+
+// getID returns the id of a captcha.
+// The hidden random data must be initialised.
+func getID(captcha []byte) []byte {
 	hash := hmac.New(hashGenerator, randomData)
 	hash.Write(captcha)
-	id = hash.Sum(nil)
-	return
+	return hash.Sum(nil)
 }
 
 // Verify validates whether an id / captia combination is valid.
@@ -130,11 +136,18 @@ func GetTimed(start time.Time, randomSize int) (id, captcha []byte, err error) {
 	if err != nil {
 		return
 	}
+	captcha = b[:]
+	id, err = getTimedID(captcha, start)
+	return
+}
+
+// getTimedID returns the id of a captcha which is valid from start.
+// The hidden random data must be initialised.
+func getTimedID(captcha []byte, start time.Time) (id []byte, err error) {
 	timeEncoded, err := start.GobEncode()
 	if err != nil {
 		return
 	}
-	captcha = b[:]
 	hash := hmac.New(hashGenerator, randomData)
 	hash.Write(captcha)
 	hash.Write(timeEncoded)
@@ -0,0 +1,165 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package captcha
+
+// This file contains verification functions which limit the number of attempts per id.
+
+import (
+	"encoding/base64"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrInvalid is returned if a captcha is not valid for an id (e.g. because it is wrong or not in date).
+	ErrInvalid = errors.New("captcha: invalid")
+
+	// ErrTooManyAttempts is returned if an id was used too often. The id can not be used any more, even if it is still in date.
+	ErrTooManyAttempts = errors.New("captcha: too many attempts")
+)
+
+// AttemptStore counts the attempts per id.
+//
+// All methods must be safe for concurrent use.
+type AttemptStore interface {
+	// AddAttempt increases the number of attempts for id and returns the new number.
+	// The entry is no longer needed after expires, so the store can remove it afterwards.
+	AddAttempt(id string, now, expires time.Time) (int, error)
+}
+
+// UseStore marks ids as used, so that they can only be used once.
+//
+// All methods must be safe for concurrent use.
+type UseStore interface {
+	// Use marks id as used. It returns false if id was already used. Checking and marking must be atomic.
+	// The entry is no longer needed after expires, so the store can remove it afterwards.
+	Use(id string, now, expires time.Time) (bool, error)
+}
+
+// MemoryAttemptStore is an in-memory AttemptStore and UseStore.
+// Expired entries are removed automatically.
+type MemoryAttemptStore struct {
+	m         sync.Mutex
+	entries   map[string]memoryAttempt
+	lastPrune time.Time
+}
+
+type memoryAttempt struct {
+	attempts int
+	used     bool
+	expires  time.Time
+}
+
+// memoryAttemptPruneInterval determines how often expired entries are removed from a MemoryAttemptStore.
+const memoryAttemptPruneInterval = 1 * time.Minute
+
+// NewMemoryAttemptStore returns a new, empty MemoryAttemptStore.
+func NewMemoryAttemptStore() *MemoryAttemptStore {
+	return &MemoryAttemptStore{
+		entries: make(map[string]memoryAttempt),
+	}
+}
+
+// AddAttempt increases the number of attempts for id. See AttemptStore for more information.
+func (s *MemoryAttemptStore) AddAttempt(id string, now, expires time.Time) (int, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	e := s.entry(id, now)
+	e.attempts++
+	if expires.After(e.expires) {
+		e.expires = expires
+	}
+	s.entries[id] = e
+	return e.attempts, nil
+}
+
+// Use marks id as used. See UseStore for more information.
+func (s *MemoryAttemptStore) Use(id string, now, expires time.Time) (bool, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	e := s.entry(id, now)
+	if e.used {
+		return false, nil
+	}
+	e.used = true
+	if expires.After(e.expires) {
+		e.expires = expires
+	}
+	s.entries[id] = e
+	return true, nil
+}
+
+// entry returns the current entry of id and removes expired entries regularly. The caller must hold the lock.
+func (s *MemoryAttemptStore) entry(id string, now time.Time) memoryAttempt {
+	if now.Sub(s.lastPrune) >= memoryAttemptPruneInterval {
+		s.lastPrune = now
+		for k, v := range s.entries {
+			if now.After(v.expires) {
+				delete(s.entries, k)
+			}
+		}
+	}
+
+	e, ok := s.entries[id]
+	if !ok || now.After(e.expires) {
+		return memoryAttempt{}
+	}
+	return e
+}
+
+// VerifyTimedLimited validates whether an id / captcha combination is valid and in date, like VerifyTimed.
+// Additionally, every attempt is counted in store. After maxAttempts attempts the id is burned and ErrTooManyAttempts is returned, even if it is still in date.
+// A wrong or outdated captcha results in ErrInvalid. Errors of the store are returned as they are.
+//
+// Since the attempt is counted before the verification, concurrent guesses can not exceed maxAttempts.
+//
+// Can be used concurrent.
+func VerifyTimedLimited(store AttemptStore, maxAttempts int, id, captcha []byte, now time.Time, validDuration time.Duration, randomSize int) error {
+	if maxAttempts < 1 {
+		return errors.New("maxAttempts must be positive")
+	}
+
+	attempts, err := store.AddAttempt(base64.StdEncoding.EncodeToString(id), now, now.Add(validDuration))
+	if err != nil {
+		return err
+	}
+	if attempts > maxAttempts {
+		return ErrTooManyAttempts
+	}
+	if !VerifyTimed(id, captcha, now, validDuration, randomSize) {
+		return ErrInvalid
+	}
+	return nil
+}
+
+// VerifyStringsTimedLimited verifies a string representation of a timed captcha (with default size) and limits the attempts per id.
+// See VerifyTimedLimited for more information.
+//
+// Can be used concurrent.
+func VerifyStringsTimedLimited(store AttemptStore, maxAttempts int, id, captcha string, now time.Time, validDuration time.Duration) error {
+	i, err := base64.StdEncoding.DecodeString(id)
+	if err != nil {
+		return ErrInvalid
+	}
+	c, err := base64.StdEncoding.DecodeString(captcha)
+	if err != nil {
+		return ErrInvalid
+	}
+	return VerifyTimedLimited(store, maxAttempts, i, c, now, validDuration, RandomSizeDefault)
+}
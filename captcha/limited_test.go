@@ -0,0 +1,199 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package captcha
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMemoryAttemptStore(t *testing.T) {
+	s := NewMemoryAttemptStore()
+	testtime := time.Now()
+
+	for i := 1; i <= 5; i++ {
+		a, err := s.AddAttempt("id", testtime, testtime.Add(1*time.Minute))
+		if err != nil {
+			t.Logf("error occured: %s", err.Error())
+			t.FailNow()
+		}
+		if a != i {
+			t.Errorf("wrong number of attempts (is: %d, should: %d)", a, i)
+		}
+	}
+
+	a, _ := s.AddAttempt("other", testtime, testtime.Add(1*time.Minute))
+	if a != 1 {
+		t.Errorf("wrong number of attempts for other id (is: %d, should: %d)", a, 1)
+	}
+
+	// Expired
+	a, _ = s.AddAttempt("id", testtime.Add(2*time.Minute), testtime.Add(3*time.Minute))
+	if a != 1 {
+		t.Errorf("wrong number of attempts after expiry (is: %d, should: %d)", a, 1)
+	}
+	if len(s.entries) != 1 {
+		t.Errorf("expired entries not removed (is: %d, should: %d)", len(s.entries), 1)
+	}
+}
+
+func TestMemoryAttemptStoreUse(t *testing.T) {
+	s := NewMemoryAttemptStore()
+	testtime := time.Now()
+
+	ok, err := s.Use("id", testtime, testtime.Add(1*time.Minute))
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if !ok {
+		t.Error("unused id not accepted")
+	}
+	ok, _ = s.Use("id", testtime, testtime.Add(1*time.Minute))
+	if ok {
+		t.Error("used id accepted")
+	}
+
+	// Attempts and use are independent
+	a, _ := s.AddAttempt("id", testtime, testtime.Add(1*time.Minute))
+	if a != 1 {
+		t.Errorf("wrong number of attempts (is: %d, should: %d)", a, 1)
+	}
+	ok, _ = s.Use("id", testtime, testtime.Add(1*time.Minute))
+	if ok {
+		t.Error("used id accepted after attempt")
+	}
+
+	// Expired
+	ok, _ = s.Use("id", testtime.Add(2*time.Minute), testtime.Add(3*time.Minute))
+	if !ok {
+		t.Error("id not accepted after expiry")
+	}
+}
+
+func TestVerifyTimedLimited(t *testing.T) {
+	s := NewMemoryAttemptStore()
+	testtime := time.Now()
+	i, c, err := GetTimed(testtime, RandomSizeDefault)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	wrong := make([]byte, RandomSizeDefault)
+	copy(wrong, c)
+	wrong[0]++
+
+	// Correct
+	err = VerifyTimedLimited(s, 3, i, c, testtime, 1*time.Minute, RandomSizeDefault)
+	if err != nil {
+		t.Errorf("verification failed: %s", err.Error())
+	}
+
+	// Wrong
+	err = VerifyTimedLimited(s, 3, i, wrong, testtime, 1*time.Minute, RandomSizeDefault)
+	if !errors.Is(err, ErrInvalid) {
+		t.Errorf("wrong error for wrong captcha (is: %v, should: %v)", err, ErrInvalid)
+	}
+
+	err = VerifyTimedLimited(NewMemoryAttemptStore(), 3, i, c, testtime.Add(2*time.Minute), 1*time.Minute, RandomSizeDefault)
+	if !errors.Is(err, ErrInvalid) {
+		t.Errorf("wrong error for outdated captcha (is: %v, should: %v)", err, ErrInvalid)
+	}
+
+	// Burned
+	err = VerifyTimedLimited(s, 3, i, wrong, testtime.Add(1*time.Second), 1*time.Minute, RandomSizeDefault)
+	if !errors.Is(err, ErrInvalid) {
+		t.Errorf("wrong error for wrong captcha (is: %v, should: %v)", err, ErrInvalid)
+	}
+	err = VerifyTimedLimited(s, 3, i, c, testtime, 1*time.Minute, RandomSizeDefault)
+	if !errors.Is(err, ErrTooManyAttempts) {
+		t.Errorf("wrong error for burned id (is: %v, should: %v)", err, ErrTooManyAttempts)
+	}
+
+	// Other ids are not affected
+	i2, c2, err := GetTimed(testtime, RandomSizeDefault)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	err = VerifyTimedLimited(s, 3, i2, c2, testtime, 1*time.Minute, RandomSizeDefault)
+	if err != nil {
+		t.Errorf("verification failed for other id: %s", err.Error())
+	}
+
+	// Invalid maxAttempts
+	err = VerifyTimedLimited(s, 0, i2, c2, testtime, 1*time.Minute, RandomSizeDefault)
+	if err == nil {
+		t.Error("no error for invalid maxAttempts")
+	}
+}
+
+func TestVerifyTimedLimitedConcurrent(t *testing.T) {
+	s := NewMemoryAttemptStore()
+	testtime := time.Now()
+	i, c, err := GetTimed(testtime, RandomSizeDefault)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+
+	var wg sync.WaitGroup
+	var m sync.Mutex
+	valid := 0
+	for x := 0; x < 20; x++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if VerifyTimedLimited(s, 5, i, c, testtime, 1*time.Minute, RandomSizeDefault) == nil {
+				m.Lock()
+				valid++
+				m.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if valid != 5 {
+		t.Errorf("wrong number of valid attempts (is: %d, should: %d)", valid, 5)
+	}
+}
+
+func TestVerifyStringsTimedLimited(t *testing.T) {
+	s := NewMemoryAttemptStore()
+	testtime := time.Now()
+	i, c, err := GetStringsTimed(testtime)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+
+	err = VerifyStringsTimedLimited(s, 1, i, c, testtime.Add(2*time.Second), 1*time.Minute)
+	if err != nil {
+		t.Errorf("verification failed: %s", err.Error())
+	}
+
+	err = VerifyStringsTimedLimited(s, 1, i, c, testtime.Add(2*time.Second), 1*time.Minute)
+	if !errors.Is(err, ErrTooManyAttempts) {
+		t.Errorf("wrong error for burned id (is: %v, should: %v)", err, ErrTooManyAttempts)
+	}
+
+	err = VerifyStringsTimedLimited(s, 1, "äää", c, testtime.Add(2*time.Second), 1*time.Minute)
+	if !errors.Is(err, ErrInvalid) {
+		t.Errorf("wrong error for invalid id (is: %v, should: %v)", err, ErrInvalid)
+	}
+}
@@ -0,0 +1,244 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package captcha
+
+// This file contains a pool of pre-generated captchas.
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrPoolClosed is returned by a Pool when it is shut down and no challenges are left.
+var ErrPoolClosed = errors.New("captcha: pool closed")
+
+// poolRenderBackoff is the time a worker waits after a failed rendering.
+const poolRenderBackoff = 100 * time.Millisecond
+
+// RenderFunc renders a captcha so that it can be shown to humans, e.g. as an image or as audio.
+type RenderFunc func(captcha []byte) ([]byte, error)
+
+// PoolConfig contains the configuration of a Pool.
+type PoolConfig struct {
+	// Size is the number of challenges kept in the pool.
+	Size int
+	// Workers is the number of goroutines generating new challenges.
+	Workers int
+	// RandomSize is the size of the generated captchas.
+	RandomSize int
+	// Render renders the generated captchas. If it is nil, challenges are not rendered.
+	Render RenderFunc
+}
+
+// Challenge is a captcha handed out by a Pool.
+type Challenge struct {
+	// ID is the id of the captcha.
+	ID []byte
+	// Captcha is the captcha which should be guessed by humans.
+	Captcha []byte
+	// Rendered is the output of the RenderFunc of the pool.
+	Rendered []byte
+}
+
+// PoolMetrics contains metrics about a Pool.
+type PoolMetrics struct {
+	// Size is the number of challenges the pool can hold.
+	Size int
+	// Filled is the number of challenges currently in the pool.
+	Filled int
+	// Handouts is the number of challenges handed out.
+	Handouts uint64
+	// Waits is the number of times a caller had to wait because the pool was empty.
+	Waits uint64
+	// RenderErrors is the number of times rendering a challenge failed.
+	RenderErrors uint64
+}
+
+// Pool keeps a bounded buffer of pre-generated and rendered challenges, which is topped up by background workers.
+// This way, expensive rendering does not happen while handling requests.
+// Handing out a challenge takes constant time unless the pool is empty.
+//
+// The id of a challenge is computed when it is handed out. This way, the validity of timed challenges starts at handout and is not shortened by the time spent in the pool.
+//
+// Can be used concurrent.
+type Pool struct {
+	// Counters are first to ensure 64-bit alignment for atomic operations.
+	handouts     uint64
+	waits        uint64
+	renderErrors uint64
+
+	config     PoolConfig
+	challenges chan pooledChallenge
+	done       <-chan struct{}
+	wg         sync.WaitGroup
+}
+
+type pooledChallenge struct {
+	captcha  []byte
+	rendered []byte
+}
+
+// NewPool creates a new Pool and starts its workers.
+// The workers run until ctx is done. Afterwards, remaining challenges can still be handed out.
+func NewPool(ctx context.Context, config PoolConfig) (*Pool, error) {
+	initialisationRandomData.Do(func() {
+		setRandomData()
+	})
+
+	if config.Size < 1 {
+		return nil, errors.New("size must be positive")
+	}
+	if config.Workers < 1 {
+		return nil, errors.New("workers must be positive")
+	}
+	if config.RandomSize < 1 {
+		return nil, errors.New("randomSize must be positive")
+	}
+
+	p := &Pool{
+		config:     config,
+		challenges: make(chan pooledChallenge, config.Size),
+		done:       ctx.Done(),
+	}
+	p.wg.Add(config.Workers)
+	for i := 0; i < config.Workers; i++ {
+		go p.worker(ctx)
+	}
+	return p, nil
+}
+
+// Get hands out one challenge. The id is the same as generated by Get.
+// If the pool is empty, Get waits until a challenge is available, ctx is done or the pool is shut down.
+//
+// Can be used concurrent.
+func (p *Pool) Get(ctx context.Context) (Challenge, error) {
+	c, err := p.take(ctx)
+	if err != nil {
+		return Challenge{}, err
+	}
+	return Challenge{ID: getID(c.captcha), Captcha: c.captcha, Rendered: c.rendered}, nil
+}
+
+// GetTimed hands out one timed challenge, which is valid from start. The id is the same as generated by GetTimed.
+// If the pool is empty, GetTimed waits until a challenge is available, ctx is done or the pool is shut down.
+//
+// Can be used concurrent.
+func (p *Pool) GetTimed(ctx context.Context, start time.Time) (Challenge, error) {
+	c, err := p.take(ctx)
+	if err != nil {
+		return Challenge{}, err
+	}
+	id, err := getTimedID(c.captcha, start)
+	if err != nil {
+		return Challenge{}, err
+	}
+	return Challenge{ID: id, Captcha: c.captcha, Rendered: c.rendered}, nil
+}
+
+// Metrics returns the current metrics of the pool.
+func (p *Pool) Metrics() PoolMetrics {
+	return PoolMetrics{
+		Size:         p.config.Size,
+		Filled:       len(p.challenges),
+		Handouts:     atomic.LoadUint64(&p.handouts),
+		Waits:        atomic.LoadUint64(&p.waits),
+		RenderErrors: atomic.LoadUint64(&p.renderErrors),
+	}
+}
+
+// Wait blocks until all workers are stopped. The workers stop after the context given to NewPool is done.
+func (p *Pool) Wait() {
+	p.wg.Wait()
+}
+
+// take removes one challenge from the pool.
+func (p *Pool) take(ctx context.Context) (pooledChallenge, error) {
+	select {
+	case c := <-p.challenges:
+		atomic.AddUint64(&p.handouts, 1)
+		return c, nil
+	default:
+	}
+
+	atomic.AddUint64(&p.waits, 1)
+	select {
+	case c := <-p.challenges:
+		atomic.AddUint64(&p.handouts, 1)
+		return c, nil
+	case <-ctx.Done():
+		return pooledChallenge{}, ctx.Err()
+	case <-p.done:
+		// Workers might have added challenges before stopping.
+		select {
+		case c := <-p.challenges:
+			atomic.AddUint64(&p.handouts, 1)
+			return c, nil
+		default:
+			return pooledChallenge{}, ErrPoolClosed
+		}
+	}
+}
+
+// worker generates challenges until ctx is done.
+func (p *Pool) worker(ctx context.Context) {
+	defer p.wg.Done()
+
+	for {
+		b := make([]byte, p.config.RandomSize)
+		_, err := rand.Read(b)
+		if err != nil {
+			atomic.AddUint64(&p.renderErrors, 1)
+			if !p.backoff(ctx) {
+				return
+			}
+			continue
+		}
+
+		c := pooledChallenge{captcha: b}
+		if p.config.Render != nil {
+			c.rendered, err = p.config.Render(b)
+			if err != nil {
+				atomic.AddUint64(&p.renderErrors, 1)
+				if !p.backoff(ctx) {
+					return
+				}
+				continue
+			}
+		}
+
+		select {
+		case p.challenges <- c:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// backoff waits after an error. It returns false if ctx is done.
+func (p *Pool) backoff(ctx context.Context) bool {
+	t := time.NewTimer(poolRenderBackoff)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,166 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package captcha
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewPool(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	_, err := NewPool(ctx, PoolConfig{Size: 0, Workers: 1, RandomSize: RandomSizeDefault})
+	if err == nil {
+		t.Error("no error for invalid size")
+	}
+	_, err = NewPool(ctx, PoolConfig{Size: 1, Workers: 0, RandomSize: RandomSizeDefault})
+	if err == nil {
+		t.Error("no error for invalid workers")
+	}
+	_, err = NewPool(ctx, PoolConfig{Size: 1, Workers: 1, RandomSize: 0})
+	if err == nil {
+		t.Error("no error for invalid randomSize")
+	}
+}
+
+func TestPool(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	render := func(c []byte) ([]byte, error) {
+		return append([]byte("rendered:"), c...), nil
+	}
+	p, err := NewPool(ctx, PoolConfig{Size: 5, Workers: 2, RandomSize: RandomSizeDefault, Render: render})
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+
+	c, err := p.Get(ctx)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if !Verify(c.ID, c.Captcha, RandomSizeDefault) {
+		t.Error("verification failed")
+	}
+	if !bytes.Equal(c.Rendered, append([]byte("rendered:"), c.Captcha...)) {
+		t.Error("challenge not rendered")
+	}
+
+	testtime := time.Now().Add(1 * time.Hour)
+	c, err = p.GetTimed(ctx, testtime)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if !VerifyTimed(c.ID, c.Captcha, testtime.Add(2*time.Second), 1*time.Minute, RandomSizeDefault) {
+		t.Error("verification failed (timed)")
+	}
+	if VerifyTimed(c.ID, c.Captcha, time.Now(), 1*time.Minute, RandomSizeDefault) {
+		t.Error("verification succeeded before handout time")
+	}
+
+	// Wait until the pool is filled
+	for x := 0; x < 100 && p.Metrics().Filled < 5; x++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	m := p.Metrics()
+	if m.Size != 5 || m.Filled != 5 {
+		t.Errorf("pool not filled (is: %d/%d, should: %d/%d)", m.Filled, m.Size, 5, 5)
+	}
+	if m.Handouts != 2 {
+		t.Errorf("wrong number of handouts (is: %d, should: %d)", m.Handouts, 2)
+	}
+
+	// Shutdown
+	cancel()
+	p.Wait()
+	for x := 0; x < 5; x++ {
+		_, err = p.Get(context.Background())
+		if err != nil {
+			t.Errorf("remaining challenge not handed out: %s", err.Error())
+		}
+	}
+	_, err = p.Get(context.Background())
+	if !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("wrong error for closed pool (is: %v, should: %v)", err, ErrPoolClosed)
+	}
+}
+
+func TestPoolWait(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	block := make(chan struct{})
+	render := func(c []byte) ([]byte, error) {
+		<-block
+		return nil, nil
+	}
+	p, err := NewPool(ctx, PoolConfig{Size: 1, Workers: 1, RandomSize: RandomSizeDefault, Render: render})
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+
+	getCtx, getCancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer getCancel()
+	_, err = p.Get(getCtx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("wrong error for empty pool (is: %v, should: %v)", err, context.DeadlineExceeded)
+	}
+	if w := p.Metrics().Waits; w != 1 {
+		t.Errorf("wrong number of waits (is: %d, should: %d)", w, 1)
+	}
+
+	close(block)
+	_, err = p.Get(context.Background())
+	if err != nil {
+		t.Errorf("error occured: %s", err.Error())
+	}
+}
+
+func TestPoolRenderError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	render := func(c []byte) ([]byte, error) {
+		return nil, errors.New("test")
+	}
+	p, err := NewPool(ctx, PoolConfig{Size: 1, Workers: 1, RandomSize: RandomSizeDefault, Render: render})
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+
+	for x := 0; x < 100 && p.Metrics().RenderErrors == 0; x++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if p.Metrics().RenderErrors == 0 {
+		t.Error("render error not counted")
+	}
+
+	cancel()
+	p.Wait()
+	_, err = p.Get(context.Background())
+	if !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("wrong error for closed pool (is: %v, should: %v)", err, ErrPoolClosed)
+	}
+}
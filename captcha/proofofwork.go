@@ -0,0 +1,221 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package captcha
+
+// This file contains a hashcash-style proof-of-work challenge.
+// Instead of a captcha guessed by a human, the client has to find a nonce so that the hash of id and nonce starts with a number of zero bits.
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"math/bits"
+	"time"
+)
+
+const (
+	// ProofOfWorkDifficultyDefault contains the suggested default difficulty (number of leading zero bits) for proof-of-work challenges.
+	ProofOfWorkDifficultyDefault = 20
+
+	// ProofOfWorkDifficultyMax contains the highest supported difficulty. Challenges with this difficulty can not be solved in reasonable time.
+	ProofOfWorkDifficultyMax = 64
+
+	// proofOfWorkRandomSize is the number of random bytes in each challenge. It ensures that no two challenges are equal.
+	proofOfWorkRandomSize = 16
+
+	// proofOfWorkNonceMax is the largest nonce accepted by the verification. It limits the work done by the server.
+	proofOfWorkNonceMax = 64
+)
+
+// proofOfWorkLabel is used to separate proof-of-work ids from other ids.
+var proofOfWorkLabel = []byte("proofofwork")
+
+// GetProofOfWork returns one timed proof-of-work challenge.
+// difficulty determines the number of leading zero bits the hash of id and nonce must have, every additional bit doubles the expected work of the client.
+// start determines the time from which the challenge is valid.
+//
+// The id is the challenge itself and can be handed out publicly. Difficulty and time are encoded in the id (and can not be tampered with without invalidating the challenge).
+//
+// Can be used concurrent.
+func GetProofOfWork(start time.Time, difficulty int) (id []byte, err error) {
+	initialisationRandomData.Do(func() {
+		setRandomData()
+	})
+
+	if difficulty < 1 || difficulty > ProofOfWorkDifficultyMax {
+		err = errors.New("difficulty out of range")
+		return
+	}
+
+	b := make([]byte, proofOfWorkRandomSize)
+	_, err = rand.Read(b)
+	if err != nil {
+		return
+	}
+	timeEncoded, err := start.GobEncode()
+	if err != nil {
+		return
+	}
+
+	challenge := make([]byte, 0, 1+proofOfWorkRandomSize+len(timeEncoded)+hashSize)
+	challenge = append(challenge, byte(difficulty))
+	challenge = append(challenge, b...)
+	challenge = append(challenge, timeEncoded...)
+
+	hash := hmac.New(hashGenerator, randomData)
+	hash.Write(proofOfWorkLabel)
+	hash.Write(challenge)
+	id = hash.Sum(challenge)
+	return
+}
+
+// VerifyProofOfWork validates whether a nonce solves the challenge given by id and whether the challenge is in date.
+// Duration determines how long a challenge should be seen as valid.
+//
+// The verification needs one hash for the solution in addition to the authentication of the id, so it is cheap compared to solving the challenge.
+// Since VerifyProofOfWork does not check if an id is already used, the same id / nonce combination is always valid (in the given time period).
+//
+// Can be used concurrent.
+func VerifyProofOfWork(id, nonce []byte, now time.Time, validDuration time.Duration) bool {
+	initialisationRandomData.Do(func() {
+		setRandomData()
+	})
+
+	if len(id) <= 1+proofOfWorkRandomSize+hashSize {
+		return false
+	}
+	if len(nonce) == 0 || len(nonce) > proofOfWorkNonceMax {
+		return false
+	}
+
+	challenge := make([]byte, len(id)-hashSize)
+	copy(challenge, id[:len(id)-hashSize]) // We need a true copy here, or else subtle.ConstantTimeCompare returns always true
+
+	hash := hmac.New(hashGenerator, randomData)
+	hash.Write(proofOfWorkLabel)
+	hash.Write(challenge)
+	checksum := hash.Sum(challenge)
+	if subtle.ConstantTimeCompare(checksum, id) == 0 {
+		return false
+	}
+
+	difficulty := int(challenge[0])
+	if difficulty < 1 || difficulty > ProofOfWorkDifficultyMax {
+		return false
+	}
+
+	var t time.Time
+	err := t.GobDecode(challenge[1+proofOfWorkRandomSize:])
+	if err != nil {
+		return false
+	}
+	if now.Before(t) {
+		return false
+	}
+	if now.Sub(t) > validDuration {
+		return false
+	}
+
+	return proofOfWorkZeroBits(id, nonce) >= difficulty
+}
+
+// ProofOfWorkDifficulty returns the difficulty encoded in a proof-of-work id.
+// The id is not authenticated, so the result should only be used by clients (e.g. to estimate the work) and never to make security decisions.
+func ProofOfWorkDifficulty(id []byte) (difficulty int, ok bool) {
+	if len(id) <= 1+proofOfWorkRandomSize+hashSize {
+		return 0, false
+	}
+	difficulty = int(id[0])
+	if difficulty < 1 || difficulty > ProofOfWorkDifficultyMax {
+		return 0, false
+	}
+	return difficulty, true
+}
+
+// SolveProofOfWork is a reference solver for proof-of-work challenges.
+// It searches for a nonce by counting upwards and returns the first one which solves the challenge.
+// The expected number of hashes is 2^difficulty.
+//
+// SolveProofOfWork does not need the hidden value, so it can be used by clients.
+func SolveProofOfWork(id []byte) (nonce []byte, err error) {
+	difficulty, ok := ProofOfWorkDifficulty(id)
+	if !ok {
+		err = errors.New("invalid proof-of-work id")
+		return
+	}
+
+	nonce = make([]byte, 8)
+	for counter := uint64(0); counter != ^uint64(0); counter++ {
+		binary.BigEndian.PutUint64(nonce, counter)
+		if proofOfWorkZeroBits(id, nonce) >= difficulty {
+			return
+		}
+	}
+	nonce = nil
+	err = errors.New("no solution found")
+	return
+}
+
+// GetProofOfWorkStrings returns a string representation of a new proof-of-work challenge. Please note: Clients have to decode the id before solving it.
+// See GetProofOfWork for more information about proof-of-work challenges.
+//
+// Can be used concurrent.
+func GetProofOfWorkStrings(start time.Time, difficulty int) (id string, err error) {
+	i, e := GetProofOfWork(start, difficulty)
+	if e != nil {
+		err = e
+		return
+	}
+	id = base64.StdEncoding.EncodeToString(i)
+	return
+}
+
+// VerifyProofOfWorkStrings verifies a string representation of a proof-of-work challenge and nonce.
+// See VerifyProofOfWork for more information about proof-of-work challenges.
+//
+// Can be used concurrent.
+func VerifyProofOfWorkStrings(id, nonce string, now time.Time, validDuration time.Duration) bool {
+	i, err := base64.StdEncoding.DecodeString(id)
+	if err != nil {
+		return false
+	}
+	n, err := base64.StdEncoding.DecodeString(nonce)
+	if err != nil {
+		return false
+	}
+	return VerifyProofOfWork(i, n, now, validDuration)
+}
+
+// proofOfWorkZeroBits returns the number of leading zero bits of the hash of id and nonce.
+func proofOfWorkZeroBits(id, nonce []byte) int {
+	hash := hashGenerator()
+	hash.Write(id)
+	hash.Write(nonce)
+	sum := hash.Sum(nil)
+
+	zeros := 0
+	for i := range sum {
+		if sum[i] != 0 {
+			zeros += bits.LeadingZeros8(sum[i])
+			break
+		}
+		zeros += 8
+	}
+	return zeros
+}
@@ -0,0 +1,223 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package captcha
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+	"time"
+)
+
+func TestGetProofOfWork(t *testing.T) {
+	testtime := time.Now()
+	i, err := GetProofOfWork(testtime, 8)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if len(i) <= 1+proofOfWorkRandomSize+hashSize {
+		t.Errorf("i has wrong size (is: %d, should be larger than: %d)", len(i), 1+proofOfWorkRandomSize+hashSize)
+	}
+
+	d, ok := ProofOfWorkDifficulty(i)
+	if !ok || d != 8 {
+		t.Errorf("wrong difficulty (is: %d, should: %d)", d, 8)
+	}
+
+	// simple random test
+	for x := 0; x < 100; x++ {
+		i2, err := GetProofOfWork(testtime, 8)
+		if err != nil {
+			t.Logf("error occured: %s", err.Error())
+			t.FailNow()
+		}
+		if bytes.Compare(i, i2) == 0 {
+			t.Errorf("simple random error failed: %s == %s (i)", i, i2)
+			break
+		}
+	}
+
+	// Test invalid difficulty
+	_, err = GetProofOfWork(testtime, 0)
+	if err == nil {
+		t.Errorf("Generating zero difficulty does not show an error")
+	}
+
+	_, err = GetProofOfWork(testtime, ProofOfWorkDifficultyMax+1)
+	if err == nil {
+		t.Errorf("Generating too high difficulty does not show an error")
+	}
+}
+
+func TestVerifyProofOfWork(t *testing.T) {
+	testtime := time.Now()
+	i, err := GetProofOfWork(testtime, 8)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+
+	n, err := SolveProofOfWork(i)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+
+	// Correct
+	r := VerifyProofOfWork(i, n, testtime, 1*time.Minute)
+	if r == false {
+		t.Error("proof-of-work verification failed")
+	}
+
+	// Correct later
+	r = VerifyProofOfWork(i, n, testtime.Add(2*time.Second), 1*time.Minute)
+	if r == false {
+		t.Error("proof-of-work verification failed (modified time)")
+	}
+
+	// Too late
+	r = VerifyProofOfWork(i, n, testtime.Add(2*time.Minute), 1*time.Minute)
+	if r == true {
+		t.Error("proof-of-work verification succeeded (time over duration)")
+	}
+
+	r = VerifyProofOfWork(i, n, testtime.Add(-2*time.Second), 1*time.Minute)
+	if r == true {
+		t.Error("proof-of-work verification succeeded (now before challenge time)")
+	}
+
+	// Wrong nonce - find one which does not solve the challenge
+	wrong := []byte{0}
+	for proofOfWorkZeroBits(i, wrong) >= 8 {
+		wrong[0]++
+	}
+	r = VerifyProofOfWork(i, wrong, testtime, 1*time.Minute)
+	if r == true {
+		t.Error("proof-of-work verification succeeded for wrong nonce")
+	}
+
+	r = VerifyProofOfWork(i, nil, testtime, 1*time.Minute)
+	if r == true {
+		t.Error("proof-of-work verification succeeded for nil nonce")
+	}
+
+	r = VerifyProofOfWork(i, make([]byte, proofOfWorkNonceMax+1), testtime, 1*time.Minute)
+	if r == true {
+		t.Error("proof-of-work verification succeeded for too long nonce")
+	}
+
+	// Lower the difficulty - uses some internal knowledge
+	forged := make([]byte, len(i))
+	copy(forged, i)
+	forged[0] = 1
+	nf, err := SolveProofOfWork(forged)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	r = VerifyProofOfWork(forged, nf, testtime, 1*time.Minute)
+	if r == true {
+		t.Error("proof-of-work verification succeeded for modified difficulty")
+	}
+
+	// Try to change the time - uses some internal knowledge
+	forgedTime, _ := testtime.Add(1 * time.Hour).GobEncode()
+	forged = append([]byte{}, i[:1+proofOfWorkRandomSize]...)
+	forged = append(forged, forgedTime...)
+	forged = append(forged, i[len(i)-hashSize:]...)
+	nf, err = SolveProofOfWork(forged)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	r = VerifyProofOfWork(forged, nf, testtime.Add(1*time.Hour), 1*time.Minute)
+	if r == true {
+		t.Error("proof-of-work verification succeeded for modified timestamp")
+	}
+
+	// Mixed captcha and proof-of-work
+	it, _, err := GetTimed(testtime, 1+proofOfWorkRandomSize)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	r = VerifyProofOfWork(it, n, testtime, 1*time.Minute)
+	if r == true {
+		t.Error("proof-of-work verification succeeded for timed captcha id")
+	}
+
+	r = VerifyProofOfWork([]byte{8, 0, 0, 0, 0}, n, testtime, 1*time.Minute)
+	if r == true {
+		t.Error("proof-of-work verification succeeded for wrong id")
+	}
+}
+
+func TestSolveProofOfWork(t *testing.T) {
+	for _, d := range []int{1, 4, 12} {
+		i, err := GetProofOfWork(time.Now(), d)
+		if err != nil {
+			t.Logf("error occured: %s", err.Error())
+			t.FailNow()
+		}
+		n, err := SolveProofOfWork(i)
+		if err != nil {
+			t.Errorf("error occured (difficulty %d): %s", d, err.Error())
+			continue
+		}
+		if proofOfWorkZeroBits(i, n) < d {
+			t.Errorf("solution has not enough zero bits (difficulty %d)", d)
+		}
+	}
+
+	_, err := SolveProofOfWork([]byte{1, 2, 3})
+	if err == nil {
+		t.Error("solving invalid id does not show an error")
+	}
+}
+
+func TestProofOfWorkStrings(t *testing.T) {
+	testtime := time.Now()
+	i, err := GetProofOfWorkStrings(testtime, 8)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+
+	id, err := base64.StdEncoding.DecodeString(i)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	n, err := SolveProofOfWork(id)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	nonce := base64.StdEncoding.EncodeToString(n)
+
+	if !VerifyProofOfWorkStrings(i, nonce, testtime.Add(2*time.Second), 1*time.Minute) {
+		t.Error("Verification failed")
+	}
+
+	if VerifyProofOfWorkStrings("äää", nonce, testtime.Add(2*time.Second), 1*time.Minute) {
+		t.Error("Verification failed (invalid id)")
+	}
+
+	if VerifyProofOfWorkStrings(i, "äää", testtime.Add(2*time.Second), 1*time.Minute) {
+		t.Error("Verification failed (invalid nonce)")
+	}
+}
@@ -0,0 +1,96 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"os"
+	"strings"
+)
+
+// encodeFields returns a text file starting with header followed by one "name: value" line per field.
+func encodeFields(header string, fields [][2]string) []byte {
+	var b bytes.Buffer
+	b.WriteString(header)
+	b.WriteString("\n")
+	for _, f := range fields {
+		b.WriteString(f[0])
+		b.WriteString(": ")
+		b.WriteString(f[1])
+		b.WriteString("\n")
+	}
+	return b.Bytes()
+}
+
+// readFields reads a file created by encodeFields and returns its header and fields.
+func readFields(name string) (header string, fields map[string]string, err error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	// Key and signature files are small, so limit the size in case a wrong file is given.
+	s := bufio.NewScanner(io.LimitReader(f, 64*1024))
+	if !s.Scan() {
+		err = errors.New(name + ": empty file")
+		if s.Err() != nil {
+			err = s.Err()
+		}
+		return
+	}
+	header = strings.TrimSpace(s.Text())
+	fields = make(map[string]string)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" {
+			continue
+		}
+		i := strings.Index(line, ":")
+		if i == -1 {
+			err = errors.New(name + ": invalid line " + line)
+			return
+		}
+		k, v := strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])
+		if _, ok := fields[k]; ok {
+			err = errors.New(name + ": duplicated field " + k)
+			return
+		}
+		fields[k] = v
+	}
+	err = s.Err()
+	return
+}
+
+// digestFile returns the SHA-256 hash of a file. The file is streamed, so it does not need to fit into memory.
+func digestFile(name string) ([]byte, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
@@ -0,0 +1,291 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Top-Ranger/auth/data"
+)
+
+const (
+	algorithmEd25519 = "ed25519"
+	algorithmHMAC    = "hmac-sha256"
+
+	headerPrivate   = "authsign-key v1"
+	headerPublic    = "authsign-public-key v1"
+	headerSignature = "authsign-signature v1"
+
+	// hmacKeySize is the size of generated hmac keys.
+	hmacKeySize = 32
+
+	// maxClockSkew is the time a signature may be in the future.
+	maxClockSkew = 5 * time.Minute
+)
+
+// key is a key of any algorithm. Depending on the algorithm and the file it was read from, only some fields are set.
+type key struct {
+	algorithm string
+	keyID     string
+	signing   *data.SigningKey
+	public    data.PublicKeySet
+	secret    []byte
+}
+
+// signature is the content of a signature file.
+type signature struct {
+	algorithm string
+	keyID     string
+	timestamp string
+	mac       []byte
+}
+
+// generateKey returns a new key. If keyID is empty, it is derived from the key.
+func generateKey(algorithm, keyID string) (*key, error) {
+	if len(keyID) > data.MaxKeyIDLength || strings.ContainsAny(keyID, " \t\r\n") {
+		return nil, errors.New("invalid key id")
+	}
+	k := &key{keyID: keyID}
+	switch strings.ToLower(algorithm) {
+	case algorithmEd25519:
+		_, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		err = k.setEd25519(private, nil)
+		if err != nil {
+			return nil, err
+		}
+	case "hmac", algorithmHMAC:
+		secret := make([]byte, hmacKeySize)
+		_, err := rand.Read(secret)
+		if err != nil {
+			return nil, err
+		}
+		k.setHMAC(secret)
+	default:
+		return nil, errors.New("unknown algorithm " + algorithm)
+	}
+	return k, nil
+}
+
+// readKey reads a private or public key file.
+func readKey(name string) (*key, error) {
+	header, fields, err := readFields(name)
+	if err != nil {
+		return nil, err
+	}
+	if header != headerPrivate && header != headerPublic {
+		return nil, errors.New(name + ": not a key file")
+	}
+	k := &key{keyID: fields["key-id"]}
+	if k.keyID == "" {
+		return nil, errors.New(name + ": no key id")
+	}
+
+	switch {
+	case fields["algorithm"] == algorithmEd25519 && header == headerPrivate:
+		seed, err := base64.StdEncoding.DecodeString(fields["private-key"])
+		if err != nil || len(seed) != ed25519.SeedSize {
+			return nil, errors.New(name + ": invalid private key")
+		}
+		err = k.setEd25519(ed25519.NewKeyFromSeed(seed), nil)
+		if err != nil {
+			return nil, err
+		}
+	case fields["algorithm"] == algorithmEd25519 && header == headerPublic:
+		public, err := base64.StdEncoding.DecodeString(fields["public-key"])
+		if err != nil || len(public) != ed25519.PublicKeySize {
+			return nil, errors.New(name + ": invalid public key")
+		}
+		err = k.setEd25519(nil, public)
+		if err != nil {
+			return nil, err
+		}
+	case fields["algorithm"] == algorithmHMAC && header == headerPrivate:
+		secret, err := base64.StdEncoding.DecodeString(fields["secret"])
+		if err != nil || len(secret) < hmacKeySize {
+			return nil, errors.New(name + ": invalid secret")
+		}
+		k.setHMAC(secret)
+	default:
+		return nil, errors.New(name + ": unknown algorithm " + fields["algorithm"])
+	}
+	return k, nil
+}
+
+// readSignature reads a signature file.
+func readSignature(name string) (*signature, error) {
+	header, fields, err := readFields(name)
+	if err != nil {
+		return nil, err
+	}
+	if header != headerSignature {
+		return nil, errors.New(name + ": not a signature file")
+	}
+	mac, err := base64.StdEncoding.DecodeString(fields["mac"])
+	if err != nil || len(mac) == 0 {
+		return nil, errors.New(name + ": invalid mac")
+	}
+	s := &signature{algorithm: fields["algorithm"], keyID: fields["key-id"], timestamp: fields["timestamp"], mac: mac}
+	if s.algorithm == "" || s.keyID == "" || s.timestamp == "" {
+		return nil, errors.New(name + ": missing field")
+	}
+	return s, nil
+}
+
+// setEd25519 sets the Ed25519 key. Either private or public must be set.
+func (k *key) setEd25519(private ed25519.PrivateKey, public ed25519.PublicKey) error {
+	k.algorithm = algorithmEd25519
+	if private != nil {
+		public = private.Public().(ed25519.PublicKey)
+	}
+	if k.keyID == "" {
+		sum := sha256.Sum256(public)
+		k.keyID = hex.EncodeToString(sum[:8])
+	}
+	if private != nil {
+		var err error
+		k.signing, err = data.NewSigningKey(k.keyID, private)
+		if err != nil {
+			return err
+		}
+	}
+	return k.public.Add(k.keyID, public)
+}
+
+// setHMAC sets the hmac secret.
+func (k *key) setHMAC(secret []byte) {
+	k.algorithm = algorithmHMAC
+	k.secret = secret
+	if k.keyID == "" {
+		h := hmac.New(sha256.New, secret)
+		h.Write([]byte("authsign key id"))
+		k.keyID = hex.EncodeToString(h.Sum(nil)[:8])
+	}
+}
+
+// canSign returns whether k can create signatures.
+func (k *key) canSign() bool {
+	return k.signing != nil || k.secret != nil
+}
+
+// encodePrivate returns the content of the key file.
+func (k *key) encodePrivate() []byte {
+	fields := [][2]string{{"algorithm", k.algorithm}, {"key-id", k.keyID}}
+	if k.algorithm == algorithmEd25519 {
+		fields = append(fields, [2]string{"private-key", base64.StdEncoding.EncodeToString(k.signing.PrivateKey().Seed())})
+	} else {
+		fields = append(fields, [2]string{"secret", base64.StdEncoding.EncodeToString(k.secret)})
+	}
+	return encodeFields(headerPrivate, fields)
+}
+
+// encodePublic returns the content of the public key file. It must only be called for Ed25519 keys.
+func (k *key) encodePublic() []byte {
+	public, _ := k.public.Key(k.keyID)
+	return encodeFields(headerPublic, [][2]string{{"algorithm", k.algorithm}, {"key-id", k.keyID}, {"public-key", base64.StdEncoding.EncodeToString(public)}})
+}
+
+// sign returns the signature of a file with the given digest.
+func (k *key) sign(digest []byte, now time.Time) (*signature, error) {
+	s := &signature{algorithm: k.algorithm, keyID: k.keyID, timestamp: now.UTC().Format(time.RFC3339)}
+	m := s.message(digest)
+
+	switch k.algorithm {
+	case algorithmEd25519:
+		id, err := k.signing.Get(m)
+		if err != nil {
+			return nil, err
+		}
+		s.mac = id
+	case algorithmHMAC:
+		s.mac = k.hmac(m)
+	default:
+		return nil, errors.New("unknown algorithm " + k.algorithm)
+	}
+	return s, nil
+}
+
+// verify checks the signature of a file with the given digest. If maxAge is positive, older signatures are rejected.
+// errInvalid is returned if the signature is invalid.
+func (k *key) verify(s *signature, digest []byte, now time.Time, maxAge time.Duration) error {
+	if s.algorithm != k.algorithm {
+		return fmt.Errorf("%w: signature uses algorithm %s, but key uses %s", errInvalid, s.algorithm, k.algorithm)
+	}
+	if s.keyID != k.keyID {
+		return fmt.Errorf("%w: signature uses key id %s, but key has id %s", errInvalid, s.keyID, k.keyID)
+	}
+
+	m := s.message(digest)
+	switch k.algorithm {
+	case algorithmEd25519:
+		if keyID, ok := data.KeyID(s.mac); !ok || keyID != s.keyID || !k.public.Verify(s.mac, m) {
+			return errInvalid
+		}
+	case algorithmHMAC:
+		if subtle.ConstantTimeCompare(k.hmac(m), s.mac) == 0 {
+			return errInvalid
+		}
+	default:
+		return errors.New("unknown algorithm " + k.algorithm)
+	}
+
+	// The timestamp is signed, so it can only be checked afterwards.
+	t, err := time.Parse(time.RFC3339, s.timestamp)
+	if err != nil {
+		return errInvalid
+	}
+	if t.After(now.Add(maxClockSkew)) {
+		return fmt.Errorf("%w: timestamp %s is in the future", errInvalid, s.timestamp)
+	}
+	if maxAge > 0 && now.Sub(t) > maxAge {
+		return fmt.Errorf("%w: signed at %s, which is older than %s", errInvalid, s.timestamp, maxAge)
+	}
+	return nil
+}
+
+// hmac returns the HMAC of m.
+func (k *key) hmac(m []byte) []byte {
+	h := hmac.New(sha256.New, k.secret)
+	h.Write(m)
+	return h.Sum(nil)
+}
+
+// message returns the data which is signed. It binds the metadata of the signature to the digest of the file.
+func (s *signature) message(digest []byte) []byte {
+	return []byte(strings.Join([]string{headerSignature, s.algorithm, s.keyID, s.timestamp, hex.EncodeToString(digest)}, "\n"))
+}
+
+// encode returns the content of the signature file.
+func (s *signature) encode() []byte {
+	return encodeFields(headerSignature, [][2]string{
+		{"algorithm", s.algorithm},
+		{"key-id", s.keyID},
+		{"timestamp", s.timestamp},
+		{"mac", base64.StdEncoding.EncodeToString(s.mac)},
+	})
+}
@@ -0,0 +1,221 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command authsign creates and verifies detached signatures of files.
+//
+// Usage:
+//
+//	authsign keygen [-algorithm ed25519|hmac] [-id key-id] name
+//	authsign sign -key name.key [-out file.sig] file
+//	authsign verify -key name.pub|name.key [-max-age duration] file [file.sig]
+//
+// keygen creates name.key (which must be kept secret) and, for ed25519, name.pub (which can be given to verifiers).
+// hmac keys are symmetric, so verifiers need name.key.
+//
+// Files are streamed, so they can be larger than the available memory.
+// The signature is a text file containing the algorithm, the key id, the timestamp and the MAC or signature.
+//
+// verify exits with 0 if the signature is valid, 1 if it is invalid and 2 on all other errors (e.g. missing files).
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+const (
+	exitValid   = 0
+	exitInvalid = 1
+	exitError   = 2
+)
+
+// errInvalid is returned if a signature is invalid.
+var errInvalid = errors.New("invalid signature")
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes the command given by args and returns the exit code.
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) < 1 {
+		usage(stderr)
+		return exitError
+	}
+
+	var err error
+	switch args[0] {
+	case "keygen":
+		err = keygen(args[1:], stdout)
+	case "sign":
+		err = sign(args[1:], stdout)
+	case "verify":
+		err = verify(args[1:])
+		if err == nil {
+			fmt.Fprintln(stdout, "valid signature")
+		}
+	case "help", "-h", "-help", "--help":
+		usage(stdout)
+		return exitValid
+	default:
+		usage(stderr)
+		return exitError
+	}
+
+	switch {
+	case err == nil:
+		return exitValid
+	case errors.Is(err, errInvalid):
+		fmt.Fprintln(stderr, err.Error())
+		return exitInvalid
+	case errors.Is(err, flag.ErrHelp):
+		return exitError
+	default:
+		fmt.Fprintln(stderr, "authsign:", err.Error())
+		return exitError
+	}
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w, "  authsign keygen [-algorithm ed25519|hmac] [-id key-id] name")
+	fmt.Fprintln(w, "  authsign sign -key name.key [-out file.sig] file")
+	fmt.Fprintln(w, "  authsign verify -key name.pub|name.key [-max-age duration] file [file.sig]")
+}
+
+func keygen(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("keygen", flag.ContinueOnError)
+	algorithm := fs.String("algorithm", algorithmEd25519, "algorithm of the key (ed25519 or hmac)")
+	keyID := fs.String("id", "", "key id (default: derived from the key)")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("keygen needs exactly one name")
+	}
+	name := fs.Arg(0)
+
+	k, err := generateKey(*algorithm, *keyID)
+	if err != nil {
+		return err
+	}
+	err = writeFile(name+".key", k.encodePrivate(), 0600, false)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "wrote %s.key (key id %s)\n", name, k.keyID)
+	if k.algorithm == algorithmEd25519 {
+		err = writeFile(name+".pub", k.encodePublic(), 0644, false)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "wrote %s.pub\n", name)
+	}
+	return nil
+}
+
+func sign(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("sign", flag.ContinueOnError)
+	keyFile := fs.String("key", "", "private key file")
+	out := fs.String("out", "", "signature file (default: file.sig)")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() != 1 || *keyFile == "" {
+		return errors.New("sign needs -key and exactly one file")
+	}
+	file := fs.Arg(0)
+	if *out == "" {
+		*out = file + ".sig"
+	}
+
+	k, err := readKey(*keyFile)
+	if err != nil {
+		return err
+	}
+	if !k.canSign() {
+		return errors.New(*keyFile + " contains no private key")
+	}
+	digest, err := digestFile(file)
+	if err != nil {
+		return err
+	}
+	s, err := k.sign(digest, time.Now())
+	if err != nil {
+		return err
+	}
+	err = writeFile(*out, s.encode(), 0644, true)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "wrote %s\n", *out)
+	return nil
+}
+
+func verify(args []string) error {
+	fs := flag.NewFlagSet("verify", flag.ContinueOnError)
+	keyFile := fs.String("key", "", "public key file (ed25519) or key file (hmac)")
+	maxAge := fs.Duration("max-age", 0, "maximum age of the signature (default: no limit)")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() < 1 || fs.NArg() > 2 || *keyFile == "" {
+		return errors.New("verify needs -key, a file and optionally a signature file")
+	}
+	file := fs.Arg(0)
+	sigFile := file + ".sig"
+	if fs.NArg() == 2 {
+		sigFile = fs.Arg(1)
+	}
+
+	k, err := readKey(*keyFile)
+	if err != nil {
+		return err
+	}
+	s, err := readSignature(sigFile)
+	if err != nil {
+		return err
+	}
+	digest, err := digestFile(file)
+	if err != nil {
+		return err
+	}
+	return k.verify(s, digest, time.Now(), *maxAge)
+}
+
+// writeFile writes b to name. Existing files are only overwritten if overwrite is true.
+func writeFile(name string, b []byte, perm os.FileMode, overwrite bool) error {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !overwrite {
+		flags |= os.O_EXCL
+	}
+	f, err := os.OpenFile(name, flags, perm)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(b)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,225 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testDir creates a directory containing a file to sign. The returned function removes the directory.
+func testDir(t *testing.T) (dir, file string, cleanup func()) {
+	dir, err := ioutil.TempDir("", "authsign")
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	file = filepath.Join(dir, "file")
+	err = ioutil.WriteFile(file, bytes.Repeat([]byte("some content\n"), 1000), 0644)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	return dir, file, func() { os.RemoveAll(dir) }
+}
+
+// testRun runs authsign with args and fails the test if the exit code is not should.
+func testRun(t *testing.T, should int, args ...string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	is := run(args, &stdout, &stderr)
+	if is != should {
+		t.Errorf("%v: wrong exit code (is: %d, should: %d)\nstdout: %s\nstderr: %s", args, is, should, stdout.String(), stderr.String())
+	}
+}
+
+// editSignature replaces the value of field in the signature file name.
+func editSignature(t *testing.T, name, field, value string) {
+	t.Helper()
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	lines := strings.Split(string(b), "\n")
+	found := false
+	for i := range lines {
+		if strings.HasPrefix(lines[i], field+": ") {
+			lines[i] = field + ": " + value
+			found = true
+		}
+	}
+	if !found {
+		t.Logf("field %s not found", field)
+		t.FailNow()
+	}
+	err = ioutil.WriteFile(name, []byte(strings.Join(lines, "\n")), 0644)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+}
+
+func TestEd25519(t *testing.T) {
+	dir, file, cleanup := testDir(t)
+	defer cleanup()
+	name := filepath.Join(dir, "test")
+
+	testRun(t, exitValid, "keygen", name)
+	testRun(t, exitValid, "sign", "-key", name+".key", file)
+	testRun(t, exitValid, "verify", "-key", name+".pub", file)
+	testRun(t, exitValid, "verify", "-key", name+".key", file)
+	testRun(t, exitValid, "verify", "-key", name+".pub", "-max-age", "1h", file, file+".sig")
+
+	// Public keys can not sign.
+	testRun(t, exitError, "sign", "-key", name+".pub", "-out", filepath.Join(dir, "public.sig"), file)
+
+	// Existing keys are not overwritten.
+	testRun(t, exitError, "keygen", name)
+
+	// Modified files are invalid.
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	_, err = f.Write([]byte("x"))
+	f.Close()
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	testRun(t, exitInvalid, "verify", "-key", name+".pub", file)
+}
+
+func TestHMAC(t *testing.T) {
+	dir, file, cleanup := testDir(t)
+	defer cleanup()
+	name := filepath.Join(dir, "test")
+
+	testRun(t, exitValid, "keygen", "-algorithm", "hmac", "-id", "my-key", name)
+	_, err := os.Stat(name + ".pub")
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("public key written for hmac key: %v", err)
+	}
+	testRun(t, exitValid, "sign", "-key", name+".key", "-out", filepath.Join(dir, "file.hmac"), file)
+	testRun(t, exitValid, "verify", "-key", name+".key", file, filepath.Join(dir, "file.hmac"))
+
+	// A signature of another file is invalid.
+	other := filepath.Join(dir, "other")
+	err = ioutil.WriteFile(other, []byte("other content"), 0644)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	testRun(t, exitInvalid, "verify", "-key", name+".key", other, filepath.Join(dir, "file.hmac"))
+}
+
+func TestOtherKey(t *testing.T) {
+	dir, file, cleanup := testDir(t)
+	defer cleanup()
+	name := filepath.Join(dir, "test")
+	other := filepath.Join(dir, "other")
+	sameID := filepath.Join(dir, "same-id")
+	hmacKey := filepath.Join(dir, "hmac")
+
+	testRun(t, exitValid, "keygen", "-id", "key", name)
+	testRun(t, exitValid, "keygen", other)
+	testRun(t, exitValid, "keygen", "-id", "key", sameID)
+	testRun(t, exitValid, "keygen", "-algorithm", "hmac", "-id", "key", hmacKey)
+	testRun(t, exitValid, "sign", "-key", name+".key", file)
+
+	// Signatures of other keys are invalid, not errors.
+	testRun(t, exitInvalid, "verify", "-key", other+".pub", file)
+	testRun(t, exitInvalid, "verify", "-key", sameID+".pub", file)
+	testRun(t, exitInvalid, "verify", "-key", hmacKey+".key", file)
+
+	// Editing the signature to name another key does not help.
+	otherKey, err := readKey(other + ".pub")
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	editSignature(t, file+".sig", "key-id", otherKey.keyID)
+	testRun(t, exitInvalid, "verify", "-key", other+".pub", file)
+	testRun(t, exitInvalid, "verify", "-key", name+".pub", file)
+	editSignature(t, file+".sig", "key-id", "key")
+	editSignature(t, file+".sig", "algorithm", algorithmHMAC)
+	testRun(t, exitInvalid, "verify", "-key", hmacKey+".key", file)
+	testRun(t, exitInvalid, "verify", "-key", name+".pub", file)
+	editSignature(t, file+".sig", "algorithm", algorithmEd25519)
+	testRun(t, exitValid, "verify", "-key", name+".pub", file)
+
+	// The timestamp is signed.
+	editSignature(t, file+".sig", "timestamp", time.Now().Add(-time.Hour).UTC().Format(time.RFC3339))
+	testRun(t, exitInvalid, "verify", "-key", name+".pub", file)
+}
+
+func TestMaxAge(t *testing.T) {
+	k, err := generateKey(algorithmEd25519, "")
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	digest := []byte("digest")
+	now := time.Now()
+	s, err := k.sign(digest, now)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+
+	err = k.verify(s, digest, now.Add(time.Minute), time.Hour)
+	if err != nil {
+		t.Errorf("valid signature rejected: %s", err.Error())
+	}
+	err = k.verify(s, digest, now.Add(2*time.Hour), time.Hour)
+	if !errors.Is(err, errInvalid) {
+		t.Errorf("old signature not rejected (is: %v, should: %v)", err, errInvalid)
+	}
+	err = k.verify(s, digest, now.Add(-time.Hour), 0)
+	if !errors.Is(err, errInvalid) {
+		t.Errorf("signature from the future not rejected (is: %v, should: %v)", err, errInvalid)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	dir, file, cleanup := testDir(t)
+	defer cleanup()
+	name := filepath.Join(dir, "test")
+	testRun(t, exitValid, "keygen", name)
+	testRun(t, exitValid, "sign", "-key", name+".key", file)
+
+	testRun(t, exitError)
+	testRun(t, exitError, "unknown")
+	testRun(t, exitValid, "help")
+	testRun(t, exitError, "keygen", "-algorithm", "rsa", filepath.Join(dir, "rsa"))
+	testRun(t, exitError, "keygen", "-unknown-flag", filepath.Join(dir, "flag"))
+	testRun(t, exitError, "sign", file)
+	testRun(t, exitError, "verify", file)
+	testRun(t, exitError, "verify", "-key", name+".pub", filepath.Join(dir, "missing"))
+	testRun(t, exitError, "verify", "-key", filepath.Join(dir, "missing.pub"), file)
+	testRun(t, exitError, "verify", "-key", name+".pub", file, filepath.Join(dir, "missing.sig"))
+	testRun(t, exitError, "verify", "-key", file, file)
+	testRun(t, exitError, "verify", "-key", name+".pub", file, name+".pub")
+}
@@ -0,0 +1,272 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cookie
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Top-Ranger/auth/data"
+)
+
+const (
+	// MaxSize is the maximum size of a cookie (name, value and attributes) supported by browsers.
+	MaxSize = 4096
+
+	// version is the version of the encoding.
+	version = 1
+
+	// flagEncrypted marks encrypted values.
+	flagEncrypted = 1 << 0
+
+	// headerSize is the size of version, flags and timestamp.
+	headerSize = 1 + 1 + 8
+)
+
+var (
+	// ErrInvalid is returned if a value was not created by the codec, was modified or belongs to another cookie.
+	ErrInvalid = errors.New("cookie: invalid value")
+
+	// ErrExpired is returned if a value is older than the maximum age.
+	ErrExpired = errors.New("cookie: value expired")
+
+	// ErrTooLarge is returned if a cookie exceeds MaxSize.
+	ErrTooLarge = errors.New("cookie: cookie too large")
+)
+
+var (
+	hashGenerator = sha256.New
+	hashSize      = hashGenerator().Size()
+)
+
+// Codec writes and reads signed and optionally encrypted cookie values.
+// The zero value signs with a key derived from the hidden value of package data and does not enforce a maximum age.
+//
+// Can be used concurrent.
+type Codec struct {
+	// Keys contains the keys used by the codec. The first key is used to create values, all keys are accepted when reading values.
+	// To rotate keys, add a new key at the beginning and remove old keys once all values created with them are expired.
+	// If Keys is empty, a key derived from the hidden value of package data is used.
+	Keys [][]byte
+	// Encrypt determines whether values are encrypted (using AES-GCM) in addition to being signed.
+	Encrypt bool
+	// MaxAge is the maximum age of a value. Older values are rejected. If it is zero, the age is not checked.
+	MaxAge time.Duration
+}
+
+// Encode returns the signed (and encrypted) representation of value for the cookie with the given name.
+// now is the creation time of the value.
+//
+// An ErrTooLarge is returned if name and encoded value exceed MaxSize.
+func (c *Codec) Encode(name string, value []byte, now time.Time) (string, error) {
+	keys, err := c.keys()
+	if err != nil {
+		return "", err
+	}
+	signKey, encryptKey := deriveKeys(keys[0])
+
+	header := make([]byte, headerSize)
+	header[0] = version
+	if c.Encrypt {
+		header[1] |= flagEncrypted
+	}
+	binary.BigEndian.PutUint64(header[2:], uint64(now.Unix()))
+
+	payload := value
+	if c.Encrypt {
+		payload, err = encrypt(encryptKey, name, header, value)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	b := make([]byte, 0, headerSize+len(payload)+hashSize)
+	b = append(b, header...)
+	b = append(b, payload...)
+	b = append(b, sign(signKey, name, b)...)
+
+	encoded := base64.RawURLEncoding.EncodeToString(b)
+	if len(name)+1+len(encoded) > MaxSize {
+		return "", ErrTooLarge
+	}
+	return encoded, nil
+}
+
+// Decode verifies the encoded value of the cookie with the given name and returns the original value.
+// All keys of the codec are tried, so values created with older keys are still accepted.
+//
+// ErrInvalid is returned if the value can not be verified, ErrExpired if it is older than MaxAge.
+func (c *Codec) Decode(name, encoded string, now time.Time) ([]byte, error) {
+	keys, err := c.keys()
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalid
+	}
+	if len(b) < headerSize+hashSize || b[0] != version {
+		return nil, ErrInvalid
+	}
+	content, mac := b[:len(b)-hashSize], b[len(b)-hashSize:]
+
+	for i := range keys {
+		signKey, encryptKey := deriveKeys(keys[i])
+		if subtle.ConstantTimeCompare(sign(signKey, name, content), mac) == 0 {
+			continue
+		}
+
+		header, payload := content[:headerSize], content[headerSize:]
+		t := time.Unix(int64(binary.BigEndian.Uint64(header[2:])), 0)
+		if now.Before(t) {
+			return nil, ErrInvalid
+		}
+		if c.MaxAge > 0 && now.Sub(t) > c.MaxAge {
+			return nil, ErrExpired
+		}
+
+		if header[1]&flagEncrypted != 0 {
+			return decrypt(encryptKey, name, header, payload)
+		}
+		if c.Encrypt {
+			// Do not accept unencrypted values when encryption is required.
+			return nil, ErrInvalid
+		}
+		value := make([]byte, len(payload))
+		copy(value, payload)
+		return value, nil
+	}
+	return nil, ErrInvalid
+}
+
+// SetCookie encodes value, stores it in cookie.Value and adds the cookie to rw.
+// If cookie.MaxAge and cookie.Expires are not set, MaxAge of the codec is used.
+//
+// An ErrTooLarge is returned if the cookie exceeds MaxSize. In this case, no cookie is set.
+func (c *Codec) SetCookie(rw http.ResponseWriter, cookie *http.Cookie, value []byte) error {
+	encoded, err := c.Encode(cookie.Name, value, time.Now())
+	if err != nil {
+		return err
+	}
+	cookie.Value = encoded
+	if cookie.MaxAge == 0 && cookie.Expires.IsZero() && c.MaxAge > 0 {
+		cookie.MaxAge = int(c.MaxAge / time.Second)
+	}
+	if len(cookie.String()) > MaxSize {
+		return ErrTooLarge
+	}
+	http.SetCookie(rw, cookie)
+	return nil
+}
+
+// Cookie returns the decoded value of the cookie with the given name in r.
+// If the cookie is not present, http.ErrNoCookie is returned.
+func (c *Codec) Cookie(r *http.Request, name string) ([]byte, error) {
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return nil, err
+	}
+	return c.Decode(name, cookie.Value, time.Now())
+}
+
+// keys returns the keys of the codec or the derived default key.
+func (c *Codec) keys() ([][]byte, error) {
+	if len(c.Keys) != 0 {
+		return c.Keys, nil
+	}
+	k, err := data.Key("cookie")
+	if err != nil {
+		return nil, err
+	}
+	return [][]byte{k}, nil
+}
+
+// deriveKeys returns separate keys for signing and encryption.
+func deriveKeys(key []byte) (signKey, encryptKey []byte) {
+	hash := hmac.New(hashGenerator, key)
+	hash.Write([]byte("cookie signature"))
+	signKey = hash.Sum(nil)
+
+	hash = hmac.New(hashGenerator, key)
+	hash.Write([]byte("cookie encryption"))
+	encryptKey = hash.Sum(nil)
+	return
+}
+
+// sign returns the MAC of the content of the cookie with the given name.
+func sign(key []byte, name string, content []byte) []byte {
+	hash := hmac.New(hashGenerator, key)
+	hash.Write([]byte(name))
+	hash.Write([]byte{0})
+	hash.Write(content)
+	return hash.Sum(nil)
+}
+
+// encrypt encrypts value with AES-GCM. Name and header are authenticated as additional data.
+func encrypt(key []byte, name string, header, value []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(value)+aead.Overhead())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, value, additionalData(name, header)), nil
+}
+
+// decrypt reverses encrypt.
+func decrypt(key []byte, name string, header, payload []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) < aead.NonceSize() {
+		return nil, ErrInvalid
+	}
+	value, err := aead.Open(nil, payload[:aead.NonceSize()], payload[aead.NonceSize():], additionalData(name, header))
+	if err != nil {
+		return nil, ErrInvalid
+	}
+	return value, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func additionalData(name string, header []byte) []byte {
+	ad := make([]byte, 0, len(name)+1+len(header))
+	ad = append(ad, name...)
+	ad = append(ad, 0)
+	ad = append(ad, header...)
+	return ad
+}
@@ -0,0 +1,211 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cookie
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Top-Ranger/auth/data"
+)
+
+func TestCodec(t *testing.T) {
+	testtime := time.Now()
+	value := []byte("some state")
+
+	for _, encrypt := range []bool{false, true} {
+		c := &Codec{Encrypt: encrypt, MaxAge: 1 * time.Hour}
+
+		e, err := c.Encode("name", value, testtime)
+		if err != nil {
+			t.Logf("error occured: %s", err.Error())
+			t.FailNow()
+		}
+		if encrypt && strings.Contains(e, base64.RawURLEncoding.EncodeToString(value)) {
+			t.Error("value not encrypted")
+		}
+
+		v, err := c.Decode("name", e, testtime.Add(2*time.Second))
+		if err != nil {
+			t.Errorf("decoding failed (encrypt: %t): %s", encrypt, err.Error())
+		}
+		if !bytes.Equal(v, value) {
+			t.Errorf("wrong value (encrypt: %t): %s", encrypt, v)
+		}
+
+		// Other cookie
+		_, err = c.Decode("other", e, testtime)
+		if !errors.Is(err, ErrInvalid) {
+			t.Errorf("wrong error for other cookie (encrypt: %t): %v", encrypt, err)
+		}
+
+		// Expired
+		_, err = c.Decode("name", e, testtime.Add(2*time.Hour))
+		if !errors.Is(err, ErrExpired) {
+			t.Errorf("wrong error for expired value (encrypt: %t): %v", encrypt, err)
+		}
+
+		// From the future
+		_, err = c.Decode("name", e, testtime.Add(-1*time.Hour))
+		if !errors.Is(err, ErrInvalid) {
+			t.Errorf("wrong error for value from the future (encrypt: %t): %v", encrypt, err)
+		}
+
+		// Modified
+		b, _ := base64.RawURLEncoding.DecodeString(e)
+		for i := range b {
+			m := append([]byte{}, b...)
+			m[i]++
+			_, err = c.Decode("name", base64.RawURLEncoding.EncodeToString(m), testtime)
+			if !errors.Is(err, ErrInvalid) {
+				t.Errorf("wrong error for modified byte %d (encrypt: %t): %v", i, encrypt, err)
+			}
+		}
+		_, err = c.Decode("name", base64.RawURLEncoding.EncodeToString(b[:len(b)-1]), testtime)
+		if !errors.Is(err, ErrInvalid) {
+			t.Errorf("wrong error for truncated value (encrypt: %t): %v", encrypt, err)
+		}
+		_, err = c.Decode("name", "äää", testtime)
+		if !errors.Is(err, ErrInvalid) {
+			t.Errorf("wrong error for invalid encoding (encrypt: %t): %v", encrypt, err)
+		}
+	}
+
+	// Unencrypted values are not accepted when encryption is required
+	e, err := (&Codec{}).Encode("name", value, testtime)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	_, err = (&Codec{Encrypt: true}).Decode("name", e, testtime)
+	if !errors.Is(err, ErrInvalid) {
+		t.Errorf("unencrypted value accepted: %v", err)
+	}
+
+	// No maximum age
+	v, err := (&Codec{}).Decode("name", e, testtime.Add(24*time.Hour))
+	if err != nil || !bytes.Equal(v, value) {
+		t.Errorf("decoding without maximum age failed: %v", err)
+	}
+}
+
+func TestCodecKeyRotation(t *testing.T) {
+	testtime := time.Now()
+	value := []byte("some state")
+	oldKey := []byte("old key")
+	newKey := []byte("new key")
+
+	old := &Codec{Keys: [][]byte{oldKey}, Encrypt: true}
+	e, err := old.Encode("name", value, testtime)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+
+	rotated := &Codec{Keys: [][]byte{newKey, oldKey}, Encrypt: true}
+	v, err := rotated.Decode("name", e, testtime)
+	if err != nil || !bytes.Equal(v, value) {
+		t.Errorf("value of old key not accepted: %v", err)
+	}
+
+	e, err = rotated.Encode("name", value, testtime)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	_, err = old.Decode("name", e, testtime)
+	if !errors.Is(err, ErrInvalid) {
+		t.Errorf("value of new key accepted by old codec: %v", err)
+	}
+
+	// Derived key is different from explicit keys
+	_, err = (&Codec{Encrypt: true}).Decode("name", e, testtime)
+	if !errors.Is(err, ErrInvalid) {
+		t.Errorf("value accepted with derived key: %v", err)
+	}
+
+	// Derived key can not be learned through public ids of package data
+	id, err := data.Get([]byte("cookie"))
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	e, err = (&Codec{Keys: [][]byte{id}}).Encode("name", value, testtime)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	_, err = (&Codec{}).Decode("name", e, testtime)
+	if !errors.Is(err, ErrInvalid) {
+		t.Errorf("value accepted with id of package data: %v", err)
+	}
+}
+
+func TestCodecSize(t *testing.T) {
+	c := &Codec{}
+	_, err := c.Encode("name", make([]byte, MaxSize), time.Now())
+	if !errors.Is(err, ErrTooLarge) {
+		t.Errorf("wrong error for large value (is: %v, should: %v)", err, ErrTooLarge)
+	}
+
+	// Value fits, but not together with the attributes
+	rec := httptest.NewRecorder()
+	cookie := &http.Cookie{Name: "name", Path: "/" + strings.Repeat("a", 200)}
+	err = c.SetCookie(rec, cookie, make([]byte, 2900))
+	if !errors.Is(err, ErrTooLarge) {
+		t.Errorf("wrong error for large cookie (is: %v, should: %v)", err, ErrTooLarge)
+	}
+	if len(rec.Result().Cookies()) != 0 {
+		t.Error("large cookie was set")
+	}
+}
+
+func TestCodecHTTP(t *testing.T) {
+	c := &Codec{Encrypt: true, MaxAge: 1 * time.Hour}
+	value := []byte("some state")
+
+	rec := httptest.NewRecorder()
+	err := c.SetCookie(rec, &http.Cookie{Name: "name", Path: "/"}, value)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("wrong number of cookies (is: %d, should: %d)", len(cookies), 1)
+	}
+	if cookies[0].MaxAge != 3600 {
+		t.Errorf("wrong max age (is: %d, should: %d)", cookies[0].MaxAge, 3600)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(cookies[0])
+	v, err := c.Cookie(r, "name")
+	if err != nil || !bytes.Equal(v, value) {
+		t.Errorf("reading cookie failed: %v", err)
+	}
+
+	_, err = c.Cookie(r, "other")
+	if !errors.Is(err, http.ErrNoCookie) {
+		t.Errorf("wrong error for missing cookie (is: %v, should: %v)", err, http.ErrNoCookie)
+	}
+}
@@ -0,0 +1,21 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cookie contains methods to store small amounts of state in signed and optionally encrypted HTTP cookies.
+// Values are timestamped and bound to the name of the cookie, so a value can not be moved to another cookie.
+//
+// By default, the keys are derived from the hidden value of package data. This means that whenever you restart the program, old cookies are no longer valid.
+// If cookies have to survive a restart, keys can be given explicitly. Multiple keys can be used to rotate them.
+package cookie
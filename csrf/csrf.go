@@ -0,0 +1,289 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csrf
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Top-Ranger/auth/data"
+)
+
+const (
+	// HeaderName is the name of the header which can contain the token.
+	HeaderName = "X-CSRF-Token"
+
+	// FieldName is the name of the form field which can contain the token.
+	FieldName = "csrf_token"
+
+	// CookieNameDefault is the default name of the cookie used for the double-submit cookie pattern.
+	CookieNameDefault = "csrf"
+
+	// ValidDurationDefault contains the suggested default validity of a token.
+	ValidDurationDefault = 12 * time.Hour
+
+	// cookieRandomSize is the number of random bytes stored in the cookie.
+	cookieRandomSize = 32
+)
+
+var (
+	// ErrInvalidToken is returned if a request contains no valid token.
+	ErrInvalidToken = errors.New("csrf: invalid token")
+
+	// ErrOrigin is returned if the Origin or Referer header of a request does not match the request.
+	ErrOrigin = errors.New("csrf: origin not allowed")
+)
+
+type contextKey int
+
+const (
+	contextSecret contextKey = iota
+	contextFailure
+)
+
+// Protector protects against cross-site request forgery.
+// The zero value uses the double-submit cookie pattern with default settings.
+//
+// Can be used concurrent.
+type Protector struct {
+	// SessionID returns the session id of a request. Tokens are bound to it.
+	// If SessionID is nil or returns an empty string, the double-submit cookie pattern is used.
+	SessionID func(r *http.Request) string
+	// ValidDuration determines how long a token is valid. If it is zero, ValidDurationDefault is used.
+	ValidDuration time.Duration
+	// TrustedOrigins contains additional origins (e.g. "https://example.com") which are allowed to send requests.
+	TrustedOrigins []string
+	// CookieName is the name of the cookie for the double-submit cookie pattern. If it is empty, CookieNameDefault is used.
+	CookieName string
+	// Secure determines whether the cookie should only be sent over HTTPS.
+	// It also means that the site is served over HTTPS, so only https origins of the host are accepted even if TLS is terminated by a proxy.
+	Secure bool
+	// ErrorHandler is called for rejected requests. The reason can be retrieved with FailureReason. If it is nil, 403 Forbidden is returned.
+	ErrorHandler http.Handler
+}
+
+// Handler returns a middleware which checks all requests with unsafe methods (everything except GET, HEAD, OPTIONS and TRACE) before they are passed to next.
+// The token must be contained in the header X-CSRF-Token or the form field csrf_token.
+//
+// The middleware also sets the cookie for the double-submit cookie pattern, so Token can be used in next.
+func (p *Protector) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if p.sessionID(r) == "" {
+			secret, ok := p.cookieSecret(r)
+			if !ok {
+				var err error
+				secret, err = p.setCookie(rw)
+				if err != nil {
+					http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+					return
+				}
+			}
+			r = r.WithContext(context.WithValue(r.Context(), contextSecret, secret))
+		}
+
+		if !safeMethod(r.Method) {
+			err := p.Verify(r)
+			if err != nil {
+				r = r.WithContext(context.WithValue(r.Context(), contextFailure, err))
+				if p.ErrorHandler != nil {
+					p.ErrorHandler.ServeHTTP(rw, r)
+					return
+				}
+				http.Error(rw, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+		}
+
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// Token returns a new token for the given action. The action is the path the request will be sent to (e.g. the form action).
+// A token issued for the empty action is valid for all actions, which is useful e.g. for JavaScript clients using the header.
+//
+// Token can be called as often as needed. Every token is valid for ValidDuration, so issuing new tokens rotates them.
+// When used without session id, r must have passed the middleware returned by Handler.
+func (p *Protector) Token(r *http.Request, action string) (string, error) {
+	binding, ok := p.binding(r, action)
+	if !ok {
+		return "", errors.New("no session id or cookie available")
+	}
+	return data.GetStringsTimed(time.Now(), binding)
+}
+
+// Verify checks whether r contains a valid token and whether its origin is allowed.
+// It is called automatically by the middleware for unsafe requests.
+func (p *Protector) Verify(r *http.Request) error {
+	if !p.originAllowed(r) {
+		return ErrOrigin
+	}
+
+	token := r.Header.Get(HeaderName)
+	if token == "" {
+		token = r.PostFormValue(FieldName)
+	}
+	if token == "" {
+		return ErrInvalidToken
+	}
+
+	validDuration := p.ValidDuration
+	if validDuration == 0 {
+		validDuration = ValidDurationDefault
+	}
+	now := time.Now()
+
+	for _, action := range []string{r.URL.Path, ""} {
+		binding, ok := p.binding(r, action)
+		if !ok {
+			return ErrInvalidToken
+		}
+		if data.VerifyStringsTimed(token, binding, now, validDuration) {
+			return nil
+		}
+	}
+	return ErrInvalidToken
+}
+
+// Rotate replaces the cookie of the double-submit cookie pattern, which invalidates all tokens issued for it.
+// It should be called whenever the privileges of a user change (e.g. after login). The new value is used by Token for the rest of the request.
+// When sessions are used, rotating the session id has the same effect.
+func (p *Protector) Rotate(rw http.ResponseWriter, r *http.Request) (*http.Request, error) {
+	secret, err := p.setCookie(rw)
+	if err != nil {
+		return r, err
+	}
+	return r.WithContext(context.WithValue(r.Context(), contextSecret, secret)), nil
+}
+
+// FailureReason returns the reason a request was rejected. It can be used in the ErrorHandler of a Protector.
+func FailureReason(r *http.Request) error {
+	err, ok := r.Context().Value(contextFailure).(error)
+	if !ok {
+		return nil
+	}
+	return err
+}
+
+// sessionID returns the session id of r or an empty string.
+func (p *Protector) sessionID(r *http.Request) string {
+	if p.SessionID == nil {
+		return ""
+	}
+	return p.SessionID(r)
+}
+
+// binding returns the data a token for r and action is bound to.
+func (p *Protector) binding(r *http.Request, action string) (string, bool) {
+	if id := p.sessionID(r); id != "" {
+		return strings.Join([]string{"csrf", "session", id, action}, "\x00"), true
+	}
+	secret, ok := r.Context().Value(contextSecret).(string)
+	if !ok {
+		secret, ok = p.cookieSecret(r)
+		if !ok {
+			return "", false
+		}
+	}
+	return strings.Join([]string{"csrf", "cookie", secret, action}, "\x00"), true
+}
+
+// cookieName returns the name of the cookie.
+func (p *Protector) cookieName() string {
+	if p.CookieName == "" {
+		return CookieNameDefault
+	}
+	return p.CookieName
+}
+
+// cookieSecret returns the random value of the cookie of r.
+func (p *Protector) cookieSecret(r *http.Request) (string, bool) {
+	c, err := r.Cookie(p.cookieName())
+	if err != nil {
+		return "", false
+	}
+	b, err := base64.RawURLEncoding.DecodeString(c.Value)
+	if err != nil || len(b) != cookieRandomSize {
+		return "", false
+	}
+	return c.Value, true
+}
+
+// setCookie sets a new cookie with a random value and returns the value.
+func (p *Protector) setCookie(rw http.ResponseWriter) (string, error) {
+	b := make([]byte, cookieRandomSize)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	secret := base64.RawURLEncoding.EncodeToString(b)
+	http.SetCookie(rw, &http.Cookie{
+		Name:     p.cookieName(),
+		Value:    secret,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   p.Secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return secret, nil
+}
+
+// originAllowed checks the Origin header or, if it is missing, the Referer header of r.
+// Requests containing neither are allowed, since the token is checked anyway.
+// The scheme of the request is https if it was received over TLS or if p.Secure is set (e.g. behind a proxy terminating TLS).
+func (p *Protector) originAllowed(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		origin = r.Header.Get("Referer")
+		if origin == "" {
+			return true
+		}
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	o := u.Scheme + "://" + u.Host
+	scheme := "http"
+	if r.TLS != nil || p.Secure {
+		scheme = "https"
+	}
+	if o == scheme+"://"+r.Host {
+		return true
+	}
+	for i := range p.TrustedOrigins {
+		if o == p.TrustedOrigins[i] {
+			return true
+		}
+	}
+	return false
+}
+
+// safeMethod returns whether method does not change state according to RFC 7231.
+func safeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,305 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csrf
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+var okHandler = http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+	rw.WriteHeader(http.StatusOK)
+})
+
+// doubleSubmit returns a token and the cookie of a first request.
+func doubleSubmit(t *testing.T, p *Protector, action string) (string, *http.Cookie) {
+	var token string
+	h := p.Handler(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		var err error
+		token, err = p.Token(r, action)
+		if err != nil {
+			t.Errorf("error occured: %s", err.Error())
+		}
+	}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://example.com/form", nil))
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("wrong number of cookies (is: %d, should: %d)", len(cookies), 1)
+	}
+	return token, cookies[0]
+}
+
+func TestDoubleSubmit(t *testing.T) {
+	p := &Protector{}
+	token, cookie := doubleSubmit(t, p, "/transfer")
+	h := p.Handler(okHandler)
+
+	// Header
+	r := httptest.NewRequest(http.MethodPost, "http://example.com/transfer", nil)
+	r.AddCookie(cookie)
+	r.Header.Set(HeaderName, token)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	if rec.Code != http.StatusOK {
+		t.Errorf("valid request rejected (header): %d", rec.Code)
+	}
+
+	// Form
+	form := url.Values{}
+	form.Set(FieldName, token)
+	r = httptest.NewRequest(http.MethodPost, "http://example.com/transfer", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.AddCookie(cookie)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	if rec.Code != http.StatusOK {
+		t.Errorf("valid request rejected (form): %d", rec.Code)
+	}
+
+	// Missing token
+	r = httptest.NewRequest(http.MethodPost, "http://example.com/transfer", nil)
+	r.AddCookie(cookie)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("request without token accepted: %d", rec.Code)
+	}
+
+	// Safe methods are not checked
+	r = httptest.NewRequest(http.MethodGet, "http://example.com/transfer", nil)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	if rec.Code != http.StatusOK {
+		t.Errorf("safe request rejected: %d", rec.Code)
+	}
+
+	// Other action
+	r = httptest.NewRequest(http.MethodPost, "http://example.com/delete", nil)
+	r.AddCookie(cookie)
+	r.Header.Set(HeaderName, token)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("token accepted for other action: %d", rec.Code)
+	}
+
+	// Other cookie
+	_, otherCookie := doubleSubmit(t, p, "/transfer")
+	r = httptest.NewRequest(http.MethodPost, "http://example.com/transfer", nil)
+	r.AddCookie(otherCookie)
+	r.Header.Set(HeaderName, token)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("token accepted for other cookie: %d", rec.Code)
+	}
+
+	// No cookie
+	r = httptest.NewRequest(http.MethodPost, "http://example.com/transfer", nil)
+	r.Header.Set(HeaderName, token)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("token accepted without cookie: %d", rec.Code)
+	}
+
+	// Empty action is valid everywhere
+	token, cookie = doubleSubmit(t, p, "")
+	r = httptest.NewRequest(http.MethodPost, "http://example.com/delete", nil)
+	r.AddCookie(cookie)
+	r.Header.Set(HeaderName, token)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	if rec.Code != http.StatusOK {
+		t.Errorf("token without action rejected: %d", rec.Code)
+	}
+}
+
+func TestSession(t *testing.T) {
+	p := &Protector{
+		SessionID: func(r *http.Request) string {
+			return r.Header.Get("Session")
+		},
+	}
+	h := p.Handler(okHandler)
+
+	r := httptest.NewRequest(http.MethodGet, "http://example.com/form", nil)
+	r.Header.Set("Session", "session1")
+	token, err := p.Token(r, "/transfer")
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "http://example.com/transfer", nil)
+	r.Header.Set("Session", "session1")
+	r.Header.Set(HeaderName, token)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	if rec.Code != http.StatusOK {
+		t.Errorf("valid request rejected: %d", rec.Code)
+	}
+	if len(rec.Result().Cookies()) != 0 {
+		t.Error("cookie set although session is used")
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "http://example.com/transfer", nil)
+	r.Header.Set("Session", "session2")
+	r.Header.Set(HeaderName, token)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("token accepted for other session: %d", rec.Code)
+	}
+
+	// Without session, the cookie is used
+	r = httptest.NewRequest(http.MethodPost, "http://example.com/transfer", nil)
+	r.Header.Set(HeaderName, token)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("token accepted without session: %d", rec.Code)
+	}
+	if len(rec.Result().Cookies()) != 1 {
+		t.Error("no cookie set without session")
+	}
+}
+
+func TestOrigin(t *testing.T) {
+	var reason error
+	p := &Protector{
+		TrustedOrigins: []string{"https://trusted.example.com"},
+		ErrorHandler: http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			reason = FailureReason(r)
+			rw.WriteHeader(http.StatusTeapot)
+		}),
+	}
+	token, cookie := doubleSubmit(t, p, "/transfer")
+	h := p.Handler(okHandler)
+
+	tests := []struct {
+		header, value string
+		allowed       bool
+	}{
+		{"Origin", "http://example.com", true},
+		{"Origin", "https://example.com", false},
+		{"Origin", "https://trusted.example.com", true},
+		{"Origin", "http://trusted.example.com", false},
+		{"Origin", "https://evil.example.com", false},
+		{"Origin", "null", false},
+		{"Referer", "http://example.com/form", true},
+		{"Referer", "https://evil.example.com/form", false},
+	}
+
+	for i := range tests {
+		reason = nil
+		r := httptest.NewRequest(http.MethodPost, "http://example.com/transfer", nil)
+		r.AddCookie(cookie)
+		r.Header.Set(HeaderName, token)
+		r.Header.Set(tests[i].header, tests[i].value)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+		if tests[i].allowed && rec.Code != http.StatusOK {
+			t.Errorf("request rejected (%s: %s): %d", tests[i].header, tests[i].value, rec.Code)
+		}
+		if !tests[i].allowed {
+			if rec.Code != http.StatusTeapot {
+				t.Errorf("request accepted (%s: %s): %d", tests[i].header, tests[i].value, rec.Code)
+			}
+			if !errors.Is(reason, ErrOrigin) {
+				t.Errorf("wrong reason (%s: %s): %v", tests[i].header, tests[i].value, reason)
+			}
+		}
+	}
+}
+
+func TestOriginScheme(t *testing.T) {
+	tests := []struct {
+		secure  bool
+		target  string
+		origin  string
+		allowed bool
+	}{
+		{false, "https://example.com/transfer", "https://example.com", true},
+		{false, "https://example.com/transfer", "http://example.com", false},
+		{true, "http://example.com/transfer", "https://example.com", true},
+		{true, "http://example.com/transfer", "http://example.com", false},
+	}
+
+	for i := range tests {
+		p := &Protector{Secure: tests[i].secure}
+		token, cookie := doubleSubmit(t, p, "/transfer")
+		r := httptest.NewRequest(http.MethodPost, tests[i].target, nil)
+		r.AddCookie(cookie)
+		r.Header.Set(HeaderName, token)
+		r.Header.Set("Origin", tests[i].origin)
+		rec := httptest.NewRecorder()
+		p.Handler(okHandler).ServeHTTP(rec, r)
+		if tests[i].allowed && rec.Code != http.StatusOK {
+			t.Errorf("request rejected (secure: %v, target: %s, origin: %s): %d", tests[i].secure, tests[i].target, tests[i].origin, rec.Code)
+		}
+		if !tests[i].allowed && rec.Code != http.StatusForbidden {
+			t.Errorf("request accepted (secure: %v, target: %s, origin: %s): %d", tests[i].secure, tests[i].target, tests[i].origin, rec.Code)
+		}
+	}
+}
+
+func TestRotate(t *testing.T) {
+	p := &Protector{}
+	token, cookie := doubleSubmit(t, p, "/transfer")
+
+	var newToken string
+	h := p.Handler(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		r, err := p.Rotate(rw, r)
+		if err != nil {
+			t.Errorf("error occured: %s", err.Error())
+			return
+		}
+		newToken, err = p.Token(r, "/transfer")
+		if err != nil {
+			t.Errorf("error occured: %s", err.Error())
+		}
+	}))
+	r := httptest.NewRequest(http.MethodPost, "http://example.com/transfer", nil)
+	r.AddCookie(cookie)
+	r.Header.Set(HeaderName, token)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("wrong number of cookies (is: %d, should: %d)", len(cookies), 1)
+	}
+	newCookie := cookies[0]
+
+	r = httptest.NewRequest(http.MethodPost, "http://example.com/transfer", nil)
+	r.AddCookie(newCookie)
+	r.Header.Set(HeaderName, newToken)
+	if err := p.Verify(r); err != nil {
+		t.Errorf("new token rejected: %s", err.Error())
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "http://example.com/transfer", nil)
+	r.AddCookie(newCookie)
+	r.Header.Set(HeaderName, token)
+	if err := p.Verify(r); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("old token accepted after rotation: %v", err)
+	}
+}
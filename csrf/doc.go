@@ -0,0 +1,24 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package csrf contains a middleware protecting against cross-site request forgery.
+// Tokens are created with the timed authentification of package data, so no state is required on the server.
+// A token is bound to the session of the user and to the action (the path a form is sent to).
+//
+// For applications with sessions, the session id is used for binding. Applications without sessions use the double-submit cookie pattern: A random value is stored in a cookie and tokens are bound to it.
+// Additionally, the Origin and Referer headers of unsafe requests are checked.
+//
+// Since package data is used, all tokens become invalid whenever the program restarts.
+package csrf
@@ -0,0 +1,300 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package data
+
+// This file contains the chunked generator.
+
+import (
+	"crypto/hmac"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"hash"
+)
+
+const (
+	// ChunkSizeDefault contains the suggested default chunk size.
+	ChunkSizeDefault = 64 * 1024
+
+	// manifestVersion is the version of the binary encoding of a Manifest.
+	manifestVersion = 1
+)
+
+var (
+	// ErrLength is returned if more or less data than announced is written to a ChunkedSigner.
+	ErrLength = errors.New("data: wrong length")
+
+	// ErrManifest is returned if an encoded manifest can not be parsed.
+	ErrManifest = errors.New("data: invalid manifest")
+)
+
+// Manifest contains the authentification of data split into chunks of a fixed size.
+// Every chunk has its own MAC, which is bound to the index of the chunk, the chunk size and the total length. The root MAC authenticates all chunk MACs.
+// This allows verifying parts of the data (see VerifyRange) while detecting truncated, extended or reordered data.
+//
+// Like all ids of this package, manifests become invalid whenever the program restarts.
+type Manifest struct {
+	// ChunkSize is the size of all chunks except the last one, which might be shorter.
+	ChunkSize int
+	// Length is the total length of the data.
+	Length int64
+	// Chunks contains the MACs of the chunks.
+	Chunks [][]byte
+	// Root authenticates all other fields.
+	Root []byte
+}
+
+// ChunkedSigner creates a Manifest for data written to it, so that the data does not need to be held in memory.
+// The total length must be known in advance, since it is part of every chunk MAC.
+//
+// A ChunkedSigner must not be used concurrently.
+type ChunkedSigner struct {
+	chunkSize int
+	length    int64
+	written   int64
+	hash      hash.Hash
+	chunks    [][]byte
+	finished  bool
+}
+
+// NewChunkedSigner returns a ChunkedSigner for length bytes split into chunks of chunkSize.
+func NewChunkedSigner(chunkSize int, length int64) (*ChunkedSigner, error) {
+	if chunkSize < 1 {
+		return nil, errors.New("chunk size must be positive")
+	}
+	if length < 0 {
+		return nil, errors.New("length must not be negative")
+	}
+	initialisationRandomData.Do(func() {
+		setRandomData()
+	})
+	return &ChunkedSigner{chunkSize: chunkSize, length: length}, nil
+}
+
+// Write adds p to the data. It implements io.Writer. ErrLength is returned if more data than announced is written.
+func (s *ChunkedSigner) Write(p []byte) (n int, err error) {
+	if s.finished {
+		return 0, ErrFinished
+	}
+	if int64(len(p)) > s.length-s.written {
+		return 0, ErrLength
+	}
+
+	for len(p) > 0 {
+		if s.hash == nil {
+			s.hash = chunkHash(s.chunkSize, s.length, int64(len(s.chunks)))
+		}
+		free := s.chunkSize - int(s.written%int64(s.chunkSize))
+		if free > len(p) {
+			free = len(p)
+		}
+		s.hash.Write(p[:free])
+		s.written += int64(free)
+		n += free
+		p = p[free:]
+
+		if s.written%int64(s.chunkSize) == 0 || s.written == s.length {
+			s.chunks = append(s.chunks, s.hash.Sum(nil))
+			s.hash = nil
+		}
+	}
+	return n, nil
+}
+
+// Sum returns the manifest of all written data. ErrLength is returned if less data than announced was written.
+// Afterwards, no more data can be written.
+func (s *ChunkedSigner) Sum() (*Manifest, error) {
+	if s.written != s.length {
+		return nil, ErrLength
+	}
+	s.finished = true
+	m := &Manifest{ChunkSize: s.chunkSize, Length: s.length, Chunks: make([][]byte, len(s.chunks))}
+	for i := range s.chunks {
+		m.Chunks[i] = append([]byte(nil), s.chunks[i]...)
+	}
+	m.Root = m.root()
+	return m, nil
+}
+
+// GetChunked returns the manifest of data split into chunks of chunkSize.
+//
+// Can be used concurrent.
+func GetChunked(data []byte, chunkSize int) (*Manifest, error) {
+	s, err := NewChunkedSigner(chunkSize, int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	_, err = s.Write(data)
+	if err != nil {
+		return nil, err
+	}
+	return s.Sum()
+}
+
+// Valid returns whether the manifest was created by this package and was not modified. It does not need the data.
+//
+// Can be used concurrent.
+func (m *Manifest) Valid() bool {
+	if m.ChunkSize < 1 || m.Length < 0 || int64(len(m.Chunks)) != chunkCount(m.ChunkSize, m.Length) {
+		return false
+	}
+	for i := range m.Chunks {
+		if len(m.Chunks[i]) != hashSize {
+			return false
+		}
+	}
+	return subtle.ConstantTimeCompare(m.root(), m.Root) == 1
+}
+
+// Verify returns whether data is the complete data of the manifest.
+//
+// Can be used concurrent.
+func (m *Manifest) Verify(data []byte) bool {
+	return int64(len(data)) == m.Length && m.VerifyRange(0, data)
+}
+
+// Range returns the smallest range of whole chunks containing the n bytes starting at offset.
+// The data in this range has to be passed to VerifyRange.
+func (m *Manifest) Range(offset, n int64) (start, end int64, err error) {
+	if m.ChunkSize < 1 || offset < 0 || n < 0 || offset > m.Length || n > m.Length-offset {
+		return 0, 0, errors.New("range out of bounds")
+	}
+	size := int64(m.ChunkSize)
+	start = offset / size * size
+	end = (offset + n + size - 1) / size * size
+	if end > m.Length {
+		end = m.Length
+	}
+	return start, end, nil
+}
+
+// VerifyRange returns whether data is the part of the data of the manifest starting at start.
+// start must be the beginning of a chunk and data must consist of whole chunks (only the last chunk of the data can be shorter). See Range.
+// Only the given chunks are hashed, but the manifest is verified as well, so that truncated or reordered chunks are detected.
+//
+// Can be used concurrent.
+func (m *Manifest) VerifyRange(start int64, data []byte) bool {
+	if !m.Valid() {
+		return false
+	}
+	size := int64(m.ChunkSize)
+	if start < 0 || start%size != 0 || start > m.Length || int64(len(data)) > m.Length-start {
+		return false
+	}
+	end := start + int64(len(data))
+	if end%size != 0 && end != m.Length {
+		return false
+	}
+
+	for index := start / size; len(data) > 0; index++ {
+		l := m.ChunkSize
+		if l > len(data) {
+			l = len(data)
+		}
+		h := chunkHash(m.ChunkSize, m.Length, index)
+		h.Write(data[:l])
+		if subtle.ConstantTimeCompare(h.Sum(nil), m.Chunks[index]) == 0 {
+			return false
+		}
+		data = data[l:]
+	}
+	return true
+}
+
+// MarshalBinary returns a compact binary encoding of m.
+func (m *Manifest) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 0, 1+2*binary.MaxVarintLen64+len(m.Root)+len(m.Chunks)*hashSize)
+	b = append(b, manifestVersion)
+	var buf [binary.MaxVarintLen64]byte
+	b = append(b, buf[:binary.PutUvarint(buf[:], uint64(m.ChunkSize))]...)
+	b = append(b, buf[:binary.PutUvarint(buf[:], uint64(m.Length))]...)
+	b = append(b, m.Root...)
+	for i := range m.Chunks {
+		b = append(b, m.Chunks[i]...)
+	}
+	return b, nil
+}
+
+// UnmarshalBinary sets m to the manifest encoded in b. ErrManifest is returned if b can not be parsed.
+// The manifest is not verified, but all verifying methods check it.
+func (m *Manifest) UnmarshalBinary(b []byte) error {
+	if len(b) < 1 || b[0] != manifestVersion {
+		return ErrManifest
+	}
+	b = b[1:]
+	chunkSize, n := binary.Uvarint(b)
+	if n <= 0 || chunkSize < 1 || chunkSize > uint64(int(^uint(0)>>1)) {
+		return ErrManifest
+	}
+	b = b[n:]
+	length, n := binary.Uvarint(b)
+	if n <= 0 || length > 1<<62 {
+		return ErrManifest
+	}
+	b = b[n:]
+	count := chunkCount(int(chunkSize), int64(length))
+	if count > int64(len(b)/hashSize) || int64(len(b)) != (count+1)*int64(hashSize) {
+		return ErrManifest
+	}
+
+	m.ChunkSize = int(chunkSize)
+	m.Length = int64(length)
+	m.Root = append([]byte(nil), b[:hashSize]...)
+	b = b[hashSize:]
+	m.Chunks = make([][]byte, count)
+	for i := range m.Chunks {
+		m.Chunks[i] = append([]byte(nil), b[:hashSize]...)
+		b = b[hashSize:]
+	}
+	return nil
+}
+
+// root returns the root MAC of m.
+func (m *Manifest) root() []byte {
+	initialisationRandomData.Do(func() {
+		setRandomData()
+	})
+	h := hmac.New(hashGenerator, deriveKey("data chunked root"))
+	h.Write(chunkHeader(m.ChunkSize, m.Length, int64(len(m.Chunks))))
+	for i := range m.Chunks {
+		h.Write(m.Chunks[i])
+	}
+	return h.Sum(nil)
+}
+
+// chunkHash returns a hash for the chunk with the given index, which already contains the header.
+func chunkHash(chunkSize int, length, index int64) hash.Hash {
+	initialisationRandomData.Do(func() {
+		setRandomData()
+	})
+	h := hmac.New(hashGenerator, deriveKey("data chunk"))
+	h.Write(chunkHeader(chunkSize, length, index))
+	return h
+}
+
+// chunkHeader encodes the values a chunk MAC is bound to.
+func chunkHeader(chunkSize int, length, index int64) []byte {
+	b := make([]byte, 24)
+	binary.BigEndian.PutUint64(b, uint64(chunkSize))
+	binary.BigEndian.PutUint64(b[8:], uint64(length))
+	binary.BigEndian.PutUint64(b[16:], uint64(index))
+	return b
+}
+
+// chunkCount returns the number of chunks of length bytes.
+func chunkCount(chunkSize int, length int64) int64 {
+	return (length + int64(chunkSize) - 1) / int64(chunkSize)
+}
@@ -0,0 +1,282 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package data
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestGetChunked(t *testing.T) {
+	payload := testPayload()
+	for _, chunkSize := range []int{1000, 4096, len(payload), len(payload) * 2} {
+		m, err := GetChunked(payload, chunkSize)
+		if err != nil {
+			t.Logf("error occured: %s", err.Error())
+			t.FailNow()
+		}
+		should := (len(payload) + chunkSize - 1) / chunkSize
+		if len(m.Chunks) != should {
+			t.Errorf("wrong number of chunks (is: %d, should: %d)", len(m.Chunks), should)
+		}
+		if !m.Valid() {
+			t.Errorf("manifest not valid (chunk size %d)", chunkSize)
+		}
+		if !m.Verify(payload) {
+			t.Errorf("data not accepted (chunk size %d)", chunkSize)
+		}
+	}
+
+	m, err := GetChunked(nil, ChunkSizeDefault)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if len(m.Chunks) != 0 || !m.Verify(nil) || m.Verify([]byte{0}) {
+		t.Errorf("wrong manifest of empty data")
+	}
+
+	_, err = GetChunked(payload, 0)
+	if err == nil {
+		t.Errorf("chunk size 0 not rejected")
+	}
+}
+
+func TestChunkedSigner(t *testing.T) {
+	payload := testPayload()
+	should, err := GetChunked(payload, 4096)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+
+	for _, writeSize := range []int{1, 1000, 4096, 5000} {
+		s, err := NewChunkedSigner(4096, int64(len(payload)))
+		if err != nil {
+			t.Logf("error occured: %s", err.Error())
+			t.FailNow()
+		}
+		_, err = io.CopyBuffer(s, bytes.NewReader(payload), make([]byte, writeSize))
+		if err != nil {
+			t.Logf("error occured: %s", err.Error())
+			t.FailNow()
+		}
+		m, err := s.Sum()
+		if err != nil {
+			t.Logf("error occured: %s", err.Error())
+			t.FailNow()
+		}
+		if !bytes.Equal(m.Root, should.Root) {
+			t.Errorf("wrong root with write size %d", writeSize)
+		}
+		_, err = s.Write([]byte{})
+		if !errors.Is(err, ErrFinished) {
+			t.Errorf("write after Sum not rejected (is: %v, should: %v)", err, ErrFinished)
+		}
+	}
+
+	s, err := NewChunkedSigner(4096, 10)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	_, err = s.Write(make([]byte, 11))
+	if !errors.Is(err, ErrLength) {
+		t.Errorf("too much data not rejected (is: %v, should: %v)", err, ErrLength)
+	}
+	_, err = s.Write(make([]byte, 9))
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	_, err = s.Sum()
+	if !errors.Is(err, ErrLength) {
+		t.Errorf("too little data not rejected (is: %v, should: %v)", err, ErrLength)
+	}
+}
+
+func TestManifestVerifyRange(t *testing.T) {
+	payload := testPayload()
+	const chunkSize = 4096
+	m, err := GetChunked(payload, chunkSize)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+
+	ranges := [][2]int64{{0, 1}, {5000, 10}, {4095, 2}, {int64(len(payload)) - 1, 1}, {12345, 50000}, {0, int64(len(payload))}, {8192, 0}}
+	for _, r := range ranges {
+		start, end, err := m.Range(r[0], r[1])
+		if err != nil {
+			t.Logf("error occured: %s", err.Error())
+			t.FailNow()
+		}
+		if start > r[0] || end < r[0]+r[1] || start%chunkSize != 0 || (end%chunkSize != 0 && end != int64(len(payload))) {
+			t.Errorf("wrong range for %v (is: %d-%d)", r, start, end)
+		}
+		if !m.VerifyRange(start, payload[start:end]) {
+			t.Errorf("range %d-%d not accepted", start, end)
+		}
+	}
+
+	for _, r := range [][2]int64{{-1, 1}, {0, int64(len(payload)) + 1}, {int64(len(payload)), 1}} {
+		_, _, err = m.Range(r[0], r[1])
+		if err == nil {
+			t.Errorf("invalid range %v not rejected", r)
+		}
+	}
+
+	modified := append([]byte(nil), payload...)
+	modified[chunkSize+10] ^= 1
+	swapped := append([]byte(nil), payload[chunkSize:2*chunkSize]...)
+	swapped = append(swapped, payload[:chunkSize]...)
+
+	tests := map[string]struct {
+		start int64
+		data  []byte
+	}{
+		"modified":      {0, modified[:2*chunkSize]},
+		"reordered":     {0, swapped},
+		"wrong start":   {chunkSize, payload[:chunkSize]},
+		"unaligned":     {10, payload[10:chunkSize]},
+		"partial chunk": {0, payload[:chunkSize-1]},
+		"too long":      {int64(len(payload)) / chunkSize * chunkSize, append(append([]byte(nil), payload[len(payload)/chunkSize*chunkSize:]...), 0)},
+	}
+	for k := range tests {
+		t.Run(k, func(t *testing.T) {
+			if m.VerifyRange(tests[k].start, tests[k].data) {
+				t.Errorf("invalid range accepted")
+			}
+		})
+	}
+}
+
+func TestManifestTruncation(t *testing.T) {
+	payload := testPayload()
+	const chunkSize = 4096
+	m, err := GetChunked(payload, chunkSize)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+
+	// Truncated data is rejected, even if it ends at a chunk boundary.
+	truncated := payload[:len(payload)/chunkSize*chunkSize]
+	if m.Verify(truncated) {
+		t.Errorf("truncated data accepted")
+	}
+
+	// A manifest of the truncated data can not be forged from the original manifest.
+	forged := &Manifest{ChunkSize: m.ChunkSize, Length: int64(len(truncated)), Chunks: m.Chunks[:len(m.Chunks)-1], Root: m.Root}
+	if forged.Valid() || forged.Verify(truncated) {
+		t.Errorf("forged manifest accepted")
+	}
+
+	// Chunks are bound to the total length.
+	short, err := GetChunked(payload[:len(payload)-1], chunkSize)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if bytes.Equal(short.Chunks[0], m.Chunks[0]) {
+		t.Errorf("chunk not bound to length")
+	}
+
+	// Modified manifests are rejected.
+	modified := *m
+	modified.Chunks = append([][]byte(nil), m.Chunks...)
+	modified.Chunks[0], modified.Chunks[1] = modified.Chunks[1], modified.Chunks[0]
+	if modified.Valid() {
+		t.Errorf("reordered manifest accepted")
+	}
+	modified = *m
+	modified.ChunkSize = 2048
+	if modified.Valid() {
+		t.Errorf("manifest with other chunk size accepted")
+	}
+}
+
+func TestManifestFromGet(t *testing.T) {
+	// A manifest must not be constructible from ids of other functions, even if the attacker controls the data.
+	payload := []byte("forged")
+	const chunkSize = 4096
+	header := chunkHeader(chunkSize, int64(len(payload)), 0)
+
+	chunk, err := Get(append(append([]byte("data chunk\x00"), header...), payload...))
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	root, err := Get(append(append([]byte("data chunked root\x00"), chunkHeader(chunkSize, int64(len(payload)), 1)...), chunk...))
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+
+	forged := &Manifest{ChunkSize: chunkSize, Length: int64(len(payload)), Chunks: [][]byte{chunk}, Root: root}
+	if forged.Valid() || forged.Verify(payload) {
+		t.Errorf("manifest created with Get accepted")
+	}
+
+	// Without the prefixes used before.
+	chunk, err = Get(append(header, payload...))
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	root, err = Get(append(chunkHeader(chunkSize, int64(len(payload)), 1), chunk...))
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	forged = &Manifest{ChunkSize: chunkSize, Length: int64(len(payload)), Chunks: [][]byte{chunk}, Root: root}
+	if forged.Valid() || forged.Verify(payload) {
+		t.Errorf("manifest created with Get accepted")
+	}
+}
+
+func TestManifestBinary(t *testing.T) {
+	payload := testPayload()
+	m, err := GetChunked(payload, 4096)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	b, err := m.MarshalBinary()
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+
+	parsed := new(Manifest)
+	err = parsed.UnmarshalBinary(b)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if !parsed.Valid() || !parsed.Verify(payload) {
+		t.Errorf("parsed manifest not accepted")
+	}
+
+	for _, invalid := range [][]byte{nil, {0}, b[:len(b)-1], append(append([]byte(nil), b...), 0), {manifestVersion, 1, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x3f}} {
+		err = new(Manifest).UnmarshalBinary(invalid)
+		if !errors.Is(err, ErrManifest) {
+			t.Errorf("invalid encoding not rejected (is: %v, should: %v)", err, ErrManifest)
+		}
+	}
+}
@@ -20,4 +20,13 @@
 // There are two caviats, though:
 // * A hidden value is used to make predictions impossible. This means that whenever you restart the program, old ids are no longer valid.
 // * One data / id combination is always valid (as long as the hidden value is the same).
+//
+// Packages which need a secret key (e.g. for encryption) can get one derived from the hidden value through Key. Keys are never returned as ids.
+//
+// Data which should not be held in memory (e.g. large uploads) can be written to a Signer or Verifier, which create and accept the same ids as the functions taking a slice.
+// If parts of the data have to be verified on their own (e.g. for range requests or resumable uploads), a ChunkedSigner creates a Manifest with a MAC per chunk.
+//
+// If ids have to be verified by others (e.g. other services or partners), a SigningKey can be used instead of the hidden value.
+// It signs with Ed25519, so verifiers only need the public keys (see PublicKeySet), which can be published as JSON Web Key Set.
+// Signing keys are not created automatically, so ids stay valid across restarts as long as you store the key.
 package data
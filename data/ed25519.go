@@ -0,0 +1,327 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package data
+
+// This file contains the asymmetric generator using Ed25519.
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// signedPlain marks ids created by SigningKey.Get.
+	signedPlain = 1
+	// signedTimed marks ids created by SigningKey.GetTimed.
+	signedTimed = 2
+
+	// MaxKeyIDLength is the maximum length of a key id.
+	MaxKeyIDLength = 255
+)
+
+// SigningKey creates ids with an Ed25519 private key. The ids can be verified by everybody holding the public key (see PublicKeySet), but only the holder of the private key can create them.
+// Unlike the hidden value, the key is not created automatically and survives restarts if you store it.
+//
+// Every id contains the key id, so that the verifier can select the right public key and keys can be rotated.
+//
+// Can be used concurrent.
+type SigningKey struct {
+	keyID   string
+	private ed25519.PrivateKey
+}
+
+// GenerateSigningKey returns a new random key with the given key id. The key id must have 1 to MaxKeyIDLength bytes.
+func GenerateSigningKey(keyID string) (*SigningKey, error) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return NewSigningKey(keyID, private)
+}
+
+// NewSigningKey returns a key using private with the given key id. The key id must have 1 to MaxKeyIDLength bytes.
+func NewSigningKey(keyID string, private ed25519.PrivateKey) (*SigningKey, error) {
+	if len(keyID) == 0 || len(keyID) > MaxKeyIDLength {
+		return nil, errors.New("invalid key id")
+	}
+	if len(private) != ed25519.PrivateKeySize {
+		return nil, errors.New("invalid private key")
+	}
+	return &SigningKey{keyID: keyID, private: append(ed25519.PrivateKey(nil), private...)}, nil
+}
+
+// KeyID returns the key id of k.
+func (k *SigningKey) KeyID() string {
+	return k.keyID
+}
+
+// PrivateKey returns the private key of k, e.g. for storing it. It must be kept secret.
+func (k *SigningKey) PrivateKey() ed25519.PrivateKey {
+	return append(ed25519.PrivateKey(nil), k.private...)
+}
+
+// PublicKey returns the public key of k, which can be added to a PublicKeySet.
+func (k *SigningKey) PublicKey() ed25519.PublicKey {
+	return k.private.Public().(ed25519.PublicKey)
+}
+
+// Get returns a signed id for data.
+//
+// Can be used concurrent.
+func (k *SigningKey) Get(data []byte) (id []byte, err error) {
+	header := signedHeader(signedPlain, k.keyID, nil)
+	return append(header, ed25519.Sign(k.private, signedMessage(header, data))...), nil
+}
+
+// GetTimed returns a timed signed id for data.
+// start determines the time from which the authentification is valid.
+//
+// Can be used concurrent.
+func (k *SigningKey) GetTimed(start time.Time, data []byte) (id []byte, err error) {
+	timeEncoded, err := start.GobEncode()
+	if err != nil {
+		return
+	}
+	header := signedHeader(signedTimed, k.keyID, timeEncoded)
+	return append(header, ed25519.Sign(k.private, signedMessage(header, data))...), nil
+}
+
+// GetStrings returns a string representation of a signed id for data.
+//
+// Can be used concurrent.
+func (k *SigningKey) GetStrings(data string) (id string, err error) {
+	i, err := k.Get([]byte(data))
+	if err != nil {
+		return
+	}
+	id = base64.StdEncoding.EncodeToString(i)
+	return
+}
+
+// GetStringsTimed returns a string representation of a timed signed id for data.
+//
+// Can be used concurrent.
+func (k *SigningKey) GetStringsTimed(start time.Time, data string) (id string, err error) {
+	i, err := k.GetTimed(start, []byte(data))
+	if err != nil {
+		return
+	}
+	id = base64.StdEncoding.EncodeToString(i)
+	return
+}
+
+// PublicKeySet verifies ids created by SigningKeys. The zero value is an empty set.
+//
+// Can be used concurrent.
+type PublicKeySet struct {
+	m    sync.RWMutex
+	keys map[string]ed25519.PublicKey
+}
+
+// Add adds key with the given key id. An existing key with the same key id is replaced.
+func (s *PublicKeySet) Add(keyID string, key ed25519.PublicKey) error {
+	if len(keyID) == 0 || len(keyID) > MaxKeyIDLength {
+		return errors.New("invalid key id")
+	}
+	if len(key) != ed25519.PublicKeySize {
+		return errors.New("invalid public key")
+	}
+
+	s.m.Lock()
+	defer s.m.Unlock()
+	if s.keys == nil {
+		s.keys = make(map[string]ed25519.PublicKey)
+	}
+	s.keys[keyID] = append(ed25519.PublicKey(nil), key...)
+	return nil
+}
+
+// Remove removes the key with the given key id. Ids created with it are no longer valid.
+func (s *PublicKeySet) Remove(keyID string) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	delete(s.keys, keyID)
+}
+
+// Key returns the key with the given key id.
+func (s *PublicKeySet) Key(keyID string) (ed25519.PublicKey, bool) {
+	s.m.RLock()
+	defer s.m.RUnlock()
+	k, ok := s.keys[keyID]
+	if !ok {
+		return nil, false
+	}
+	return append(ed25519.PublicKey(nil), k...), true
+}
+
+// KeyIDs returns the sorted key ids of all keys in s.
+func (s *PublicKeySet) KeyIDs() []string {
+	s.m.RLock()
+	defer s.m.RUnlock()
+	ids := make([]string, 0, len(s.keys))
+	for k := range s.keys {
+		ids = append(ids, k)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Verify validates whether an id / data combination was created by SigningKey.Get with a key in s.
+//
+// Can be used concurrent.
+func (s *PublicKeySet) Verify(id, data []byte) bool {
+	kind, _, _, ok := s.verify(id, data)
+	return ok && kind == signedPlain
+}
+
+// VerifyTimed validates whether an id / data combination was created by SigningKey.GetTimed with a key in s and is in date.
+// Duration determines how long an id should be seen as valid.
+//
+// Can be used concurrent.
+func (s *PublicKeySet) VerifyTimed(id, data []byte, now time.Time, validDuration time.Duration) bool {
+	kind, _, timeEncoded, ok := s.verify(id, data)
+	if !ok || kind != signedTimed {
+		return false
+	}
+	var t time.Time
+	err := t.GobDecode(timeEncoded)
+	if err != nil {
+		return false
+	}
+	if now.Before(t) {
+		return false
+	}
+	if now.Sub(t) > validDuration {
+		return false
+	}
+	return true
+}
+
+// VerifyStrings verifies an id / data combination created by SigningKey.GetStrings.
+//
+// Can be used concurrent.
+func (s *PublicKeySet) VerifyStrings(id, data string) bool {
+	i, err := base64.StdEncoding.DecodeString(id)
+	if err != nil {
+		return false
+	}
+	return s.Verify(i, []byte(data))
+}
+
+// VerifyStringsTimed verifies a timed id / data combination created by SigningKey.GetStringsTimed.
+//
+// Can be used concurrent.
+func (s *PublicKeySet) VerifyStringsTimed(id, data string, now time.Time, validDuration time.Duration) bool {
+	i, err := base64.StdEncoding.DecodeString(id)
+	if err != nil {
+		return false
+	}
+	return s.VerifyTimed(i, []byte(data), now, validDuration)
+}
+
+// KeyID returns the key id contained in an id created by a SigningKey. The key id is not verified.
+// It can be used to look up the public key (e.g. from a JWKS endpoint) before verification.
+func KeyID(id []byte) (keyID string, ok bool) {
+	_, keyID, _, _, ok = parseSigned(id)
+	return
+}
+
+// KeyIDStrings returns the key id contained in a string id created by a SigningKey. The key id is not verified.
+func KeyIDStrings(id string) (keyID string, ok bool) {
+	i, err := base64.StdEncoding.DecodeString(id)
+	if err != nil {
+		return "", false
+	}
+	return KeyID(i)
+}
+
+// verify checks the signature of id and returns its content.
+func (s *PublicKeySet) verify(id, data []byte) (kind byte, keyID string, timeEncoded []byte, ok bool) {
+	kind, keyID, timeEncoded, signature, ok := parseSigned(id)
+	if !ok {
+		return
+	}
+	s.m.RLock()
+	key, found := s.keys[keyID]
+	s.m.RUnlock()
+	if !found {
+		ok = false
+		return
+	}
+	header := id[:len(id)-ed25519.SignatureSize]
+	ok = ed25519.Verify(key, signedMessage(header, data), signature)
+	return
+}
+
+// signedHeader returns the part of an id before the signature.
+func signedHeader(kind byte, keyID string, timeEncoded []byte) []byte {
+	b := make([]byte, 0, 3+len(keyID)+len(timeEncoded)+ed25519.SignatureSize)
+	b = append(b, kind, byte(len(keyID)))
+	b = append(b, keyID...)
+	if kind == signedTimed {
+		b = append(b, byte(len(timeEncoded)))
+		b = append(b, timeEncoded...)
+	}
+	return b
+}
+
+// parseSigned splits an id created by a SigningKey.
+func parseSigned(id []byte) (kind byte, keyID string, timeEncoded, signature []byte, ok bool) {
+	if len(id) < 2+ed25519.SignatureSize {
+		return
+	}
+	kind = id[0]
+	if kind != signedPlain && kind != signedTimed {
+		return
+	}
+	l := int(id[1])
+	rest := id[2:]
+	if l == 0 || len(rest) < l+ed25519.SignatureSize {
+		return
+	}
+	keyID, rest = string(rest[:l]), rest[l:]
+	if kind == signedTimed {
+		if len(rest) < 1 {
+			return
+		}
+		l = int(rest[0])
+		rest = rest[1:]
+		if len(rest) < l+ed25519.SignatureSize {
+			return
+		}
+		timeEncoded, rest = rest[:l], rest[l:]
+	}
+	if len(rest) != ed25519.SignatureSize {
+		return
+	}
+	signature = rest
+	ok = true
+	return
+}
+
+// signedMessage returns the message signed for an id with the given header. The prefix separates the signatures from other uses of the key.
+func signedMessage(header, data []byte) []byte {
+	const prefix = "data ed25519\x00"
+	b := make([]byte, 0, len(prefix)+len(header)+len(data))
+	b = append(b, prefix...)
+	b = append(b, header...)
+	return append(b, data...)
+}
@@ -0,0 +1,219 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package data
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func testSigningKey(t *testing.T, keyID string) (*SigningKey, *PublicKeySet) {
+	k, err := GenerateSigningKey(keyID)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	s := new(PublicKeySet)
+	err = s.Add(k.KeyID(), k.PublicKey())
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	return k, s
+}
+
+func TestSigningKeyGet(t *testing.T) {
+	k, s := testSigningKey(t, "key 1")
+	data := []byte{24, 122, 5, 3}
+
+	i, err := k.Get(data)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if !s.Verify(i, data) {
+		t.Errorf("valid id not accepted")
+	}
+	if s.Verify(i, []byte{24, 122, 5, 4}) {
+		t.Errorf("other data accepted")
+	}
+	if s.VerifyTimed(i, data, time.Now(), time.Hour) {
+		t.Errorf("plain id accepted as timed id")
+	}
+
+	for n := range i {
+		modified := append([]byte(nil), i...)
+		modified[n] ^= 1
+		if s.Verify(modified, data) {
+			t.Errorf("id modified at byte %d accepted", n)
+		}
+	}
+	for n := 0; n < len(i); n++ {
+		if s.Verify(i[:n], data) {
+			t.Errorf("truncated id (%d bytes) accepted", n)
+		}
+	}
+
+	// Only the public key is needed for verification, but ids of other keys are rejected.
+	other, _ := testSigningKey(t, "key 1")
+	i, err = other.Get(data)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if s.Verify(i, data) {
+		t.Errorf("id of other key accepted")
+	}
+}
+
+func TestSigningKeyGetTimed(t *testing.T) {
+	k, s := testSigningKey(t, "key")
+	data := []byte{24, 122, 5, 3}
+	now := time.Now()
+
+	i, err := k.GetTimed(now, data)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if !s.VerifyTimed(i, data, now.Add(time.Minute), time.Hour) {
+		t.Errorf("valid id not accepted")
+	}
+	if s.VerifyTimed(i, data, now.Add(2*time.Hour), time.Hour) {
+		t.Errorf("expired id accepted")
+	}
+	if s.VerifyTimed(i, data, now.Add(-time.Minute), time.Hour) {
+		t.Errorf("id from the future accepted")
+	}
+	if s.VerifyTimed(i, []byte{1}, now, time.Hour) {
+		t.Errorf("other data accepted")
+	}
+	if s.Verify(i, data) {
+		t.Errorf("timed id accepted as plain id")
+	}
+}
+
+func TestSigningKeyStrings(t *testing.T) {
+	k, s := testSigningKey(t, "key")
+	now := time.Now()
+
+	i, err := k.GetStrings("data")
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if !s.VerifyStrings(i, "data") || s.VerifyStrings(i, "other") || s.VerifyStrings("!"+i, "data") {
+		t.Errorf("wrong result of VerifyStrings")
+	}
+
+	i, err = k.GetStringsTimed(now, "data")
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if !s.VerifyStringsTimed(i, "data", now, time.Minute) || s.VerifyStringsTimed(i, "other", now, time.Minute) || s.VerifyStringsTimed(i, "data", now.Add(time.Hour), time.Minute) {
+		t.Errorf("wrong result of VerifyStringsTimed")
+	}
+
+	keyID, ok := KeyIDStrings(i)
+	if !ok || keyID != "key" {
+		t.Errorf("wrong key id (is: %s %v, should: key true)", keyID, ok)
+	}
+	_, ok = KeyIDStrings("!")
+	if ok {
+		t.Errorf("key id of invalid string returned")
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	oldKey, s := testSigningKey(t, "old")
+	newKey, err := GenerateSigningKey("new")
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	err = s.Add(newKey.KeyID(), newKey.PublicKey())
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+
+	oldID, err := oldKey.Get([]byte("data"))
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	newID, err := newKey.Get([]byte("data"))
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	for _, c := range []struct {
+		id    []byte
+		keyID string
+	}{{oldID, "old"}, {newID, "new"}} {
+		keyID, ok := KeyID(c.id)
+		if !ok || keyID != c.keyID {
+			t.Errorf("wrong key id (is: %s %v, should: %s true)", keyID, ok, c.keyID)
+		}
+		if !s.Verify(c.id, []byte("data")) {
+			t.Errorf("id of key %s not accepted", c.keyID)
+		}
+	}
+
+	s.Remove("old")
+	if s.Verify(oldID, []byte("data")) {
+		t.Errorf("id of removed key accepted")
+	}
+	if !s.Verify(newID, []byte("data")) {
+		t.Errorf("id of new key not accepted")
+	}
+	if ids := s.KeyIDs(); len(ids) != 1 || ids[0] != "new" {
+		t.Errorf("wrong key ids (is: %v, should: [new])", ids)
+	}
+}
+
+func TestNewSigningKey(t *testing.T) {
+	k, _ := testSigningKey(t, "key")
+	restored, err := NewSigningKey("key", k.PrivateKey())
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if !bytes.Equal(restored.PublicKey(), k.PublicKey()) {
+		t.Errorf("restored key differs")
+	}
+
+	for _, keyID := range []string{"", string(make([]byte, MaxKeyIDLength+1))} {
+		_, err = NewSigningKey(keyID, k.PrivateKey())
+		if err == nil {
+			t.Errorf("invalid key id of length %d not rejected", len(keyID))
+		}
+		err = new(PublicKeySet).Add(keyID, k.PublicKey())
+		if err == nil {
+			t.Errorf("invalid key id of length %d not rejected by Add", len(keyID))
+		}
+	}
+	_, err = NewSigningKey("key", k.PrivateKey()[:10])
+	if err == nil {
+		t.Errorf("invalid private key not rejected")
+	}
+	err = new(PublicKeySet).Add("key", k.PublicKey()[:10])
+	if err == nil {
+		t.Errorf("invalid public key not rejected")
+	}
+}
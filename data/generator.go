@@ -28,12 +28,13 @@ import (
 
 var (
 	randomData               = []byte{}
+	keyData                  = []byte{}
 	initialisationRandomData = sync.Once{}
 	hashGenerator            = sha256.New
 	hashSize                 = hashGenerator().Size()
 )
 
-// setRandomData sets the hidden random data. It should be called before generating the first id, and only once (since resetting makes all older captchas invalid).
+// setRandomData sets the hidden random data and the hidden key data. It should be called before generating the first id, and only once (since resetting makes all older captchas invalid).
 // The generator functions do this automatically, so there is no need to call it manually.
 func setRandomData() error {
 	b := make([]byte, hashSize*2)
@@ -42,11 +43,39 @@ func setRandomData() error {
 		return err
 	}
 
+	k := make([]byte, hashSize*2)
+	_, err = rand.Read(k)
+	if err != nil {
+		return err
+	}
+
 	randomData = b
+	keyData = k
 
 	return nil
 }
 
+// Key returns a secret key for the given purpose, e.g. the name of a package.
+// The key is derived from a hidden value which is never used for ids, so no output of Get or GetTimed can be used as a key.
+// Unlike ids, keys must not be shown publicly. Like ids, keys change whenever you restart the program.
+//
+// Can be used concurrent.
+func Key(purpose string) (key []byte, err error) {
+	initialisationRandomData.Do(func() {
+		setRandomData()
+	})
+
+	return deriveKey(purpose), nil
+}
+
+// deriveKey returns a secret key for purpose. The hidden values must be set before.
+// Since the key is derived from the hidden key data, no output of Get or GetTimed can be used as a key.
+func deriveKey(purpose string) []byte {
+	hash := hmac.New(hashGenerator, keyData)
+	hash.Write([]byte(purpose))
+	return hash.Sum(nil)
+}
+
 // Get returns one random id / captcha combination.
 //
 // Can be used concurrent.
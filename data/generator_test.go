@@ -16,6 +16,7 @@
 package data
 
 import (
+	"bytes"
 	"testing"
 	"time"
 )
@@ -209,3 +210,42 @@ func TestVerifyTimed(t *testing.T) {
 		t.Error("capcha verification succeeded for wrong data (Modified timestamp)")
 	}
 }
+
+func TestKey(t *testing.T) {
+	k, err := Key("purpose")
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if len(k) != hashSize {
+		t.Errorf("k has wrong size (is: %d, should: %d)", len(k), hashSize)
+	}
+
+	k2, err := Key("purpose")
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if !bytes.Equal(k, k2) {
+		t.Errorf("key not stable")
+	}
+
+	k2, err = Key("other purpose")
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if bytes.Equal(k, k2) {
+		t.Errorf("same key for different purposes")
+	}
+
+	// Keys must never be returned as ids.
+	i, err := Get([]byte("purpose"))
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if bytes.Equal(k, i) {
+		t.Errorf("key equals id of purpose")
+	}
+}
@@ -0,0 +1,92 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package data
+
+// This file contains the JSON encoding of public keys.
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+// jwk is a JSON Web Key (RFC 7517) containing an Ed25519 public key (RFC 8037).
+type jwk struct {
+	KeyType   string `json:"kty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	KeyID     string `json:"kid,omitempty"`
+	Algorithm string `json:"alg,omitempty"`
+	Use       string `json:"use,omitempty"`
+}
+
+// jwks is a JSON Web Key Set.
+type jwks struct {
+	Keys []jwk `json:"keys"`
+}
+
+// MarshalJSON returns the keys of s as JSON Web Key Set (RFC 7517 and RFC 8037), which can be published for verifiers.
+// The keys are sorted by key id.
+func (s *PublicKeySet) MarshalJSON() ([]byte, error) {
+	set := jwks{Keys: make([]jwk, 0)}
+	for _, keyID := range s.KeyIDs() {
+		k, ok := s.Key(keyID)
+		if !ok {
+			// Removed concurrently.
+			continue
+		}
+		set.Keys = append(set.Keys, jwk{
+			KeyType:   "OKP",
+			Curve:     "Ed25519",
+			X:         base64.RawURLEncoding.EncodeToString(k),
+			KeyID:     keyID,
+			Algorithm: "EdDSA",
+			Use:       "sig",
+		})
+	}
+	return json.Marshal(set)
+}
+
+// UnmarshalJSON replaces the keys of s with the Ed25519 keys of a JSON Web Key Set.
+// Keys of other types or for other uses are ignored. An error is returned if an Ed25519 key has no key id or is invalid.
+func (s *PublicKeySet) UnmarshalJSON(b []byte) error {
+	var set jwks
+	err := json.Unmarshal(b, &set)
+	if err != nil {
+		return err
+	}
+
+	keys := make(map[string]ed25519.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.KeyType != "OKP" || k.Curve != "Ed25519" || (k.Use != "" && k.Use != "sig") || (k.Algorithm != "" && k.Algorithm != "EdDSA") {
+			continue
+		}
+		if len(k.KeyID) == 0 || len(k.KeyID) > MaxKeyIDLength {
+			return errors.New("data: invalid key id in key set")
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return errors.New("data: invalid key in key set")
+		}
+		keys[k.KeyID] = x
+	}
+
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.keys = keys
+	return nil
+}
@@ -0,0 +1,114 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package data
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+)
+
+func TestPublicKeySetJSON(t *testing.T) {
+	// RFC 8037, appendix A.1 and A.2
+	seed, err := base64.RawURLEncoding.DecodeString("nWGxne_9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A")
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	k, err := NewSigningKey("rfc", ed25519.NewKeyFromSeed(seed))
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	s := new(PublicKeySet)
+	err = s.Add(k.KeyID(), k.PublicKey())
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+
+	b, err := json.Marshal(s)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	should := `{"keys":[{"kty":"OKP","crv":"Ed25519","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo","kid":"rfc","alg":"EdDSA","use":"sig"}]}`
+	if string(b) != should {
+		t.Errorf("wrong encoding (is: %s, should: %s)", b, should)
+	}
+
+	parsed := new(PublicKeySet)
+	err = json.Unmarshal(b, parsed)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	key, ok := parsed.Key("rfc")
+	if !ok || !bytes.Equal(key, k.PublicKey()) {
+		t.Errorf("wrong key (is: %x, should: %x)", key, k.PublicKey())
+	}
+
+	id, err := k.Get([]byte("data"))
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if !parsed.Verify(id, []byte("data")) {
+		t.Errorf("id not accepted by parsed key set")
+	}
+
+	b, err = json.Marshal(new(PublicKeySet))
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if string(b) != `{"keys":[]}` {
+		t.Errorf("wrong encoding of empty set (is: %s, should: %s)", b, `{"keys":[]}`)
+	}
+}
+
+func TestPublicKeySetUnmarshalJSON(t *testing.T) {
+	valid := `{"keys":[{"kty":"RSA","kid":"rsa","n":"AQAB","e":"AQAB"},{"kty":"OKP","crv":"X25519","kid":"x","x":"AAAA"},{"kty":"OKP","crv":"Ed25519","kid":"a","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}]}`
+	s := new(PublicKeySet)
+	err := s.Add("old", make([]byte, ed25519.PublicKeySize))
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	err = json.Unmarshal([]byte(valid), s)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if ids := s.KeyIDs(); len(ids) != 1 || ids[0] != "a" {
+		t.Errorf("wrong key ids (is: %v, should: [a])", ids)
+	}
+
+	for _, invalid := range []string{
+		`{"keys":[{"kty":"OKP","crv":"Ed25519","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}]}`,
+		`{"keys":[{"kty":"OKP","crv":"Ed25519","kid":"a","x":"AAAA"}]}`,
+		`{"keys":[{"kty":"OKP","crv":"Ed25519","kid":"a","x":"!"}]}`,
+		`{"keys":{}}`,
+		`[]`,
+	} {
+		err = json.Unmarshal([]byte(invalid), new(PublicKeySet))
+		if err == nil {
+			t.Errorf("invalid key set %s not rejected", invalid)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package data
+
+// This file contains the streaming generator.
+
+import (
+	"crypto/hmac"
+	"crypto/subtle"
+	"errors"
+	"hash"
+	"time"
+)
+
+// ErrFinished is returned when writing to a Signer or Verifier after Sum or Valid was called.
+var ErrFinished = errors.New("data: already finished")
+
+// Signer creates an id for data written to it, so that the data does not need to be held in memory.
+// The id is the same as the one returned by Get (or GetTimed for signers created by NewTimedSigner) for all written bytes.
+//
+// A Signer must not be used concurrently.
+type Signer struct {
+	hash        hash.Hash
+	timeEncoded []byte
+	id          []byte
+}
+
+// NewSigner returns a Signer creating ids like Get.
+func NewSigner() *Signer {
+	initialisationRandomData.Do(func() {
+		setRandomData()
+	})
+	return &Signer{hash: hmac.New(hashGenerator, randomData)}
+}
+
+// NewTimedSigner returns a Signer creating ids like GetTimed.
+// start determines the time from which the authentification is valid.
+func NewTimedSigner(start time.Time) (*Signer, error) {
+	timeEncoded, err := start.GobEncode()
+	if err != nil {
+		return nil, err
+	}
+	s := NewSigner()
+	s.timeEncoded = timeEncoded
+	return s, nil
+}
+
+// Write adds p to the signed data. It implements io.Writer and never returns an error unless Sum was already called.
+func (s *Signer) Write(p []byte) (n int, err error) {
+	if s.id != nil {
+		return 0, ErrFinished
+	}
+	return s.hash.Write(p)
+}
+
+// Sum returns the id of all written data. Afterwards, no more data can be written. Calling Sum again returns the same id.
+func (s *Signer) Sum() (id []byte) {
+	if s.id == nil {
+		if s.timeEncoded != nil {
+			s.hash.Write(s.timeEncoded)
+			s.id = s.hash.Sum(s.timeEncoded)
+		} else {
+			s.id = s.hash.Sum(nil)
+		}
+	}
+	return append([]byte(nil), s.id...)
+}
+
+// Verifier checks an id against data written to it, so that the data does not need to be held in memory.
+// It accepts the same ids as Verify (or VerifyTimed for verifiers created by NewTimedVerifier).
+//
+// A Verifier must not be used concurrently.
+type Verifier struct {
+	hash          hash.Hash
+	id            []byte
+	timed         bool
+	timeEncoded   []byte
+	now           time.Time
+	validDuration time.Duration
+	finished      bool
+	valid         bool
+}
+
+// NewVerifier returns a Verifier checking id like Verify.
+func NewVerifier(id []byte) *Verifier {
+	initialisationRandomData.Do(func() {
+		setRandomData()
+	})
+	return &Verifier{hash: hmac.New(hashGenerator, randomData), id: append([]byte(nil), id...)}
+}
+
+// NewTimedVerifier returns a Verifier checking id like VerifyTimed.
+// Duration determines how long an id should be seen as valid.
+func NewTimedVerifier(id []byte, now time.Time, validDuration time.Duration) *Verifier {
+	v := NewVerifier(id)
+	v.timed = true
+	v.now = now
+	v.validDuration = validDuration
+	if len(id) > hashSize {
+		v.timeEncoded = make([]byte, len(id)-hashSize)
+		copy(v.timeEncoded, id[:len(id)-hashSize])
+	}
+	return v
+}
+
+// Write adds p to the verified data. It implements io.Writer and never returns an error unless Valid was already called.
+func (v *Verifier) Write(p []byte) (n int, err error) {
+	if v.finished {
+		return 0, ErrFinished
+	}
+	return v.hash.Write(p)
+}
+
+// Valid returns whether the id is valid for all written data. Afterwards, no more data can be written. Calling Valid again returns the same result.
+func (v *Verifier) Valid() bool {
+	if v.finished {
+		return v.valid
+	}
+	v.finished = true
+
+	if !v.timed {
+		if len(v.id) != hashSize {
+			return false
+		}
+		v.valid = subtle.ConstantTimeCompare(v.hash.Sum(nil), v.id) == 1
+		return v.valid
+	}
+
+	if v.timeEncoded == nil {
+		return false
+	}
+	v.hash.Write(v.timeEncoded)
+	checksum := v.hash.Sum(append([]byte(nil), v.timeEncoded...))
+	if subtle.ConstantTimeCompare(checksum, v.id) == 0 {
+		return false
+	}
+	var t time.Time
+	err := t.GobDecode(v.timeEncoded)
+	if err != nil {
+		return false
+	}
+	if v.now.Before(t) {
+		return false
+	}
+	if v.now.Sub(t) > v.validDuration {
+		return false
+	}
+	v.valid = true
+	return true
+}
@@ -0,0 +1,203 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package data
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+func testPayload() []byte {
+	// Use a simple pseudo-random generator, so that chunks differ.
+	b := make([]byte, 100000)
+	x := uint32(1)
+	for i := range b {
+		x = x*1664525 + 1013904223
+		b[i] = byte(x >> 24)
+	}
+	return b
+}
+
+func TestSigner(t *testing.T) {
+	payload := testPayload()
+	should, err := Get(payload)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+
+	for _, chunk := range []int{1, 7, 4096, len(payload)} {
+		s := NewSigner()
+		for i := 0; i < len(payload); i += chunk {
+			end := i + chunk
+			if end > len(payload) {
+				end = len(payload)
+			}
+			n, err := s.Write(payload[i:end])
+			if err != nil || n != end-i {
+				t.Fatalf("write failed (is: %d %v, should: %d <nil>)", n, err, end-i)
+			}
+		}
+		if id := s.Sum(); !bytes.Equal(id, should) {
+			t.Errorf("wrong id with chunk size %d (is: %x, should: %x)", chunk, id, should)
+		}
+		if id := s.Sum(); !bytes.Equal(id, should) {
+			t.Errorf("second Sum returns other id")
+		}
+		_, err = s.Write([]byte{1})
+		if !errors.Is(err, ErrFinished) {
+			t.Errorf("write after Sum not rejected (is: %v, should: %v)", err, ErrFinished)
+		}
+	}
+
+	empty, err := Get(nil)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if id := NewSigner().Sum(); !bytes.Equal(id, empty) {
+		t.Errorf("wrong id of empty data (is: %x, should: %x)", id, empty)
+	}
+}
+
+func TestTimedSigner(t *testing.T) {
+	payload := testPayload()
+	now := time.Now()
+	should, err := GetTimed(now, payload)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+
+	s, err := NewTimedSigner(now)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	_, err = io.Copy(s, bytes.NewReader(payload))
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	id := s.Sum()
+	if !bytes.Equal(id, should) {
+		t.Errorf("wrong id (is: %x, should: %x)", id, should)
+	}
+	if !VerifyTimed(id, payload, now, time.Minute) {
+		t.Errorf("id not accepted by VerifyTimed")
+	}
+}
+
+func TestVerifier(t *testing.T) {
+	payload := testPayload()
+	id, err := Get(payload)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+
+	v := NewVerifier(id)
+	_, err = io.Copy(v, bytes.NewReader(payload))
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if !v.Valid() || !v.Valid() {
+		t.Errorf("valid id not accepted")
+	}
+	_, err = v.Write([]byte{1})
+	if !errors.Is(err, ErrFinished) {
+		t.Errorf("write after Valid not rejected (is: %v, should: %v)", err, ErrFinished)
+	}
+
+	modified := append([]byte(nil), payload...)
+	modified[5000] ^= 1
+	tests := map[string]struct {
+		id   []byte
+		data []byte
+	}{
+		"modified data": {id, modified},
+		"truncated":     {id, payload[:len(payload)-1]},
+		"extended":      {id, append(append([]byte(nil), payload...), 0)},
+		"short id":      {id[:len(id)-1], payload},
+		"empty id":      {nil, payload},
+	}
+	for k := range tests {
+		t.Run(k, func(t *testing.T) {
+			v := NewVerifier(tests[k].id)
+			v.Write(tests[k].data)
+			if v.Valid() {
+				t.Errorf("invalid combination accepted")
+			}
+		})
+	}
+}
+
+func TestTimedVerifier(t *testing.T) {
+	payload := testPayload()
+	now := time.Now()
+	id, err := GetTimed(now, payload)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	plain, err := Get(payload)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+
+	tests := []struct {
+		name   string
+		id     []byte
+		now    time.Time
+		result bool
+	}{
+		{"valid", id, now.Add(time.Second), true},
+		{"expired", id, now.Add(2 * time.Minute), false},
+		{"future", id, now.Add(-time.Second), false},
+		{"plain id", plain, now, false},
+		{"short id", id[:10], now, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			v := NewTimedVerifier(tc.id, tc.now, time.Minute)
+			_, err := io.Copy(v, bytes.NewReader(payload))
+			if err != nil {
+				t.Logf("error occured: %s", err.Error())
+				t.FailNow()
+			}
+			if v.Valid() != tc.result {
+				t.Errorf("wrong result (is: %v, should: %v)", !tc.result, tc.result)
+			}
+			if tc.result != VerifyTimed(tc.id, payload, tc.now, time.Minute) {
+				t.Errorf("result differs from VerifyTimed")
+			}
+		})
+	}
+
+	// The id must not be changed by the verifier.
+	before := append([]byte(nil), id...)
+	v := NewTimedVerifier(id, now, time.Minute)
+	v.Write(payload)
+	v.Valid()
+	if !bytes.Equal(before, id) {
+		t.Errorf("id was modified")
+	}
+}
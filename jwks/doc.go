@@ -0,0 +1,21 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package jwks publishes and fetches the public keys of package data as JSON Web Key Set.
+//
+// A Publisher serves the keys of a data.PublicKeySet. It should contain the active key as well as retiring keys, so that ids created shortly before a rotation can still be verified.
+// Publisher.Retire keeps an old key published for at least one cache lifetime and removes it afterwards.
+// A KeySet fetches the keys from a URL and caches them as long as allowed by the Cache-Control header. When it sees an unknown key id (e.g. after a rotation), it refreshes the keys early.
+package jwks
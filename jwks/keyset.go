@@ -0,0 +1,335 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Top-Ranger/auth/data"
+)
+
+const (
+	// MinRefreshIntervalDefault contains the suggested default for the minimum time between two refreshes caused by unknown key ids.
+	MinRefreshIntervalDefault = time.Minute
+
+	// FetchTimeoutDefault is the timeout of the client used if KeySet.Client is nil.
+	FetchTimeoutDefault = 10 * time.Second
+
+	// maxResponseSize limits the size of a fetched key set.
+	maxResponseSize = 1 << 20
+)
+
+var (
+	// ErrInvalid is returned if an id can not be verified with the key it names.
+	ErrInvalid = errors.New("jwks: invalid id")
+
+	// ErrUnknownKey is returned if the key id of an id is not contained in the key set, even after a refresh.
+	ErrUnknownKey = errors.New("jwks: unknown key id")
+)
+
+var (
+	// defaultClient is used if KeySet.Client is nil.
+	defaultClient = &http.Client{Timeout: FetchTimeoutDefault}
+
+	// errSkipped is returned by refresh if the last fetch was too recent.
+	errSkipped = errors.New("jwks: refresh skipped")
+)
+
+// KeySet fetches keys published by a Publisher (or any other JSON Web Key Set containing Ed25519 keys) and verifies ids created by data.SigningKey.
+// Keys are cached as long as allowed by the Cache-Control header of the response. Unknown key ids cause a refresh, but at most once per MinRefreshInterval.
+// If a refresh fails, cached keys are still used and the next refresh is delayed by MinRefreshInterval, so an unavailable endpoint is not queried on every call.
+//
+// Only one fetch runs at a time and concurrent callers share its result. While a fetch is running, cached keys are still served, so a slow endpoint only delays callers which need new keys.
+// The fetch does not depend on the context of any caller, so a cancelled caller only stops waiting for it.
+//
+// Can be used concurrent.
+type KeySet struct {
+	// URL is the URL of the key set.
+	URL string
+	// Client is used for fetching the keys. If it is nil, a client with a timeout of FetchTimeoutDefault is used.
+	// If the client has no timeout, FetchTimeoutDefault is used for each fetch.
+	Client *http.Client
+	// MinRefreshInterval is the minimum time between two refreshes caused by unknown key ids or after a failed refresh. If it is zero, MinRefreshIntervalDefault is used.
+	MinRefreshInterval time.Duration
+	// MaxAge is used if the response does not contain a max-age directive. If it is zero, MaxAgeDefault is used.
+	MaxAge time.Duration
+
+	m         sync.Mutex
+	keys      *data.PublicKeySet
+	etag      string
+	expires   time.Time
+	lastFetch time.Time
+	err       error
+	fetching  *pendingFetch
+}
+
+// pendingFetch is a running fetch of a KeySet.
+type pendingFetch struct {
+	// done is closed when the fetch is finished.
+	done chan struct{}
+	// err is the result of the fetch. It must only be read after done is closed.
+	err error
+}
+
+// Verify validates whether an id / data combination was created by a data.SigningKey whose public key is contained in the key set.
+// ErrUnknownKey is returned if the key is not known, ErrInvalid if the id is invalid. Other errors are caused by fetching the keys.
+func (k *KeySet) Verify(ctx context.Context, id, b []byte) error {
+	keys, err := k.keysFor(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !keys.Verify(id, b) {
+		return ErrInvalid
+	}
+	return nil
+}
+
+// VerifyTimed validates whether a timed id / data combination was created by a data.SigningKey whose public key is contained in the key set and is in date.
+// ErrUnknownKey is returned if the key is not known, ErrInvalid if the id is invalid or expired. Other errors are caused by fetching the keys.
+func (k *KeySet) VerifyTimed(ctx context.Context, id, b []byte, now time.Time, validDuration time.Duration) error {
+	keys, err := k.keysFor(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !keys.VerifyTimed(id, b, now, validDuration) {
+		return ErrInvalid
+	}
+	return nil
+}
+
+// Keys returns the cached keys, refreshing them first if the cache is expired.
+// If the cache is expired while another refresh is running, the cached keys are returned without waiting.
+func (k *KeySet) Keys(ctx context.Context) (*data.PublicKeySet, error) {
+	keys, err := k.cached(ctx)
+	if keys == nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Refresh fetches the keys, regardless of the cache.
+// If a fetch is already running, Refresh waits for it instead of starting a new one.
+func (k *KeySet) Refresh(ctx context.Context) error {
+	return k.refresh(ctx, 0)
+}
+
+// cached returns the cached keys, refreshing them first if the cache is expired.
+// The error of the last refresh is returned together with the cached keys, which might be nil.
+func (k *KeySet) cached(ctx context.Context) (*data.PublicKeySet, error) {
+	k.m.Lock()
+	keys, err := k.keys, k.err
+	fresh := time.Now().Before(k.expires) || (keys != nil && k.fetching != nil)
+	k.m.Unlock()
+	if fresh {
+		return keys, err
+	}
+
+	err = k.refresh(ctx, 0)
+	k.m.Lock()
+	keys = k.keys
+	k.m.Unlock()
+	return keys, err
+}
+
+// keysFor returns the keys after making sure that the key id of id is known if possible.
+func (k *KeySet) keysFor(ctx context.Context, id []byte) (*data.PublicKeySet, error) {
+	keyID, ok := data.KeyID(id)
+	if !ok {
+		return nil, ErrInvalid
+	}
+
+	keys, err := k.cached(ctx)
+	if keys != nil {
+		if _, ok := keys.Key(keyID); ok {
+			return keys, nil
+		}
+	}
+
+	refreshErr := k.refresh(ctx, k.minRefreshInterval())
+	if refreshErr != errSkipped {
+		err = refreshErr
+		k.m.Lock()
+		keys = k.keys
+		k.m.Unlock()
+		if keys != nil {
+			if _, ok := keys.Key(keyID); ok {
+				return keys, nil
+			}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return nil, ErrUnknownKey
+}
+
+// minRefreshInterval returns MinRefreshInterval or its default.
+func (k *KeySet) minRefreshInterval() time.Duration {
+	if k.MinRefreshInterval == 0 {
+		return MinRefreshIntervalDefault
+	}
+	return k.MinRefreshInterval
+}
+
+// refresh fetches the keys and waits until the fetch is finished or ctx is done. If a fetch is already running, it waits for it instead.
+// If the last fetch started less than minInterval ago, errSkipped is returned without fetching.
+// k.m must not be held.
+func (k *KeySet) refresh(ctx context.Context, minInterval time.Duration) error {
+	k.m.Lock()
+	f := k.fetching
+	if f == nil {
+		now := time.Now()
+		if minInterval > 0 && now.Sub(k.lastFetch) < minInterval {
+			k.m.Unlock()
+			return errSkipped
+		}
+		f = &pendingFetch{done: make(chan struct{})}
+		k.fetching = f
+		k.lastFetch = now
+		etag := ""
+		if k.keys != nil {
+			etag = k.etag
+		}
+		go k.run(f, now, etag)
+	}
+	k.m.Unlock()
+
+	select {
+	case <-f.done:
+		return f.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run executes the fetch f started at now and stores its result.
+// It uses its own context, so callers giving up waiting do not cancel the fetch for others.
+func (k *KeySet) run(f *pendingFetch, now time.Time, etag string) {
+	client := k.Client
+	if client == nil {
+		client = defaultClient
+	}
+	timeout := client.Timeout
+	if timeout <= 0 {
+		timeout = FetchTimeoutDefault
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// Only one fetch runs at a time, so the cache can not change until it is finished.
+	keys, etag, maxAge, err := k.fetch(ctx, client, etag)
+
+	k.m.Lock()
+	if err == nil {
+		if keys != nil {
+			k.keys = keys
+			k.etag = etag
+		}
+		k.expires = now.Add(maxAge)
+	} else {
+		// Keep the cached keys, but do not retry on every call.
+		k.expires = now.Add(k.minRefreshInterval())
+	}
+	k.err = err
+	k.fetching = nil
+	f.err = err
+	close(f.done)
+	k.m.Unlock()
+}
+
+// fetch fetches the keys. If etag is not empty, it is sent as If-None-Match and nil keys are returned if the keys were not modified.
+func (k *KeySet) fetch(ctx context.Context, client *http.Client, etag string) (keys *data.PublicKeySet, newEtag string, maxAge time.Duration, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, k.URL, nil)
+	if err != nil {
+		return
+	}
+	req.Header.Set("Accept", ContentType+", application/json")
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		var b []byte
+		b, err = ioutil.ReadAll(io.LimitReader(resp.Body, maxResponseSize+1))
+		if err != nil {
+			return
+		}
+		if len(b) > maxResponseSize {
+			err = errors.New("jwks: key set too large")
+			return
+		}
+		keys = new(data.PublicKeySet)
+		err = json.Unmarshal(b, keys)
+		if err != nil {
+			keys = nil
+			return
+		}
+		newEtag = resp.Header.Get("ETag")
+	case http.StatusNotModified:
+		if etag == "" {
+			err = errors.New("jwks: unexpected status " + resp.Status)
+			return
+		}
+	default:
+		err = errors.New("jwks: unexpected status " + resp.Status)
+		return
+	}
+
+	maxAge = k.maxAge(resp.Header.Get("Cache-Control"))
+	return
+}
+
+// maxAge returns how long a response with the given Cache-Control header may be cached.
+func (k *KeySet) maxAge(cacheControl string) time.Duration {
+	maxAge := k.MaxAge
+	if maxAge == 0 {
+		maxAge = MaxAgeDefault
+	}
+	for _, d := range strings.Split(cacheControl, ",") {
+		d = strings.ToLower(strings.TrimSpace(d))
+		switch {
+		case d == "no-store" || d == "no-cache":
+			return 0
+		case strings.HasPrefix(d, "max-age="):
+			seconds, err := strconv.ParseInt(strings.Trim(d[len("max-age="):], `"`), 10, 64)
+			if err != nil || seconds < 0 {
+				return 0
+			}
+			if seconds > int64(math.MaxInt64/time.Second) {
+				seconds = int64(math.MaxInt64 / time.Second)
+			}
+			maxAge = time.Duration(seconds) * time.Second
+		}
+	}
+	return maxAge
+}
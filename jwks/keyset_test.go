@@ -0,0 +1,443 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwks
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingServer serves h and counts the requests which were answered with the full key set.
+func countingServer(h http.Handler) (*httptest.Server, *int32) {
+	var count int32
+	return httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+		if rec.Code == http.StatusOK {
+			atomic.AddInt32(&count, 1)
+		}
+		for k, v := range rec.Header() {
+			rw.Header()[k] = v
+		}
+		rw.WriteHeader(rec.Code)
+		rw.Write(rec.Body.Bytes())
+	})), &count
+}
+
+func TestKeySetVerify(t *testing.T) {
+	keys, s := testKeys(t, "a")
+	server, count := countingServer(&Publisher{Keys: s})
+	defer server.Close()
+	ks := &KeySet{URL: server.URL, Client: server.Client()}
+	ctx := context.Background()
+
+	id, err := keys[0].Get([]byte("data"))
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	err = ks.Verify(ctx, id, []byte("data"))
+	if err != nil {
+		t.Errorf("valid id not accepted: %s", err.Error())
+	}
+	err = ks.Verify(ctx, id, []byte("other"))
+	if !errors.Is(err, ErrInvalid) {
+		t.Errorf("other data not rejected (is: %v, should: %v)", err, ErrInvalid)
+	}
+	err = ks.Verify(ctx, []byte("no id"), []byte("data"))
+	if !errors.Is(err, ErrInvalid) {
+		t.Errorf("malformed id not rejected (is: %v, should: %v)", err, ErrInvalid)
+	}
+
+	now := time.Now()
+	id, err = keys[0].GetTimed(now, []byte("data"))
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	err = ks.VerifyTimed(ctx, id, []byte("data"), now, time.Minute)
+	if err != nil {
+		t.Errorf("valid timed id not accepted: %s", err.Error())
+	}
+	err = ks.VerifyTimed(ctx, id, []byte("data"), now.Add(time.Hour), time.Minute)
+	if !errors.Is(err, ErrInvalid) {
+		t.Errorf("expired id not rejected (is: %v, should: %v)", err, ErrInvalid)
+	}
+
+	if c := atomic.LoadInt32(count); c != 1 {
+		t.Errorf("keys not cached (fetched: %d, should: 1)", c)
+	}
+}
+
+func TestKeySetUnknownKey(t *testing.T) {
+	keys, s := testKeys(t, "old")
+	server, count := countingServer(&Publisher{Keys: s})
+	defer server.Close()
+	ks := &KeySet{URL: server.URL, Client: server.Client(), MinRefreshInterval: time.Hour}
+	ctx := context.Background()
+
+	_, err := ks.Keys(ctx)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+
+	// Rotate the key. The cache is still valid, but the new key id causes a refresh.
+	newKeys, _ := testKeys(t, "new")
+	err = s.Add(newKeys[0].KeyID(), newKeys[0].PublicKey())
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	id, err := newKeys[0].Get([]byte("data"))
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	ks.lastFetch = time.Time{}
+	err = ks.Verify(ctx, id, []byte("data"))
+	if err != nil {
+		t.Errorf("id of new key not accepted: %s", err.Error())
+	}
+	if c := atomic.LoadInt32(count); c != 2 {
+		t.Errorf("keys not refreshed (fetched: %d, should: 2)", c)
+	}
+
+	// Unknown key ids do not cause refreshes within MinRefreshInterval.
+	unknown, _ := testKeys(t, "unknown")
+	id, err = unknown[0].Get([]byte("data"))
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	for i := 0; i < 3; i++ {
+		err = ks.Verify(ctx, id, []byte("data"))
+		if !errors.Is(err, ErrUnknownKey) {
+			t.Errorf("unknown key not rejected (is: %v, should: %v)", err, ErrUnknownKey)
+		}
+	}
+	if c := atomic.LoadInt32(count); c != 2 {
+		t.Errorf("refresh not limited (fetched: %d, should: 2)", c)
+	}
+
+	id, err = keys[0].Get([]byte("data"))
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	err = ks.Verify(ctx, id, []byte("data"))
+	if err != nil {
+		t.Errorf("id of old key not accepted: %s", err.Error())
+	}
+}
+
+func TestKeySetCacheControl(t *testing.T) {
+	_, s := testKeys(t, "a")
+	server, count := countingServer(&Publisher{Keys: s, MaxAge: time.Second})
+	defer server.Close()
+	ks := &KeySet{URL: server.URL, Client: server.Client()}
+	ctx := context.Background()
+
+	_, err := ks.Keys(ctx)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if d := ks.expires.Sub(ks.lastFetch); d != time.Second {
+		t.Errorf("wrong cache duration (is: %v, should: %v)", d, time.Second)
+	}
+
+	// Expire the cache. Since the keys did not change, the server answers with 304 Not Modified.
+	ks.expires = time.Now().Add(-time.Second)
+	keys, err := ks.Keys(ctx)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if ids := keys.KeyIDs(); len(ids) != 1 || ids[0] != "a" {
+		t.Errorf("wrong keys after revalidation (is: %v, should: [a])", ids)
+	}
+	if c := atomic.LoadInt32(count); c != 1 {
+		t.Errorf("keys fetched instead of revalidated (fetched: %d, should: 1)", c)
+	}
+	if !ks.expires.After(time.Now()) {
+		t.Errorf("cache not extended after revalidation")
+	}
+}
+
+func TestKeySetMaxAge(t *testing.T) {
+	ks := &KeySet{}
+	tests := map[string]time.Duration{
+		"":                                MaxAgeDefault,
+		"public":                          MaxAgeDefault,
+		"public, max-age=60":              time.Minute,
+		"MAX-AGE=\"120\"":                 2 * time.Minute,
+		"max-age=60, no-cache":            0,
+		"no-store":                        0,
+		"max-age=-1":                      0,
+		"max-age=invalid":                 0,
+		"max-age=99999999999999999999999": 0,
+	}
+	for k := range tests {
+		if d := ks.maxAge(k); d != tests[k] {
+			t.Errorf("wrong max age for %q (is: %v, should: %v)", k, d, tests[k])
+		}
+	}
+
+	ks.MaxAge = time.Minute
+	if d := ks.maxAge(""); d != time.Minute {
+		t.Errorf("wrong default max age (is: %v, should: %v)", d, time.Minute)
+	}
+}
+
+func TestKeySetErrors(t *testing.T) {
+	keys, s := testKeys(t, "a")
+	fail := int32(0)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&fail) == 1 {
+			http.Error(rw, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		(&Publisher{Keys: s}).ServeHTTP(rw, r)
+	}))
+	defer server.Close()
+	ctx := context.Background()
+
+	atomic.StoreInt32(&fail, 1)
+	ks := &KeySet{URL: server.URL, Client: server.Client()}
+	_, err := ks.Keys(ctx)
+	if err == nil {
+		t.Errorf("error of server not returned")
+	}
+
+	// Cached keys are used if a refresh fails.
+	atomic.StoreInt32(&fail, 0)
+	err = ks.Refresh(ctx)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	atomic.StoreInt32(&fail, 1)
+	ks.expires = time.Time{}
+	id, err := keys[0].Get([]byte("data"))
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	err = ks.Verify(ctx, id, []byte("data"))
+	if err != nil {
+		t.Errorf("cached keys not used: %s", err.Error())
+	}
+
+	unknown, _ := testKeys(t, "unknown")
+	id, err = unknown[0].Get([]byte("data"))
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	ks.lastFetch = time.Time{}
+	err = ks.Verify(ctx, id, []byte("data"))
+	if err == nil || errors.Is(err, ErrUnknownKey) {
+		t.Errorf("error of refresh not returned (is: %v)", err)
+	}
+}
+
+func TestKeySetSlowEndpoint(t *testing.T) {
+	keys, s := testKeys(t, "a")
+	publisher := &Publisher{Keys: s}
+	var requests int32
+	started := make(chan struct{}, 10)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		// All requests except the first one hang until released.
+		if atomic.AddInt32(&requests, 1) > 1 {
+			started <- struct{}{}
+			<-release
+		}
+		publisher.ServeHTTP(rw, r)
+	}))
+	defer server.Close()
+	ks := &KeySet{URL: server.URL, Client: server.Client(), MinRefreshInterval: time.Nanosecond}
+	ctx := context.Background()
+
+	id, err := keys[0].Get([]byte("data"))
+	if err != nil {
+		close(release)
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	err = ks.Verify(ctx, id, []byte("data"))
+	if err != nil {
+		close(release)
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+
+	// An unknown key id causes a refresh, which hangs.
+	unknownKeys, _ := testKeys(t, "unknown")
+	unknown, err := unknownKeys[0].Get([]byte("data"))
+	if err != nil {
+		close(release)
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	results := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			results <- ks.Verify(ctx, unknown, []byte("data"))
+		}()
+	}
+	<-started
+
+	// Cached keys are still served, even if the cache is expired.
+	done := make(chan error)
+	go func() {
+		err := ks.Verify(ctx, id, []byte("data"))
+		if err != nil {
+			done <- err
+			return
+		}
+		ks.m.Lock()
+		ks.expires = time.Time{}
+		ks.m.Unlock()
+		_, err = ks.Keys(ctx)
+		done <- err
+	}()
+	select {
+	case err = <-done:
+		if err != nil {
+			t.Errorf("cached key not accepted: %s", err.Error())
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("verification with cached key blocked by refresh")
+	}
+
+	close(release)
+	for i := 0; i < 2; i++ {
+		err = <-results
+		if !errors.Is(err, ErrUnknownKey) {
+			t.Errorf("unknown key not rejected (is: %v, should: %v)", err, ErrUnknownKey)
+		}
+	}
+}
+
+func TestKeySetBackoff(t *testing.T) {
+	keys, s := testKeys(t, "a")
+	var hits, fail int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		if atomic.LoadInt32(&fail) == 1 {
+			http.Error(rw, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		(&Publisher{Keys: s}).ServeHTTP(rw, r)
+	}))
+	defer server.Close()
+	ctx := context.Background()
+
+	// Without cached keys, the error is returned until the backoff is over.
+	atomic.StoreInt32(&fail, 1)
+	ks := &KeySet{URL: server.URL, Client: server.Client(), MinRefreshInterval: time.Hour}
+	for i := 0; i < 10; i++ {
+		_, err := ks.Keys(ctx)
+		if err == nil {
+			t.Errorf("error of server not returned")
+		}
+	}
+	if h := atomic.LoadInt32(&hits); h != 1 {
+		t.Errorf("failing server queried too often (is: %d, should: %d)", h, 1)
+	}
+
+	// Expired keys are served while the server fails.
+	atomic.StoreInt32(&fail, 0)
+	err := ks.Refresh(ctx)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	atomic.StoreInt32(&fail, 1)
+	ks.m.Lock()
+	ks.expires = time.Time{}
+	ks.m.Unlock()
+	id, err := keys[0].Get([]byte("data"))
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	for i := 0; i < 10; i++ {
+		err = ks.Verify(ctx, id, []byte("data"))
+		if err != nil {
+			t.Errorf("cached keys not used: %s", err.Error())
+		}
+	}
+	if h := atomic.LoadInt32(&hits); h != 3 {
+		t.Errorf("failing server queried too often (is: %d, should: %d)", h, 3)
+	}
+
+	// After the backoff, the server is queried again.
+	ks.m.Lock()
+	ks.expires = time.Time{}
+	ks.m.Unlock()
+	_, err = ks.Keys(ctx)
+	if err != nil {
+		t.Errorf("cached keys not used: %s", err.Error())
+	}
+	if h := atomic.LoadInt32(&hits); h != 4 {
+		t.Errorf("server not queried after backoff (is: %d, should: %d)", h, 4)
+	}
+}
+
+func TestKeySetCancelledCaller(t *testing.T) {
+	_, s := testKeys(t, "a")
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		(&Publisher{Keys: s}).ServeHTTP(rw, r)
+	}))
+	defer server.Close()
+	ks := &KeySet{URL: server.URL, Client: server.Client()}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		first <- ks.Refresh(ctx)
+	}()
+	<-started
+	second := make(chan error, 1)
+	go func() {
+		_, err := ks.Keys(context.Background())
+		second <- err
+	}()
+
+	// The cancelled caller stops waiting, but the fetch continues for the others.
+	cancel()
+	err := <-first
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled caller still waiting (is: %v, should: %v)", err, context.Canceled)
+	}
+	close(release)
+	err = <-second
+	if err != nil {
+		t.Errorf("fetch cancelled by other caller: %s", err.Error())
+	}
+}
@@ -0,0 +1,160 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwks
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Top-Ranger/auth/data"
+)
+
+const (
+	// ContentType is the media type of a JSON Web Key Set.
+	ContentType = "application/jwk-set+json"
+
+	// MaxAgeDefault contains the suggested default for how long clients may cache the keys.
+	MaxAgeDefault = time.Hour
+)
+
+// Publisher is an http.Handler serving the keys of a data.PublicKeySet.
+// Keys added to or removed from the set are served immediately, but clients might use cached keys for MaxAge.
+// Therefore, a new key should be published MaxAge before it is used.
+//
+// A key which is no longer used for signing should be retired (see Retire) instead of removed. It stays published until ids created with it are no longer needed and is removed automatically afterwards.
+//
+// Can be used concurrent.
+type Publisher struct {
+	// Keys contains the published keys.
+	Keys *data.PublicKeySet
+	// MaxAge determines how long clients may cache the keys. If it is zero, MaxAgeDefault is used.
+	MaxAge time.Duration
+
+	m       sync.Mutex
+	retired map[string]time.Time
+}
+
+// Retire marks the key with the given key id as retiring. The key must no longer be used for signing.
+// It stays published for at least MaxAge after now, or for validDuration if that is longer (e.g. the time timed ids created with the key are accepted), and is removed from Keys afterwards.
+func (p *Publisher) Retire(keyID string, now time.Time, validDuration time.Duration) error {
+	if p.Keys == nil {
+		return errors.New("jwks: no keys")
+	}
+	if _, ok := p.Keys.Key(keyID); !ok {
+		return errors.New("jwks: unknown key id " + keyID)
+	}
+	keep := p.maxAge()
+	if validDuration > keep {
+		keep = validDuration
+	}
+
+	p.m.Lock()
+	defer p.m.Unlock()
+	if p.retired == nil {
+		p.retired = make(map[string]time.Time)
+	}
+	p.retired[keyID] = now.Add(keep)
+	return nil
+}
+
+// Retiring returns the retiring keys together with the time they will be removed.
+func (p *Publisher) Retiring() map[string]time.Time {
+	p.m.Lock()
+	defer p.m.Unlock()
+	r := make(map[string]time.Time, len(p.retired))
+	for k, v := range p.retired {
+		r[k] = v
+	}
+	return r
+}
+
+// Prune removes all retiring keys whose time is over at now. It is called automatically when serving the keys.
+func (p *Publisher) Prune(now time.Time) {
+	p.m.Lock()
+	defer p.m.Unlock()
+	for k, until := range p.retired {
+		if now.Before(until) {
+			continue
+		}
+		if p.Keys != nil {
+			p.Keys.Remove(k)
+		}
+		delete(p.retired, k)
+	}
+}
+
+// ServeHTTP implements http.Handler. Only GET and HEAD requests are allowed.
+// The response contains an ETag, so that clients can revalidate their cache with If-None-Match.
+func (p *Publisher) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		rw.Header().Set("Allow", "GET, HEAD")
+		http.Error(rw, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	p.Prune(time.Now())
+	keys := p.Keys
+	if keys == nil {
+		keys = new(data.PublicKeySet)
+	}
+	b, err := json.Marshal(keys)
+	if err != nil {
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	maxAge := p.maxAge()
+	sum := sha256.Sum256(b)
+	etag := `"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`
+
+	rw.Header().Set("Cache-Control", "public, max-age="+strconv.FormatInt(int64(maxAge/time.Second), 10))
+	rw.Header().Set("ETag", etag)
+	if matchETag(r.Header.Get("If-None-Match"), etag) {
+		rw.WriteHeader(http.StatusNotModified)
+		return
+	}
+	rw.Header().Set("Content-Type", ContentType)
+	rw.Header().Set("Content-Length", strconv.Itoa(len(b)))
+	if r.Method == http.MethodHead {
+		return
+	}
+	rw.Write(b)
+}
+
+// maxAge returns MaxAge or its default.
+func (p *Publisher) maxAge() time.Duration {
+	if p.MaxAge == 0 {
+		return MaxAgeDefault
+	}
+	return p.MaxAge
+}
+
+// matchETag returns whether the If-None-Match header contains etag.
+func matchETag(header, etag string) bool {
+	for _, h := range strings.Split(header, ",") {
+		h = strings.TrimPrefix(strings.TrimSpace(h), "W/")
+		if h == etag || h == "*" {
+			return true
+		}
+	}
+	return false
+}
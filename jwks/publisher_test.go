@@ -0,0 +1,191 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwks
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Top-Ranger/auth/data"
+)
+
+func testKeys(t *testing.T, keyIDs ...string) ([]*data.SigningKey, *data.PublicKeySet) {
+	s := new(data.PublicKeySet)
+	keys := make([]*data.SigningKey, len(keyIDs))
+	for i := range keyIDs {
+		k, err := data.GenerateSigningKey(keyIDs[i])
+		if err != nil {
+			t.Logf("error occured: %s", err.Error())
+			t.FailNow()
+		}
+		err = s.Add(k.KeyID(), k.PublicKey())
+		if err != nil {
+			t.Logf("error occured: %s", err.Error())
+			t.FailNow()
+		}
+		keys[i] = k
+	}
+	return keys, s
+}
+
+func TestPublisher(t *testing.T) {
+	_, s := testKeys(t, "active", "retiring")
+	p := &Publisher{Keys: s, MaxAge: 10 * time.Minute}
+
+	rw := httptest.NewRecorder()
+	p.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+	if rw.Code != http.StatusOK {
+		t.Fatalf("wrong status (is: %d, should: %d)", rw.Code, http.StatusOK)
+	}
+	if cc := rw.Header().Get("Cache-Control"); cc != "public, max-age=600" {
+		t.Errorf("wrong Cache-Control (is: %s, should: %s)", cc, "public, max-age=600")
+	}
+	if ct := rw.Header().Get("Content-Type"); ct != ContentType {
+		t.Errorf("wrong Content-Type (is: %s, should: %s)", ct, ContentType)
+	}
+	parsed := new(data.PublicKeySet)
+	err := json.Unmarshal(rw.Body.Bytes(), parsed)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if ids := parsed.KeyIDs(); len(ids) != 2 || ids[0] != "active" || ids[1] != "retiring" {
+		t.Errorf("wrong keys (is: %v, should: [active retiring])", ids)
+	}
+
+	etag := rw.Header().Get("ETag")
+	if etag == "" {
+		t.Fatalf("no ETag")
+	}
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("If-None-Match", `"other", `+etag)
+	rw = httptest.NewRecorder()
+	p.ServeHTTP(rw, r)
+	if rw.Code != http.StatusNotModified || rw.Body.Len() != 0 {
+		t.Errorf("wrong revalidation (is: %d with %d bytes, should: %d with 0 bytes)", rw.Code, rw.Body.Len(), http.StatusNotModified)
+	}
+
+	s.Remove("retiring")
+	rw = httptest.NewRecorder()
+	p.ServeHTTP(rw, r)
+	if rw.Code != http.StatusOK || rw.Header().Get("ETag") == etag {
+		t.Errorf("changed keys not served (is: %d, should: %d)", rw.Code, http.StatusOK)
+	}
+
+	rw = httptest.NewRecorder()
+	p.ServeHTTP(rw, httptest.NewRequest(http.MethodHead, "/", nil))
+	if rw.Code != http.StatusOK || rw.Body.Len() != 0 || rw.Header().Get("Content-Length") == "" {
+		t.Errorf("wrong HEAD response (is: %d with %d bytes)", rw.Code, rw.Body.Len())
+	}
+
+	rw = httptest.NewRecorder()
+	p.ServeHTTP(rw, httptest.NewRequest(http.MethodPost, "/", nil))
+	if rw.Code != http.StatusMethodNotAllowed {
+		t.Errorf("wrong status for POST (is: %d, should: %d)", rw.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestPublisherRetire(t *testing.T) {
+	_, s := testKeys(t, "active", "retiring")
+	p := &Publisher{Keys: s, MaxAge: 10 * time.Minute}
+	testtime := time.Now()
+
+	err := p.Retire("retiring", testtime, 0)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	err = p.Retire("unknown", testtime, 0)
+	if err == nil {
+		t.Errorf("unknown key retired")
+	}
+	if until, ok := p.Retiring()["retiring"]; !ok || !until.Equal(testtime.Add(10*time.Minute)) {
+		t.Errorf("wrong retirement (is: %v, should: %v)", until, testtime.Add(10*time.Minute))
+	}
+
+	// The retiring key is still published within one cache lifetime.
+	rw := httptest.NewRecorder()
+	p.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/", nil))
+	parsed := new(data.PublicKeySet)
+	err = json.Unmarshal(rw.Body.Bytes(), parsed)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if ids := parsed.KeyIDs(); len(ids) != 2 {
+		t.Errorf("retiring key not published (is: %v, should: [active retiring])", ids)
+	}
+	p.Prune(testtime.Add(9 * time.Minute))
+	if _, ok := s.Key("retiring"); !ok {
+		t.Errorf("retiring key removed too early")
+	}
+
+	// Afterwards it is removed.
+	p.Prune(testtime.Add(10 * time.Minute))
+	if ids := s.KeyIDs(); len(ids) != 1 || ids[0] != "active" {
+		t.Errorf("retiring key not removed (is: %v, should: [active])", ids)
+	}
+	if len(p.Retiring()) != 0 {
+		t.Errorf("removed key still retiring")
+	}
+
+	// Keys used for long-lived ids are kept longer.
+	err = p.Retire("active", testtime, time.Hour)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	p.Prune(testtime.Add(30 * time.Minute))
+	if _, ok := s.Key("active"); !ok {
+		t.Errorf("key removed before ids expired")
+	}
+	p.Prune(testtime.Add(time.Hour))
+	if _, ok := s.Key("active"); ok {
+		t.Errorf("key not removed after ids expired")
+	}
+
+	// Serving removes keys automatically.
+	_, s = testKeys(t, "old")
+	p = &Publisher{Keys: s}
+	err = p.Retire("old", testtime.Add(-2*MaxAgeDefault), 0)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	rw = httptest.NewRecorder()
+	p.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rw.Body.String() != `{"keys":[]}` {
+		t.Errorf("retired key served (is: %s, should: %s)", rw.Body.String(), `{"keys":[]}`)
+	}
+}
+
+func TestPublisherDefault(t *testing.T) {
+	p := &Publisher{}
+	rw := httptest.NewRecorder()
+	p.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rw.Code != http.StatusOK {
+		t.Fatalf("wrong status (is: %d, should: %d)", rw.Code, http.StatusOK)
+	}
+	if rw.Body.String() != `{"keys":[]}` {
+		t.Errorf("wrong body (is: %s, should: %s)", rw.Body.String(), `{"keys":[]}`)
+	}
+	if cc := rw.Header().Get("Cache-Control"); cc != "public, max-age=3600" {
+		t.Errorf("wrong Cache-Control (is: %s, should: %s)", cc, "public, max-age=3600")
+	}
+}
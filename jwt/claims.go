@@ -0,0 +1,197 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"time"
+)
+
+// registered contains the names of the registered claims handled by Claims.
+var registered = []string{"iss", "sub", "aud", "exp", "nbf", "iat", "jti"}
+
+// Audience contains the recipients of a token. A single recipient is encoded as a string, multiple recipients as an array.
+type Audience []string
+
+// Contains returns whether a contains audience.
+func (a Audience) Contains(audience string) bool {
+	for i := range a {
+		if a[i] == audience {
+			return true
+		}
+	}
+	return false
+}
+
+// MarshalJSON implements json.Marshaler.
+func (a Audience) MarshalJSON() ([]byte, error) {
+	if len(a) == 1 {
+		return json.Marshal(a[0])
+	}
+	return json.Marshal([]string(a))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (a *Audience) UnmarshalJSON(b []byte) error {
+	var s string
+	if json.Unmarshal(b, &s) == nil {
+		*a = Audience{s}
+		return nil
+	}
+	var l []string
+	err := json.Unmarshal(b, &l)
+	if err != nil {
+		return err
+	}
+	*a = l
+	return nil
+}
+
+// Claims contains the claims of a token. Zero values of the registered claims are omitted.
+// Times are encoded as NumericDate (seconds since the Unix epoch), so they are truncated to seconds.
+type Claims struct {
+	// Issuer is the "iss" claim.
+	Issuer string
+	// Subject is the "sub" claim.
+	Subject string
+	// Audience is the "aud" claim.
+	Audience Audience
+	// ExpiresAt is the "exp" claim.
+	ExpiresAt time.Time
+	// NotBefore is the "nbf" claim.
+	NotBefore time.Time
+	// IssuedAt is the "iat" claim.
+	IssuedAt time.Time
+	// ID is the "jti" claim.
+	ID string
+	// Custom contains all other claims. It must not contain registered claims. Numbers of verified tokens are decoded as json.Number.
+	Custom map[string]interface{}
+}
+
+// MarshalJSON implements json.Marshaler.
+func (c Claims) MarshalJSON() ([]byte, error) {
+	m := make(map[string]interface{}, len(c.Custom)+len(registered))
+	for k := range c.Custom {
+		for i := range registered {
+			if k == registered[i] {
+				return nil, errors.New("registered claim " + k + " in custom claims")
+			}
+		}
+		m[k] = c.Custom[k]
+	}
+
+	if c.Issuer != "" {
+		m["iss"] = c.Issuer
+	}
+	if c.Subject != "" {
+		m["sub"] = c.Subject
+	}
+	if len(c.Audience) != 0 {
+		m["aud"] = c.Audience
+	}
+	if !c.ExpiresAt.IsZero() {
+		m["exp"] = c.ExpiresAt.Unix()
+	}
+	if !c.NotBefore.IsZero() {
+		m["nbf"] = c.NotBefore.Unix()
+	}
+	if !c.IssuedAt.IsZero() {
+		m["iat"] = c.IssuedAt.Unix()
+	}
+	if c.ID != "" {
+		m["jti"] = c.ID
+	}
+	return json.Marshal(m)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (c *Claims) UnmarshalJSON(b []byte) error {
+	var m map[string]json.RawMessage
+	err := json.Unmarshal(b, &m)
+	if err != nil {
+		return err
+	}
+
+	var result Claims
+	fields := []struct {
+		name string
+		v    interface{}
+	}{
+		{"iss", &result.Issuer},
+		{"sub", &result.Subject},
+		{"aud", &result.Audience},
+		{"jti", &result.ID},
+	}
+	for _, f := range fields {
+		if raw, ok := m[f.name]; ok {
+			err = json.Unmarshal(raw, f.v)
+			if err != nil {
+				return err
+			}
+			delete(m, f.name)
+		}
+	}
+	times := []struct {
+		name string
+		t    *time.Time
+	}{
+		{"exp", &result.ExpiresAt},
+		{"nbf", &result.NotBefore},
+		{"iat", &result.IssuedAt},
+	}
+	for _, f := range times {
+		if raw, ok := m[f.name]; ok {
+			*f.t, err = numericDate(raw)
+			if err != nil {
+				return err
+			}
+			delete(m, f.name)
+		}
+	}
+
+	if len(m) != 0 {
+		result.Custom = make(map[string]interface{}, len(m))
+		for k := range m {
+			d := json.NewDecoder(bytes.NewReader(m[k]))
+			d.UseNumber()
+			var v interface{}
+			err = d.Decode(&v)
+			if err != nil {
+				return err
+			}
+			result.Custom[k] = v
+		}
+	}
+	*c = result
+	return nil
+}
+
+// numericDate decodes a NumericDate, which may contain fractions of seconds.
+func numericDate(raw json.RawMessage) (time.Time, error) {
+	var f float64
+	err := json.Unmarshal(raw, &f)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if math.IsNaN(f) || f < 0 || f > math.MaxInt32*1000 {
+		return time.Time{}, errors.New("invalid NumericDate")
+	}
+	sec, frac := math.Modf(f)
+	return time.Unix(int64(sec), int64(frac*1e9)), nil
+}
@@ -0,0 +1,122 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAudienceJSON(t *testing.T) {
+	tests := map[string]Audience{
+		`"a"`:       {"a"},
+		`["a","b"]`: {"a", "b"},
+	}
+	for k := range tests {
+		b, err := json.Marshal(tests[k])
+		if err != nil {
+			t.Logf("error occured: %s", err.Error())
+			t.FailNow()
+		}
+		if string(b) != k {
+			t.Errorf("wrong encoding (is: %s, should: %s)", b, k)
+		}
+		var a Audience
+		err = json.Unmarshal([]byte(k), &a)
+		if err != nil {
+			t.Logf("error occured: %s", err.Error())
+			t.FailNow()
+		}
+		if !reflect.DeepEqual(a, tests[k]) {
+			t.Errorf("wrong decoding (is: %v, should: %v)", a, tests[k])
+		}
+	}
+
+	var a Audience
+	err := json.Unmarshal([]byte(`1`), &a)
+	if err == nil {
+		t.Errorf("invalid audience not rejected")
+	}
+}
+
+func TestClaimsJSON(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	c := Claims{
+		Issuer:    "iss",
+		Subject:   "sub",
+		Audience:  Audience{"aud"},
+		ExpiresAt: now.Add(time.Hour),
+		NotBefore: now,
+		IssuedAt:  now,
+		ID:        "jti",
+		Custom:    map[string]interface{}{"name": "value", "n": json.Number("5"), "list": []interface{}{"a"}},
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	should := `{"aud":"aud","exp":1600003600,"iat":1600000000,"iss":"iss","jti":"jti","list":["a"],"n":5,"name":"value","nbf":1600000000,"sub":"sub"}`
+	if string(b) != should {
+		t.Errorf("wrong encoding (is: %s, should: %s)", b, should)
+	}
+
+	var result Claims
+	err = json.Unmarshal(b, &result)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(result, c) {
+		t.Errorf("wrong decoding (is: %+v, should: %+v)", result, c)
+	}
+
+	b, err = json.Marshal(Claims{})
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if string(b) != "{}" {
+		t.Errorf("zero claims not omitted (is: %s, should: {})", b)
+	}
+
+	_, err = json.Marshal(Claims{Custom: map[string]interface{}{"exp": 1}})
+	if err == nil {
+		t.Errorf("registered claim in custom claims not rejected")
+	}
+}
+
+func TestClaimsNumericDate(t *testing.T) {
+	var c Claims
+	err := json.Unmarshal([]byte(`{"exp":1600000000.5}`), &c)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	should := time.Unix(1600000000, 500000000)
+	if !c.ExpiresAt.Equal(should) {
+		t.Errorf("wrong time (is: %v, should: %v)", c.ExpiresAt, should)
+	}
+
+	for _, s := range []string{`{"exp":"1600000000"}`, `{"nbf":-1}`, `{"iat":1e300}`, `{"iss":1}`} {
+		err = json.Unmarshal([]byte(s), &c)
+		if err == nil {
+			t.Errorf("invalid claims %s not rejected", s)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package jwt contains JSON Web Tokens (RFC 7519) signed with HMAC (HS256, HS384 and HS512) for consumers which expect JWTs.
+// Other token formats of this module (e.g. package tokens or package macaroon) should be preferred when the consumer is under your control.
+//
+// Only the configured algorithm is accepted, so tokens using "none" or another algorithm are always rejected.
+// If no keys are configured, a key derived from the hidden value of package data is used. In this case, tokens become invalid whenever the program restarts.
+package jwt
@@ -0,0 +1,266 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/hmac"
+	_ "crypto/sha256" // Register SHA-256 for crypto.Hash.
+	_ "crypto/sha512" // Register SHA-384 and SHA-512 for crypto.Hash.
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Top-Ranger/auth/data"
+)
+
+// Algorithm is a JWS algorithm.
+type Algorithm string
+
+const (
+	// HS256 is HMAC with SHA-256.
+	HS256 Algorithm = "HS256"
+	// HS384 is HMAC with SHA-384.
+	HS384 Algorithm = "HS384"
+	// HS512 is HMAC with SHA-512.
+	HS512 Algorithm = "HS512"
+)
+
+var (
+	// ErrMalformed is returned if a token is not a compact JWS with a JSON header and claims.
+	ErrMalformed = errors.New("jwt: malformed token")
+
+	// ErrAlgorithm is returned if a token uses another algorithm than the Codec (including "none").
+	ErrAlgorithm = errors.New("jwt: algorithm not allowed")
+
+	// ErrInvalid is returned if the signature of a token does not match.
+	ErrInvalid = errors.New("jwt: invalid signature")
+
+	// ErrExpired is returned if a token is expired.
+	ErrExpired = errors.New("jwt: token expired")
+
+	// ErrNotYetValid is returned if a token is not valid yet or was issued in the future.
+	ErrNotYetValid = errors.New("jwt: token not yet valid")
+
+	// ErrIssuer is returned if a token was issued by another issuer.
+	ErrIssuer = errors.New("jwt: wrong issuer")
+
+	// ErrAudience is returned if a token was issued for another audience.
+	ErrAudience = errors.New("jwt: wrong audience")
+)
+
+// encoding rejects non-zero padding bits, so that a token has only one valid serialisation.
+var encoding = base64.RawURLEncoding.Strict()
+
+// hash returns the hash function of a.
+func (a Algorithm) hash() (crypto.Hash, bool) {
+	switch a {
+	case HS256:
+		return crypto.SHA256, true
+	case HS384:
+		return crypto.SHA384, true
+	case HS512:
+		return crypto.SHA512, true
+	default:
+		return 0, false
+	}
+}
+
+// header is the JOSE header of a token.
+type header struct {
+	Algorithm Algorithm `json:"alg"`
+	Type      string    `json:"typ,omitempty"`
+	Critical  []string  `json:"crit,omitempty"`
+}
+
+// Codec signs and verifies tokens.
+// The zero value uses HS256 with a key derived from the hidden value of package data.
+//
+// Can be used concurrent.
+type Codec struct {
+	// Algorithm is the only algorithm used and accepted. If it is empty, HS256 is used.
+	Algorithm Algorithm
+	// Keys contains the keys used by the codec. The first key signs tokens, all keys are accepted when verifying tokens.
+	// Keys must be at least as long as the output of the hash function (e.g. 32 bytes for HS256).
+	// If Keys is empty, a key derived from the hidden value of package data is used.
+	Keys [][]byte
+	// Issuer is required in the "iss" claim of verified tokens. If it is empty, the issuer is not checked.
+	Issuer string
+	// Audience is required in the "aud" claim of verified tokens. If it is empty, only tokens without audience are accepted.
+	Audience string
+	// Leeway is the tolerated clock difference when checking "exp", "nbf" and "iat".
+	Leeway time.Duration
+}
+
+// Sign returns the compact serialisation of a token containing claims.
+func (c *Codec) Sign(claims Claims) (string, error) {
+	alg, h, err := c.algorithm()
+	if err != nil {
+		return "", err
+	}
+	keys, err := c.keys(alg, h)
+	if err != nil {
+		return "", err
+	}
+
+	hb, err := json.Marshal(header{Algorithm: alg, Type: "JWT"})
+	if err != nil {
+		return "", err
+	}
+	cb, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	content := encoding.EncodeToString(hb) + "." + encoding.EncodeToString(cb)
+	return content + "." + encoding.EncodeToString(sign(h, keys[0], content)), nil
+}
+
+// Verify checks token and returns its claims.
+// The algorithm is checked before the signature, all keys are tried. Afterwards, the registered claims are checked against now, Issuer and Audience.
+//
+// ErrMalformed, ErrAlgorithm, ErrInvalid, ErrExpired, ErrNotYetValid, ErrIssuer or ErrAudience is returned if the verification fails.
+func (c *Codec) Verify(token string, now time.Time) (Claims, error) {
+	alg, h, err := c.algorithm()
+	if err != nil {
+		return Claims{}, err
+	}
+	keys, err := c.keys(alg, h)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Claims{}, ErrMalformed
+	}
+	hb, err := encoding.DecodeString(parts[0])
+	if err != nil {
+		return Claims{}, ErrMalformed
+	}
+	var hd header
+	err = json.Unmarshal(hb, &hd)
+	if err != nil {
+		return Claims{}, ErrMalformed
+	}
+	if hd.Algorithm != alg {
+		return Claims{}, ErrAlgorithm
+	}
+	if len(hd.Critical) != 0 {
+		// No extensions are supported, so critical extensions can not be understood (RFC 7515, section 4.1.11).
+		return Claims{}, ErrMalformed
+	}
+
+	signature, err := encoding.DecodeString(parts[2])
+	if err != nil {
+		return Claims{}, ErrMalformed
+	}
+	content := parts[0] + "." + parts[1]
+	valid := 0
+	for i := range keys {
+		valid |= subtle.ConstantTimeCompare(sign(h, keys[i], content), signature)
+	}
+	if valid == 0 {
+		return Claims{}, ErrInvalid
+	}
+
+	cb, err := encoding.DecodeString(parts[1])
+	if err != nil {
+		return Claims{}, ErrMalformed
+	}
+	var claims Claims
+	err = json.Unmarshal(cb, &claims)
+	if err != nil {
+		return Claims{}, ErrMalformed
+	}
+
+	err = c.check(claims, now)
+	if err != nil {
+		return Claims{}, err
+	}
+	return claims, nil
+}
+
+// check validates the registered claims.
+func (c *Codec) check(claims Claims, now time.Time) error {
+	if !claims.ExpiresAt.IsZero() && !now.Before(claims.ExpiresAt.Add(c.Leeway)) {
+		return ErrExpired
+	}
+	if !claims.NotBefore.IsZero() && now.Before(claims.NotBefore.Add(-c.Leeway)) {
+		return ErrNotYetValid
+	}
+	if !claims.IssuedAt.IsZero() && now.Before(claims.IssuedAt.Add(-c.Leeway)) {
+		return ErrNotYetValid
+	}
+	if c.Issuer != "" && claims.Issuer != c.Issuer {
+		return ErrIssuer
+	}
+	if len(claims.Audience) != 0 && !claims.Audience.Contains(c.Audience) {
+		return ErrAudience
+	}
+	if c.Audience != "" && len(claims.Audience) == 0 {
+		return ErrAudience
+	}
+	return nil
+}
+
+// algorithm returns the algorithm of the codec and its hash.
+func (c *Codec) algorithm() (Algorithm, crypto.Hash, error) {
+	alg := c.Algorithm
+	if alg == "" {
+		alg = HS256
+	}
+	h, ok := alg.hash()
+	if !ok {
+		return alg, 0, errors.New("unsupported algorithm " + strconv.Quote(string(alg)))
+	}
+	return alg, h, nil
+}
+
+// keys returns the keys of the codec or the derived default key.
+func (c *Codec) keys(alg Algorithm, h crypto.Hash) ([][]byte, error) {
+	if len(c.Keys) != 0 {
+		for i := range c.Keys {
+			if len(c.Keys[i]) < h.Size() {
+				return nil, errors.New("key too short")
+			}
+		}
+		return c.Keys, nil
+	}
+
+	// Derive as many blocks as needed to match the size of the hash.
+	var key bytes.Buffer
+	for i := 0; key.Len() < h.Size(); i++ {
+		k, err := data.Key("jwt\x00" + string(alg) + "\x00" + strconv.Itoa(i))
+		if err != nil {
+			return nil, err
+		}
+		key.Write(k)
+	}
+	return [][]byte{key.Bytes()}, nil
+}
+
+// sign returns the HMAC of content.
+func sign(h crypto.Hash, key []byte, content string) []byte {
+	mac := hmac.New(h.New, key)
+	mac.Write([]byte(content))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,23 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package macaroon contains macaroons, which are bearer tokens that can be restricted by their holder.
+// A macaroon consists of an id and a list of caveats. Its signature is a chain of HMACs: every caveat is signed with the signature of the macaroon before it.
+// This way, anybody holding a macaroon can add caveats (e.g. "expires 2020-06-01T12:00:00Z" or "path /reports") and pass on the weaker macaroon, but nobody can remove caveats without the root key.
+//
+// The root key is derived from the hidden value of package data and the id, so macaroons become invalid whenever the program restarts.
+// Only first-party caveats (checked by the server itself) are supported. A caveat consists of a predicate name and an argument separated by a space.
+// A Verifier checks every caveat with the predicate registered for its name. Caveats with unknown predicates are never satisfied.
+package macaroon
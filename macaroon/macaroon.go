@@ -0,0 +1,231 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package macaroon
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+
+	"github.com/Top-Ranger/auth/data"
+)
+
+// version is the version of the serialisation.
+const version = 1
+
+// ErrMalformed is returned if a serialised macaroon can not be parsed.
+var ErrMalformed = errors.New("macaroon: malformed macaroon")
+
+var (
+	hashGenerator = sha256.New
+	hashSize      = hashGenerator().Size()
+)
+
+// Macaroon is a bearer token with caveats. Macaroons are created by New and restricted by AddCaveat.
+//
+// A Macaroon must not be modified concurrently. Use Clone to create a copy before adding caveats for another holder.
+type Macaroon struct {
+	location  string
+	id        []byte
+	caveats   []string
+	signature []byte
+}
+
+// New returns a new macaroon with the given id. The id should identify what the macaroon grants access to (e.g. a user or a resource).
+// location is a hint where the macaroon can be used. It is not signed, so it must not be trusted.
+//
+// Can be used concurrent.
+func New(id []byte, location string) (*Macaroon, error) {
+	if len(id) == 0 {
+		return nil, errors.New("empty id")
+	}
+	key, err := rootKey(id)
+	if err != nil {
+		return nil, err
+	}
+	m := &Macaroon{
+		location:  location,
+		id:        append([]byte(nil), id...),
+		signature: chain(key, id),
+	}
+	return m, nil
+}
+
+// AddCaveat restricts m by caveat. It does not need the root key, so every holder of a macaroon can add caveats.
+// A caveat consists of a predicate name and an argument separated by a space (see Caveat).
+func (m *Macaroon) AddCaveat(caveat string) error {
+	if caveat == "" {
+		return errors.New("empty caveat")
+	}
+	m.caveats = append(m.caveats, caveat)
+	m.signature = chain(m.signature, []byte(caveat))
+	return nil
+}
+
+// Caveat returns a caveat consisting of predicate and argument.
+func Caveat(predicate, argument string) string {
+	return predicate + " " + argument
+}
+
+// ID returns the id of m.
+func (m *Macaroon) ID() []byte {
+	return append([]byte(nil), m.id...)
+}
+
+// Location returns the unsigned location hint of m.
+func (m *Macaroon) Location() string {
+	return m.location
+}
+
+// Caveats returns the caveats of m in the order they were added.
+func (m *Macaroon) Caveats() []string {
+	return append([]string(nil), m.caveats...)
+}
+
+// Signature returns the signature of m.
+func (m *Macaroon) Signature() []byte {
+	return append([]byte(nil), m.signature...)
+}
+
+// Clone returns a copy of m. Caveats added to the copy do not affect m.
+func (m *Macaroon) Clone() *Macaroon {
+	return &Macaroon{
+		location:  m.location,
+		id:        m.ID(),
+		caveats:   m.Caveats(),
+		signature: m.Signature(),
+	}
+}
+
+// MarshalBinary returns the compact binary serialisation of m.
+func (m *Macaroon) MarshalBinary() ([]byte, error) {
+	size := 1 + 3*binary.MaxVarintLen64 + len(m.location) + len(m.id) + len(m.signature)
+	for i := range m.caveats {
+		size += binary.MaxVarintLen64 + len(m.caveats[i])
+	}
+	b := make([]byte, 0, size)
+	b = append(b, version)
+	b = appendField(b, []byte(m.location))
+	b = appendField(b, m.id)
+	b = appendUvarint(b, uint64(len(m.caveats)))
+	for i := range m.caveats {
+		b = appendField(b, []byte(m.caveats[i]))
+	}
+	b = append(b, m.signature...)
+	return b, nil
+}
+
+// UnmarshalBinary sets m to the macaroon serialised in b. The signature is not verified.
+// ErrMalformed is returned if b is not a valid serialisation.
+func (m *Macaroon) UnmarshalBinary(b []byte) error {
+	if len(b) < 1 || b[0] != version {
+		return ErrMalformed
+	}
+	b = b[1:]
+
+	location, b, err := readField(b)
+	if err != nil {
+		return err
+	}
+	id, b, err := readField(b)
+	if err != nil || len(id) == 0 {
+		return ErrMalformed
+	}
+	n, l := binary.Uvarint(b)
+	// Every caveat needs at least two bytes, which limits the allocation.
+	if l <= 0 || n > uint64(len(b)/2) {
+		return ErrMalformed
+	}
+	b = b[l:]
+	caveats := make([]string, 0, n)
+	for i := uint64(0); i < n; i++ {
+		var c []byte
+		c, b, err = readField(b)
+		if err != nil || len(c) == 0 {
+			return ErrMalformed
+		}
+		caveats = append(caveats, string(c))
+	}
+	if len(b) != hashSize {
+		return ErrMalformed
+	}
+
+	m.location = string(location)
+	m.id = append([]byte(nil), id...)
+	m.caveats = caveats
+	m.signature = append([]byte(nil), b...)
+	return nil
+}
+
+// String returns the serialisation of m encoded with unpadded URL-safe base64, so that it can be used in headers and URLs.
+func (m *Macaroon) String() string {
+	b, _ := m.MarshalBinary()
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// Parse returns the macaroon encoded in s by String. The signature is not verified (see Verifier).
+// ErrMalformed is returned if s can not be parsed.
+//
+// Can be used concurrent.
+func Parse(s string) (*Macaroon, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	m := new(Macaroon)
+	err = m.UnmarshalBinary(b)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// rootKey returns the root key of the macaroon with the given id.
+func rootKey(id []byte) ([]byte, error) {
+	key, err := data.Key("macaroon")
+	if err != nil {
+		return nil, err
+	}
+	return chain(key, id), nil
+}
+
+// chain returns the HMAC of content with key.
+func chain(key, content []byte) []byte {
+	hash := hmac.New(hashGenerator, key)
+	hash.Write(content)
+	return hash.Sum(nil)
+}
+
+func appendUvarint(b []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	return append(b, buf[:binary.PutUvarint(buf[:], v)]...)
+}
+
+func appendField(b, field []byte) []byte {
+	b = appendUvarint(b, uint64(len(field)))
+	return append(b, field...)
+}
+
+func readField(b []byte) (field, rest []byte, err error) {
+	n, l := binary.Uvarint(b)
+	if l <= 0 || n > uint64(len(b)-l) {
+		return nil, nil, ErrMalformed
+	}
+	b = b[l:]
+	return b[:n], b[n:], nil
+}
@@ -0,0 +1,131 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package macaroon
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	m, err := New([]byte("user 1"), "https://example.com")
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if !bytes.Equal(m.ID(), []byte("user 1")) {
+		t.Errorf("wrong id (is: %s, should: %s)", m.ID(), "user 1")
+	}
+	if m.Location() != "https://example.com" {
+		t.Errorf("wrong location (is: %s, should: %s)", m.Location(), "https://example.com")
+	}
+	if len(m.Caveats()) != 0 {
+		t.Errorf("new macaroon has caveats")
+	}
+
+	other, err := New([]byte("user 2"), "https://example.com")
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if bytes.Equal(m.Signature(), other.Signature()) {
+		t.Errorf("different ids have the same signature")
+	}
+
+	_, err = New(nil, "")
+	if err == nil {
+		t.Errorf("empty id not rejected")
+	}
+}
+
+func TestAddCaveat(t *testing.T) {
+	m, err := New([]byte("id"), "")
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	before := m.Signature()
+	c := m.Clone()
+
+	err = m.AddCaveat("op read")
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if bytes.Equal(before, m.Signature()) {
+		t.Errorf("signature not changed")
+	}
+	if !reflect.DeepEqual(m.Caveats(), []string{"op read"}) {
+		t.Errorf("wrong caveats (is: %v, should: %v)", m.Caveats(), []string{"op read"})
+	}
+	if len(c.Caveats()) != 0 || !bytes.Equal(c.Signature(), before) {
+		t.Errorf("clone was modified")
+	}
+
+	err = m.AddCaveat("")
+	if err == nil {
+		t.Errorf("empty caveat not rejected")
+	}
+}
+
+func TestSerialisation(t *testing.T) {
+	m, err := New([]byte("id\x00with binary\xff"), "https://example.com/ä")
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	for _, c := range []string{"op read", Caveat("path", "/reports"), "expires 2020-06-01T12:00:00Z"} {
+		err = m.AddCaveat(c)
+		if err != nil {
+			t.Logf("error occured: %s", err.Error())
+			t.FailNow()
+		}
+	}
+
+	parsed, err := Parse(m.String())
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(m, parsed) {
+		t.Errorf("parsed macaroon differs (is: %+v, should: %+v)", parsed, m)
+	}
+
+	b, err := m.MarshalBinary()
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	for i := 0; i < len(b); i++ {
+		err = new(Macaroon).UnmarshalBinary(b[:i])
+		if !errors.Is(err, ErrMalformed) {
+			t.Errorf("truncated macaroon (%d bytes) not rejected (is: %v, should: %v)", i, err, ErrMalformed)
+		}
+	}
+	err = new(Macaroon).UnmarshalBinary(append(b, 0))
+	if !errors.Is(err, ErrMalformed) {
+		t.Errorf("extended macaroon not rejected (is: %v, should: %v)", err, ErrMalformed)
+	}
+
+	for _, s := range []string{"", "!", "AA", "Af____________"} {
+		_, err = Parse(s)
+		if !errors.Is(err, ErrMalformed) {
+			t.Errorf("invalid string %s not rejected (is: %v, should: %v)", s, err, ErrMalformed)
+		}
+	}
+}
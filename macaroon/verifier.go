@@ -0,0 +1,163 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package macaroon
+
+import (
+	"crypto/subtle"
+	"errors"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PredicateExpires is the name of the built-in predicate which checks an expiry. Its argument is a time in RFC 3339 format.
+const PredicateExpires = "expires"
+
+var (
+	// ErrInvalid is returned if the signature of a macaroon does not match.
+	ErrInvalid = errors.New("macaroon: invalid signature")
+
+	// ErrUnsatisfied is returned if a caveat of a macaroon is not satisfied or has no registered predicate.
+	ErrUnsatisfied = errors.New("macaroon: caveat not satisfied")
+)
+
+// Context contains the information a request is checked against.
+type Context struct {
+	// Now is the time of the request. If it is zero, caveats using PredicateExpires are not satisfied.
+	Now time.Time
+	// Values contains arbitrary values of the request (e.g. the path or the operation).
+	Values map[string]string
+}
+
+// Predicate checks whether the argument of a caveat is satisfied in ctx.
+type Predicate func(argument string, ctx Context) bool
+
+// Verifier checks macaroons against a registry of predicates.
+// The zero value knows the predicate PredicateExpires.
+//
+// Can be used concurrent.
+type Verifier struct {
+	m          sync.RWMutex
+	predicates map[string]Predicate
+}
+
+// Register registers p for caveats with the given predicate name. Registering a name again replaces the predicate.
+// The name must not be empty or contain a space.
+func (v *Verifier) Register(name string, p Predicate) error {
+	if name == "" || strings.Contains(name, " ") {
+		return errors.New("invalid predicate name")
+	}
+	if p == nil {
+		return errors.New("no predicate")
+	}
+
+	v.m.Lock()
+	defer v.m.Unlock()
+	if v.predicates == nil {
+		v.predicates = make(map[string]Predicate)
+	}
+	v.predicates[name] = p
+	return nil
+}
+
+// Verify checks the signature of m and all of its caveats.
+// ErrInvalid is returned if the signature does not match, ErrUnsatisfied if a caveat is not satisfied.
+func (v *Verifier) Verify(m *Macaroon, ctx Context) error {
+	key, err := rootKey(m.id)
+	if err != nil {
+		return err
+	}
+	signature := chain(key, m.id)
+	for i := range m.caveats {
+		signature = chain(signature, []byte(m.caveats[i]))
+	}
+	if subtle.ConstantTimeCompare(signature, m.signature) == 0 {
+		return ErrInvalid
+	}
+
+	v.m.RLock()
+	defer v.m.RUnlock()
+	for i := range m.caveats {
+		name, argument := m.caveats[i], ""
+		if s := strings.IndexByte(name, ' '); s != -1 {
+			name, argument = name[:s], name[s+1:]
+		}
+		p, ok := v.predicates[name]
+		if !ok && name == PredicateExpires {
+			p, ok = expires, true
+		}
+		if !ok || !p(argument, ctx) {
+			return ErrUnsatisfied
+		}
+	}
+	return nil
+}
+
+// Expires returns a caveat which expires at t.
+func Expires(t time.Time) string {
+	return Caveat(PredicateExpires, t.UTC().Format(time.RFC3339))
+}
+
+// Equal returns a predicate which is satisfied if the value with the given key in the context is equal to the argument.
+func Equal(key string) Predicate {
+	return func(argument string, ctx Context) bool {
+		value, ok := ctx.Values[key]
+		return ok && value == argument
+	}
+}
+
+// OneOf returns a predicate which is satisfied if the value with the given key in the context is one of the comma separated values of the argument.
+func OneOf(key string) Predicate {
+	return func(argument string, ctx Context) bool {
+		value, ok := ctx.Values[key]
+		if !ok {
+			return false
+		}
+		for _, a := range strings.Split(argument, ",") {
+			if a == value {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// PathPrefix returns a predicate which is satisfied if the value with the given key in the context is a path equal to or below the argument.
+// Both paths are cleaned first, so that "/a/../b" is not below "/a".
+func PathPrefix(key string) Predicate {
+	return func(argument string, ctx Context) bool {
+		value, ok := ctx.Values[key]
+		if !ok {
+			return false
+		}
+		value = path.Clean("/" + value)
+		prefix := path.Clean("/" + argument)
+		if prefix == "/" {
+			return true
+		}
+		return value == prefix || strings.HasPrefix(value, prefix+"/")
+	}
+}
+
+// expires is the predicate of PredicateExpires.
+func expires(argument string, ctx Context) bool {
+	t, err := time.Parse(time.RFC3339, argument)
+	if err != nil || ctx.Now.IsZero() {
+		return false
+	}
+	return !ctx.Now.After(t)
+}
@@ -0,0 +1,203 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package macaroon
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Top-Ranger/auth/data"
+)
+
+func testVerifier(t *testing.T) *Verifier {
+	v := new(Verifier)
+	for name, p := range map[string]Predicate{"op": OneOf("op"), "path": PathPrefix("path"), "user": Equal("user")} {
+		err := v.Register(name, p)
+		if err != nil {
+			t.Logf("error occured: %s", err.Error())
+			t.FailNow()
+		}
+	}
+	return v
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Now()
+	m, err := New([]byte("id"), "")
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	for _, c := range []string{"op read,list", Expires(now.Add(time.Hour)), "path /reports"} {
+		err = m.AddCaveat(c)
+		if err != nil {
+			t.Logf("error occured: %s", err.Error())
+			t.FailNow()
+		}
+	}
+	v := testVerifier(t)
+
+	tests := []struct {
+		name   string
+		ctx    Context
+		result error
+	}{
+		{"valid", Context{Now: now, Values: map[string]string{"op": "read", "path": "/reports/2020"}}, nil},
+		{"valid prefix", Context{Now: now, Values: map[string]string{"op": "list", "path": "/reports"}}, nil},
+		{"wrong op", Context{Now: now, Values: map[string]string{"op": "write", "path": "/reports"}}, ErrUnsatisfied},
+		{"wrong path", Context{Now: now, Values: map[string]string{"op": "read", "path": "/reportsx"}}, ErrUnsatisfied},
+		{"path traversal", Context{Now: now, Values: map[string]string{"op": "read", "path": "/reports/../admin"}}, ErrUnsatisfied},
+		{"missing value", Context{Now: now, Values: map[string]string{"op": "read"}}, ErrUnsatisfied},
+		{"expired", Context{Now: now.Add(2 * time.Hour), Values: map[string]string{"op": "read", "path": "/reports"}}, ErrUnsatisfied},
+		{"no time", Context{Values: map[string]string{"op": "read", "path": "/reports"}}, ErrUnsatisfied},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := v.Verify(m, tc.ctx)
+			if !errors.Is(err, tc.result) {
+				t.Errorf("wrong result (is: %v, should: %v)", err, tc.result)
+			}
+		})
+	}
+}
+
+func TestVerifyAttenuated(t *testing.T) {
+	now := time.Now()
+	ctx := Context{Now: now, Values: map[string]string{"op": "read", "user": "alice"}}
+	v := testVerifier(t)
+
+	m, err := New([]byte("id"), "")
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	err = v.Verify(m, ctx)
+	if err != nil {
+		t.Errorf("macaroon without caveats not accepted: %s", err.Error())
+	}
+
+	// The holder adds a caveat after parsing.
+	holder, err := Parse(m.String())
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	err = holder.AddCaveat("user bob")
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	err = v.Verify(holder, ctx)
+	if !errors.Is(err, ErrUnsatisfied) {
+		t.Errorf("attenuated macaroon accepted (is: %v, should: %v)", err, ErrUnsatisfied)
+	}
+	ctx.Values["user"] = "bob"
+	err = v.Verify(holder, ctx)
+	if err != nil {
+		t.Errorf("attenuated macaroon not accepted: %s", err.Error())
+	}
+}
+
+func TestVerifyInvalid(t *testing.T) {
+	ctx := Context{Now: time.Now(), Values: map[string]string{"op": "read", "user": "alice"}}
+	v := testVerifier(t)
+
+	m, err := New([]byte("id"), "")
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	err = m.AddCaveat("user bob")
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+
+	// Removing or changing caveats must invalidate the signature.
+	removed := m.Clone()
+	removed.caveats = nil
+	changed := m.Clone()
+	changed.caveats[0] = "user alice"
+	otherID := m.Clone()
+	otherID.id = []byte("other")
+
+	for name, c := range map[string]*Macaroon{"removed": removed, "changed": changed, "id": otherID} {
+		err = v.Verify(c, ctx)
+		if !errors.Is(err, ErrInvalid) {
+			t.Errorf("%s: macaroon not rejected (is: %v, should: %v)", name, err, ErrInvalid)
+		}
+	}
+
+	// The root key can not be learned through public ids of package data.
+	id, err := data.Get([]byte("macaroon\x00id"))
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	forged := &Macaroon{id: []byte("id"), signature: chain(id, []byte("id"))}
+	err = v.Verify(forged, ctx)
+	if !errors.Is(err, ErrInvalid) {
+		t.Errorf("macaroon with id of package data not rejected (is: %v, should: %v)", err, ErrInvalid)
+	}
+
+	// Unknown predicates are never satisfied.
+	err = m.AddCaveat("unknown value")
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	ctx.Values["user"] = "bob"
+	err = v.Verify(m, ctx)
+	if !errors.Is(err, ErrUnsatisfied) {
+		t.Errorf("unknown predicate not rejected (is: %v, should: %v)", err, ErrUnsatisfied)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	v := new(Verifier)
+	for _, name := range []string{"", "a b"} {
+		err := v.Register(name, Equal("a"))
+		if err == nil {
+			t.Errorf("invalid name %q not rejected", name)
+		}
+	}
+	err := v.Register("a", nil)
+	if err == nil {
+		t.Errorf("nil predicate not rejected")
+	}
+
+	// Built-in predicates can be replaced.
+	err = v.Register(PredicateExpires, func(string, Context) bool { return true })
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	m, err := New([]byte("id"), "")
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	err = m.AddCaveat(Expires(time.Now().Add(-time.Hour)))
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	err = v.Verify(m, Context{Now: time.Now()})
+	if err != nil {
+		t.Errorf("replaced predicate not used: %s", err.Error())
+	}
+}
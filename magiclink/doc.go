@@ -0,0 +1,23 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package magiclink contains a passwordless login by email.
+// The user enters an email address and receives a link. Opening the link logs the user in.
+//
+// Links are short-lived and can only be used once (which is tracked by a ReplayStore). They are authenticated using the timed authentification of package data, so links become invalid whenever the program restarts.
+// A link is bound to the browser which requested it through a random value stored in a cookie. This way, a link intercepted from the email can not be used in another browser.
+//
+// Sending links costs resources and might annoy the owners of the addresses, so you should limit the requests (e.g. with package ratelimit).
+package magiclink
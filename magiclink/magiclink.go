@@ -0,0 +1,299 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package magiclink
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Top-Ranger/auth/data"
+)
+
+const (
+	// EmailField is the name of the form field containing the email address for the request handler.
+	EmailField = "email"
+
+	// TokenParameter is the name of the query parameter containing the token in a link.
+	TokenParameter = "token"
+
+	// CookieNameDefault is the default name of the cookie binding links to the browser.
+	CookieNameDefault = "magiclink"
+
+	// ValidDurationDefault contains the suggested default validity of a link.
+	ValidDurationDefault = 15 * time.Minute
+
+	// randomSize is the number of random bytes of the cookie and of the token id.
+	randomSize = 32
+
+	// maxExpiredDuration determines how long expired links are reported as expired instead of invalid.
+	maxExpiredDuration = 24 * time.Hour
+)
+
+var (
+	// ErrInvalid is returned if a link is not valid.
+	ErrInvalid = errors.New("magiclink: invalid link")
+
+	// ErrExpired is returned if a link is expired.
+	ErrExpired = errors.New("magiclink: link expired")
+
+	// ErrUsed is returned if a link was already used.
+	ErrUsed = errors.New("magiclink: link already used")
+
+	// ErrBrowser is returned if a link is opened in a browser which did not request a link.
+	ErrBrowser = errors.New("magiclink: link opened in another browser")
+
+	// ErrEmail is returned by the request handler if the email address is not valid.
+	ErrEmail = errors.New("magiclink: invalid email address")
+)
+
+var encoding = base64.RawURLEncoding
+
+type contextKey int
+
+const contextFailure contextKey = iota
+
+// Manager issues and verifies links.
+//
+// Can be used concurrent.
+type Manager struct {
+	// Store remembers used links.
+	Store ReplayStore
+	// ConsumeURL is the absolute URL the consume handler is served at. The token is added to it.
+	ConsumeURL string
+	// Send sends link to email. It is called by the request handler.
+	Send func(r *http.Request, email string, link *url.URL) error
+	// Login is called by the consume handler with the verified email address. It must write the response (e.g. by starting a session and redirecting the user).
+	Login func(rw http.ResponseWriter, r *http.Request, email string)
+	// Sent is called by the request handler after the link was sent. If it is nil, a short text is returned.
+	Sent http.Handler
+	// ValidDuration determines how long a link is valid. If it is zero, ValidDurationDefault is used.
+	ValidDuration time.Duration
+	// CookieName is the name of the cookie binding links to the browser. If it is empty, CookieNameDefault is used.
+	CookieName string
+	// Secure determines whether the cookie should only be sent over HTTPS.
+	Secure bool
+	// ErrorHandler is called for failed requests. The reason can be retrieved with FailureReason. If it is nil, 400 Bad Request (request handler) or 403 Forbidden (consume handler) is returned.
+	ErrorHandler http.Handler
+}
+
+// Token returns a new token for email. nonce is the random value of the browser requesting the link (see RequestHandler).
+func (m *Manager) Token(email, nonce string, now time.Time) (string, error) {
+	b := make([]byte, randomSize)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	jti := encoding.EncodeToString(b)
+
+	id, err := data.GetTimed(now, binding(email, nonce, jti))
+	if err != nil {
+		return "", err
+	}
+	return strings.Join([]string{encoding.EncodeToString([]byte(email)), jti, encoding.EncodeToString(id)}, "."), nil
+}
+
+// Verify checks token for the browser with the given nonce and marks it as used. The email address the token was issued for is returned.
+//
+// ErrInvalid, ErrExpired, ErrUsed or ErrBrowser is returned if the token can not be used. Errors of the store are returned as they are.
+func (m *Manager) Verify(token, nonce string, now time.Time) (string, error) {
+	if m.Store == nil {
+		return "", errors.New("no store")
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", ErrInvalid
+	}
+	email, err := encoding.DecodeString(parts[0])
+	if err != nil {
+		return "", ErrInvalid
+	}
+	jti := parts[1]
+	id, err := encoding.DecodeString(parts[2])
+	if err != nil {
+		return "", ErrInvalid
+	}
+
+	validDuration := m.validDuration()
+	b := binding(string(email), nonce, jti)
+	if !data.VerifyTimed(id, b, now, validDuration) {
+		switch {
+		case data.VerifyTimed(id, b, now, maxExpiredDuration):
+			return "", ErrExpired
+		case nonce == "":
+			// A browser without cookie can not have requested any link.
+			return "", ErrBrowser
+		default:
+			// A link opened in another browser with a cookie can not be distinguished from a modified link.
+			return "", ErrInvalid
+		}
+	}
+
+	ok, err := m.Store.Use(jti, now, now.Add(validDuration))
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", ErrUsed
+	}
+	return string(email), nil
+}
+
+// RequestHandler returns a handler sending a link to the email address in the form field EmailField (usually with a POST request).
+// The handler sets the cookie binding the link to the browser, so the link must be opened in the same browser.
+func (m *Manager) RequestHandler() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		address, err := mail.ParseAddress(r.PostFormValue(EmailField))
+		if err != nil {
+			m.fail(rw, r, ErrEmail, http.StatusBadRequest)
+			return
+		}
+		if m.Send == nil {
+			http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		nonce, ok := m.nonce(r)
+		if !ok {
+			b := make([]byte, randomSize)
+			_, err = rand.Read(b)
+			if err != nil {
+				http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+			nonce = encoding.EncodeToString(b)
+		}
+
+		token, err := m.Token(address.Address, nonce, time.Now())
+		if err != nil {
+			http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		link, err := url.Parse(m.ConsumeURL)
+		if err != nil {
+			http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		q := link.Query()
+		q.Set(TokenParameter, token)
+		link.RawQuery = q.Encode()
+
+		err = m.Send(r, address.Address, link)
+		if err != nil {
+			http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		http.SetCookie(rw, &http.Cookie{
+			Name:     m.cookieName(),
+			Value:    nonce,
+			Path:     "/",
+			MaxAge:   int(m.validDuration() / time.Second),
+			HttpOnly: true,
+			Secure:   m.Secure,
+			SameSite: http.SameSiteLaxMode,
+		})
+		if m.Sent != nil {
+			m.Sent.ServeHTTP(rw, r)
+			return
+		}
+		rw.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		rw.Write([]byte("A login link was sent to your email address."))
+	})
+}
+
+// ConsumeHandler returns a handler verifying the link in the query parameter TokenParameter and calling Login with the email address.
+// The cookie binding links to the browser is removed after a successful login.
+func (m *Manager) ConsumeHandler() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if m.Login == nil {
+			http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		nonce, _ := m.nonce(r)
+		email, err := m.Verify(r.URL.Query().Get(TokenParameter), nonce, time.Now())
+		switch {
+		case errors.Is(err, ErrInvalid), errors.Is(err, ErrExpired), errors.Is(err, ErrUsed), errors.Is(err, ErrBrowser):
+			m.fail(rw, r, err, http.StatusForbidden)
+			return
+		case err != nil:
+			http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		http.SetCookie(rw, &http.Cookie{Name: m.cookieName(), Path: "/", MaxAge: -1, HttpOnly: true, Secure: m.Secure})
+		m.Login(rw, r, email)
+	})
+}
+
+// FailureReason returns the reason a request failed. It can be used in the ErrorHandler of a Manager.
+func FailureReason(r *http.Request) error {
+	err, ok := r.Context().Value(contextFailure).(error)
+	if !ok {
+		return nil
+	}
+	return err
+}
+
+// fail calls the error handler or returns the given status code.
+func (m *Manager) fail(rw http.ResponseWriter, r *http.Request, err error, statusCode int) {
+	r = r.WithContext(context.WithValue(r.Context(), contextFailure, err))
+	if m.ErrorHandler != nil {
+		m.ErrorHandler.ServeHTTP(rw, r)
+		return
+	}
+	http.Error(rw, http.StatusText(statusCode), statusCode)
+}
+
+// nonce returns the random value of the cookie of r.
+func (m *Manager) nonce(r *http.Request) (string, bool) {
+	c, err := r.Cookie(m.cookieName())
+	if err != nil {
+		return "", false
+	}
+	b, err := encoding.DecodeString(c.Value)
+	if err != nil || len(b) != randomSize {
+		return "", false
+	}
+	return c.Value, true
+}
+
+func (m *Manager) cookieName() string {
+	if m.CookieName == "" {
+		return CookieNameDefault
+	}
+	return m.CookieName
+}
+
+func (m *Manager) validDuration() time.Duration {
+	if m.ValidDuration == 0 {
+		return ValidDurationDefault
+	}
+	return m.ValidDuration
+}
+
+// binding returns the data a token is bound to. The email address is last, so that it can not shift the other (fixed size) parts.
+func binding(email, nonce, jti string) []byte {
+	return []byte(strings.Join([]string{"magiclink", nonce, jti, email}, "\x00"))
+}
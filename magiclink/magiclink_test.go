@@ -0,0 +1,198 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package magiclink
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testNonce = "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+
+func TestTokenVerify(t *testing.T) {
+	m := &Manager{Store: NewMemoryReplayStore()}
+	testtime := time.Now()
+
+	token, err := m.Token("alice@example.com", testNonce, testtime)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if url.QueryEscape(token) != token {
+		t.Errorf("token not URL-safe: %s", token)
+	}
+
+	email, err := m.Verify(token, testNonce, testtime.Add(time.Minute))
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if email != "alice@example.com" {
+		t.Errorf("wrong email (is: %s, should: %s)", email, "alice@example.com")
+	}
+
+	_, err = m.Verify(token, testNonce, testtime.Add(time.Minute))
+	if !errors.Is(err, ErrUsed) {
+		t.Errorf("wrong error for used token (is: %v, should: %v)", err, ErrUsed)
+	}
+}
+
+func TestVerifyInvalid(t *testing.T) {
+	m := &Manager{Store: NewMemoryReplayStore(), ValidDuration: 10 * time.Minute}
+	testtime := time.Now()
+
+	token, err := m.Token("alice@example.com", testNonce, testtime)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	parts := strings.Split(token, ".")
+
+	testcases := []struct {
+		name  string
+		token string
+		nonce string
+		now   time.Time
+		err   error
+	}{
+		{"expired", token, testNonce, testtime.Add(11 * time.Minute), ErrExpired},
+		{"future", token, testNonce, testtime.Add(-time.Minute), ErrInvalid},
+		{"no cookie", token, "", testtime, ErrBrowser},
+		{"other browser", token, "BBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBB", testtime, ErrInvalid},
+		{"other email", strings.Join([]string{encoding.EncodeToString([]byte("mallory@example.com")), parts[1], parts[2]}, "."), testNonce, testtime, ErrInvalid},
+		{"other id", strings.Join([]string{parts[0], testNonce, parts[2]}, "."), testNonce, testtime, ErrInvalid},
+		{"malformed", "token", testNonce, testtime, ErrInvalid},
+		{"empty", "", testNonce, testtime, ErrInvalid},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := m.Verify(tc.token, tc.nonce, tc.now)
+			if !errors.Is(err, tc.err) {
+				t.Errorf("wrong error (is: %v, should: %v)", err, tc.err)
+			}
+		})
+	}
+
+	// Failed attempts do not use the token
+	_, err = m.Verify(token, testNonce, testtime)
+	if err != nil {
+		t.Errorf("token not valid after failed attempts: %s", err.Error())
+	}
+}
+
+func TestHandlers(t *testing.T) {
+	var sent *url.URL
+	var loggedIn string
+	m := &Manager{
+		Store:      NewMemoryReplayStore(),
+		ConsumeURL: "https://example.com/login?next=%2F",
+		Send: func(r *http.Request, email string, link *url.URL) error {
+			if email != "alice@example.com" {
+				t.Errorf("wrong email (is: %s, should: %s)", email, "alice@example.com")
+			}
+			sent = link
+			return nil
+		},
+		Login: func(rw http.ResponseWriter, r *http.Request, email string) {
+			loggedIn = email
+		},
+	}
+
+	// Request link
+	r := httptest.NewRequest(http.MethodPost, "/request", strings.NewReader(url.Values{EmailField: {"Alice <alice@example.com>"}}.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rw := httptest.NewRecorder()
+	m.RequestHandler().ServeHTTP(rw, r)
+	if rw.Code != http.StatusOK {
+		t.Fatalf("wrong status code (is: %d, should: %d)", rw.Code, http.StatusOK)
+	}
+	if sent == nil {
+		t.Fatal("no link sent")
+	}
+	if sent.Host != "example.com" || sent.Path != "/login" || sent.Query().Get("next") != "/" {
+		t.Errorf("wrong link: %s", sent)
+	}
+	cookies := rw.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != CookieNameDefault {
+		t.Fatalf("cookie not set")
+	}
+
+	// Consume link in another browser
+	r = httptest.NewRequest(http.MethodGet, sent.RequestURI(), nil)
+	rw = httptest.NewRecorder()
+	m.ConsumeHandler().ServeHTTP(rw, r)
+	if rw.Code != http.StatusForbidden || loggedIn != "" {
+		t.Errorf("link accepted in another browser")
+	}
+
+	// Consume link
+	r = httptest.NewRequest(http.MethodGet, sent.RequestURI(), nil)
+	r.AddCookie(cookies[0])
+	rw = httptest.NewRecorder()
+	m.ConsumeHandler().ServeHTTP(rw, r)
+	if loggedIn != "alice@example.com" {
+		t.Errorf("wrong login (is: %s, should: %s)", loggedIn, "alice@example.com")
+	}
+	c := rw.Result().Cookies()
+	if len(c) != 1 || c[0].MaxAge >= 0 {
+		t.Errorf("cookie not removed")
+	}
+
+	// Consume link again
+	loggedIn = ""
+	var reason error
+	m.ErrorHandler = http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		reason = FailureReason(r)
+		rw.WriteHeader(http.StatusTeapot)
+	})
+	r = httptest.NewRequest(http.MethodGet, sent.RequestURI(), nil)
+	r.AddCookie(cookies[0])
+	rw = httptest.NewRecorder()
+	m.ConsumeHandler().ServeHTTP(rw, r)
+	if loggedIn != "" {
+		t.Error("link used twice")
+	}
+	if rw.Code != http.StatusTeapot || !errors.Is(reason, ErrUsed) {
+		t.Errorf("error handler not called correctly (code: %d, reason: %v)", rw.Code, reason)
+	}
+}
+
+func TestRequestHandlerInvalidEmail(t *testing.T) {
+	m := &Manager{
+		Store:      NewMemoryReplayStore(),
+		ConsumeURL: "https://example.com/login",
+		Send: func(r *http.Request, email string, link *url.URL) error {
+			t.Error("link sent to invalid email")
+			return nil
+		},
+	}
+
+	for _, email := range []string{"", "alice", "alice@"} {
+		r := httptest.NewRequest(http.MethodPost, "/request", strings.NewReader(url.Values{EmailField: {email}}.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rw := httptest.NewRecorder()
+		m.RequestHandler().ServeHTTP(rw, r)
+		if rw.Code != http.StatusBadRequest {
+			t.Errorf("wrong status code for %s (is: %d, should: %d)", email, rw.Code, http.StatusBadRequest)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package magiclink
+
+import (
+	"sync"
+	"time"
+)
+
+// ReplayStore remembers used links.
+//
+// All methods must be safe for concurrent use.
+type ReplayStore interface {
+	// Use marks id as used. It returns false if id was already used. Checking and marking must be atomic.
+	// The entry is not needed after expires and can be removed.
+	Use(id string, now, expires time.Time) (bool, error)
+}
+
+// MemoryReplayStore is an in-memory ReplayStore.
+// Expired entries are removed automatically.
+type MemoryReplayStore struct {
+	m         sync.Mutex
+	entries   map[string]time.Time
+	lastPrune time.Time
+}
+
+// memoryReplayPruneInterval determines how often expired entries are removed from a MemoryReplayStore.
+const memoryReplayPruneInterval = 1 * time.Minute
+
+// NewMemoryReplayStore returns a new, empty MemoryReplayStore.
+func NewMemoryReplayStore() *MemoryReplayStore {
+	return &MemoryReplayStore{
+		entries: make(map[string]time.Time),
+	}
+}
+
+// Use marks id as used. See ReplayStore for more information.
+func (s *MemoryReplayStore) Use(id string, now, expires time.Time) (bool, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if now.Sub(s.lastPrune) >= memoryReplayPruneInterval {
+		s.lastPrune = now
+		for k, v := range s.entries {
+			if now.After(v) {
+				delete(s.entries, k)
+			}
+		}
+	}
+
+	e, ok := s.entries[id]
+	if ok && !now.After(e) {
+		return false, nil
+	}
+	s.entries[id] = expires
+	return true, nil
+}
@@ -0,0 +1,76 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package magiclink
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMemoryReplayStore(t *testing.T) {
+	s := NewMemoryReplayStore()
+	testtime := time.Now()
+
+	ok, err := s.Use("id", testtime, testtime.Add(time.Minute))
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if !ok {
+		t.Error("new id not accepted")
+	}
+	ok, _ = s.Use("id", testtime.Add(30*time.Second), testtime.Add(time.Minute))
+	if ok {
+		t.Error("used id accepted")
+	}
+	ok, _ = s.Use("other", testtime, testtime.Add(time.Minute))
+	if !ok {
+		t.Error("other id not accepted")
+	}
+
+	// Expired entries are removed
+	s.Use("id", testtime.Add(2*time.Minute), testtime.Add(3*time.Minute))
+	if _, ok := s.entries["other"]; ok {
+		t.Error("expired entry not removed")
+	}
+}
+
+func TestMemoryReplayStoreConcurrent(t *testing.T) {
+	s := NewMemoryReplayStore()
+	testtime := time.Now()
+	var wg sync.WaitGroup
+	var m sync.Mutex
+	accepted := 0
+
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ok, _ := s.Use("id", testtime, testtime.Add(time.Minute))
+			if ok {
+				m.Lock()
+				accepted++
+				m.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if accepted != 1 {
+		t.Errorf("id accepted multiple times (is: %d, should: %d)", accepted, 1)
+	}
+}
@@ -0,0 +1,24 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package otp contains methods to generate and verify one-time passwords as used for two-factor authentication.
+// HOTP (RFC 4226) uses a counter, TOTP (RFC 6238) uses the current time. Both support SHA1, SHA256 and SHA512 and codes with 6 to 8 digits.
+// Most authenticator apps only support the defaults (SHA1, 6 digits, 30 seconds period), so you should only change them if you know the apps of your users.
+//
+// The secret is shared with the authenticator app, usually through a QR code containing a provisioning URI (see URI). Package qr can be used to create the QR code.
+// Unlike the hidden value of package data, the secret must be stored permanently for each user.
+//
+// A code is valid for some time (TOTP) or until a later code is used (HOTP). To prevent replay attacks, VerifyOnce remembers the last accepted counter in a CounterStore.
+package otp
@@ -0,0 +1,107 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otp
+
+import (
+	"net/url"
+	"strconv"
+)
+
+// HOTP generates and verifies counter based one-time passwords (RFC 4226).
+//
+// Can be used concurrent.
+type HOTP struct {
+	// Algorithm is the hash algorithm.
+	Algorithm Algorithm
+	// Digits is the number of digits of a code (6 to 8).
+	Digits int
+	// LookAhead is the number of counters after the expected counter which are accepted.
+	// This allows users to generate codes without using them, which is common for hardware tokens.
+	LookAhead int
+}
+
+// HOTPDefault returns the suggested default configuration for HOTP.
+func HOTPDefault() HOTP {
+	return HOTP{
+		Algorithm: SHA1,
+		Digits:    DigitsDefault,
+		LookAhead: 10,
+	}
+}
+
+// Generate returns the code for the given counter.
+func (h HOTP) Generate(secret []byte, counter uint64) (string, error) {
+	return generate(h.Algorithm, h.Digits, secret, counter)
+}
+
+// Verify checks whether code is valid for counter or one of the following LookAhead counters.
+// The matching counter is returned. The next expected counter is the matching counter + 1.
+//
+// Verify does not protect against replays, so you must store the next expected counter yourself or use VerifyOnce.
+func (h HOTP) Verify(secret []byte, counter uint64, code string) (matched uint64, ok bool) {
+	code = normaliseCode(code)
+	for i := 0; i <= h.LookAhead; i++ {
+		c := counter + uint64(i)
+		if c < counter {
+			// Overflow
+			break
+		}
+		if check(h.Algorithm, h.Digits, secret, c, code) {
+			return c, true
+		}
+	}
+	return 0, false
+}
+
+// VerifyOnce verifies code starting with the counter following the last accepted counter of key in store (or 0 if none was accepted yet).
+// The matching counter is accepted, so that the code and all previous codes can not be used again.
+//
+// ErrInvalid is returned if the code is not valid. ErrReplay is returned if the code was accepted concurrently.
+func (h HOTP) VerifyOnce(store CounterStore, key string, secret []byte, code string) error {
+	last, ok, err := store.Last(key)
+	if err != nil {
+		return err
+	}
+	var start uint64
+	if ok {
+		start = last + 1
+		if start == 0 {
+			// Overflow, all counters are used.
+			return ErrInvalid
+		}
+	}
+
+	c, valid := h.Verify(secret, start, code)
+	if !valid {
+		return ErrInvalid
+	}
+	accepted, err := store.Accept(key, c)
+	if err != nil {
+		return err
+	}
+	if !accepted {
+		return ErrReplay
+	}
+	return nil
+}
+
+// URI returns the provisioning URI (otpauth://hotp/...) for authenticator apps. counter is the next expected counter.
+// issuer is the name of your service, account identifies the user. issuer may be empty.
+func (h HOTP) URI(secret []byte, issuer, account string, counter uint64) string {
+	params := url.Values{}
+	params.Set("counter", strconv.FormatUint(counter, 10))
+	return uri("hotp", secret, issuer, account, h.Algorithm, h.Digits, params)
+}
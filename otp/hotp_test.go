@@ -0,0 +1,104 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otp
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestHOTPGenerate(t *testing.T) {
+	// Test vectors from RFC 4226, appendix D
+	secret := []byte("12345678901234567890")
+	codes := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+
+	h := HOTPDefault()
+	for i := range codes {
+		code, err := h.Generate(secret, uint64(i))
+		if err != nil {
+			t.Logf("error occured: %s", err.Error())
+			t.FailNow()
+		}
+		if code != codes[i] {
+			t.Errorf("wrong code for counter %d (is: %s, should: %s)", i, code, codes[i])
+		}
+	}
+}
+
+func TestHOTPVerify(t *testing.T) {
+	secret := []byte("12345678901234567890")
+	h := HOTP{Algorithm: SHA1, Digits: 6, LookAhead: 2}
+
+	c, ok := h.Verify(secret, 3, "969429")
+	if !ok || c != 3 {
+		t.Errorf("expected code not accepted (is: %d, should: %d)", c, 3)
+	}
+	c, ok = h.Verify(secret, 3, "254 676")
+	if !ok || c != 5 {
+		t.Errorf("look ahead code not accepted (is: %d, should: %d)", c, 5)
+	}
+	_, ok = h.Verify(secret, 3, "287922")
+	if ok {
+		t.Error("code after look ahead accepted")
+	}
+	_, ok = h.Verify(secret, 3, "359152")
+	if ok {
+		t.Error("previous code accepted")
+	}
+	_, ok = h.Verify(secret, 3, "")
+	if ok {
+		t.Error("empty code accepted")
+	}
+}
+
+func TestHOTPVerifyOnce(t *testing.T) {
+	secret := []byte("12345678901234567890")
+	h := HOTP{Algorithm: SHA1, Digits: 6, LookAhead: 2}
+	store := NewMemoryCounterStore()
+
+	err := h.VerifyOnce(store, "user", secret, "287082")
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	err = h.VerifyOnce(store, "user", secret, "287082")
+	if !errors.Is(err, ErrInvalid) {
+		t.Errorf("wrong error for reused code (is: %v, should: %v)", err, ErrInvalid)
+	}
+	err = h.VerifyOnce(store, "user", secret, "755224")
+	if !errors.Is(err, ErrInvalid) {
+		t.Errorf("wrong error for previous code (is: %v, should: %v)", err, ErrInvalid)
+	}
+	err = h.VerifyOnce(store, "user", secret, "338314")
+	if err != nil {
+		t.Errorf("look ahead code not accepted: %s", err.Error())
+	}
+
+	// Keys are independent
+	err = h.VerifyOnce(store, "other", secret, "755224")
+	if err != nil {
+		t.Errorf("code of other key not accepted: %s", err.Error())
+	}
+}
+
+func TestHOTPURI(t *testing.T) {
+	h := HOTPDefault()
+	uri := h.URI([]byte("12345678901234567890"), "Example", "alice@example.com", 5)
+	should := "otpauth://hotp/Example:alice@example.com?algorithm=SHA1&counter=5&digits=6&issuer=Example&secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	if uri != should {
+		t.Errorf("wrong uri (is: %s, should: %s)", uri, should)
+	}
+}
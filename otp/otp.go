@@ -0,0 +1,176 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DigitsDefault is the suggested default number of digits of a code.
+	DigitsDefault = 6
+
+	// SecretSizeDefault is the suggested default size of a secret in bytes (as recommended by RFC 4226).
+	SecretSizeDefault = 20
+)
+
+var (
+	// ErrInvalid is returned if a code is not valid.
+	ErrInvalid = errors.New("otp: invalid code")
+
+	// ErrReplay is returned if a valid code was already used.
+	ErrReplay = errors.New("otp: code already used")
+)
+
+// secretEncoding is the encoding of secrets in provisioning URIs.
+var secretEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// Algorithm is the hash algorithm used to generate codes.
+type Algorithm int
+
+const (
+	// SHA1 uses HMAC-SHA1. It is the default and supported by all authenticator apps.
+	SHA1 Algorithm = iota
+	// SHA256 uses HMAC-SHA256.
+	SHA256
+	// SHA512 uses HMAC-SHA512.
+	SHA512
+)
+
+// String returns the name of the algorithm as used in provisioning URIs.
+func (a Algorithm) String() string {
+	switch a {
+	case SHA1:
+		return "SHA1"
+	case SHA256:
+		return "SHA256"
+	case SHA512:
+		return "SHA512"
+	default:
+		return "unknown"
+	}
+}
+
+// hash returns the hash function of the algorithm or nil if it is unknown.
+func (a Algorithm) hash() func() hash.Hash {
+	switch a {
+	case SHA1:
+		return sha1.New
+	case SHA256:
+		return sha256.New
+	case SHA512:
+		return sha512.New
+	default:
+		return nil
+	}
+}
+
+// GenerateSecret returns a new random secret of the given size in bytes.
+// RFC 4226 requires at least 16 bytes, SecretSizeDefault is recommended.
+//
+// Can be used concurrent.
+func GenerateSecret(size int) ([]byte, error) {
+	if size < 16 {
+		return nil, errors.New("secret must be at least 16 bytes")
+	}
+	secret := make([]byte, size)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+// EncodeSecret returns the base32 representation of secret, which is used by authenticator apps for manual input.
+func EncodeSecret(secret []byte) string {
+	return secretEncoding.EncodeToString(secret)
+}
+
+// DecodeSecret decodes a secret encoded by EncodeSecret. Spaces, padding and lower case letters are accepted.
+func DecodeSecret(s string) ([]byte, error) {
+	s = strings.ToUpper(strings.ReplaceAll(s, " ", ""))
+	s = strings.TrimRight(s, "=")
+	return secretEncoding.DecodeString(s)
+}
+
+// generate returns the code for counter as defined in RFC 4226, section 5.3.
+func generate(a Algorithm, digits int, secret []byte, counter uint64) (string, error) {
+	h := a.hash()
+	if h == nil {
+		return "", errors.New("unknown algorithm")
+	}
+	if digits < 6 || digits > 8 {
+		return "", errors.New("digits must be between 6 and 8")
+	}
+	if len(secret) == 0 {
+		return "", errors.New("empty secret")
+	}
+
+	mac := hmac.New(h, secret)
+	var c [8]byte
+	binary.BigEndian.PutUint64(c[:], counter)
+	mac.Write(c[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0xf
+	value := binary.BigEndian.Uint32(sum[offset:]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+	code := strconv.FormatUint(uint64(value%mod), 10)
+	return strings.Repeat("0", digits-len(code)) + code, nil
+}
+
+// check returns whether code is the code for counter. The comparison is done in constant time.
+func check(a Algorithm, digits int, secret []byte, counter uint64, code string) bool {
+	expected, err := generate(a, digits, secret, counter)
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1
+}
+
+// normaliseCode removes spaces which users might enter for readability.
+func normaliseCode(code string) string {
+	return strings.ReplaceAll(code, " ", "")
+}
+
+// uri returns a provisioning URI in the format used by Google Authenticator.
+func uri(kind string, secret []byte, issuer, account string, a Algorithm, digits int, params url.Values) string {
+	label := url.PathEscape(account)
+	if issuer != "" {
+		label = url.PathEscape(issuer) + ":" + label
+		params.Set("issuer", issuer)
+	}
+	params.Set("secret", EncodeSecret(secret))
+	params.Set("algorithm", a.String())
+	params.Set("digits", strconv.Itoa(digits))
+	return "otpauth://" + kind + "/" + label + "?" + params.Encode()
+}
@@ -0,0 +1,112 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otp
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestGenerateSecret(t *testing.T) {
+	s, err := GenerateSecret(SecretSizeDefault)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if len(s) != SecretSizeDefault {
+		t.Errorf("wrong size (is: %d, should: %d)", len(s), SecretSizeDefault)
+	}
+
+	// simple random test
+	for x := 0; x < 100; x++ {
+		s2, err := GenerateSecret(SecretSizeDefault)
+		if err != nil {
+			t.Logf("error occured: %s", err.Error())
+			t.FailNow()
+		}
+		if bytes.Equal(s, s2) {
+			t.Errorf("simple random error failed: %v == %v", s, s2)
+			break
+		}
+	}
+
+	_, err = GenerateSecret(15)
+	if err == nil {
+		t.Error("no error for short secret")
+	}
+}
+
+func TestEncodeSecret(t *testing.T) {
+	secret := []byte("12345678901234567890")
+	encoded := EncodeSecret(secret)
+	if encoded != "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ" {
+		t.Errorf("wrong encoding (is: %s, should: %s)", encoded, "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ")
+	}
+
+	for _, s := range []string{encoded, "gezd gnbv gy3t qojq gezd gnbv gy3t qojq"} {
+		decoded, err := DecodeSecret(s)
+		if err != nil {
+			t.Logf("error occured: %s", err.Error())
+			t.FailNow()
+		}
+		if !bytes.Equal(decoded, secret) {
+			t.Errorf("wrong decoding of %s (is: %v, should: %v)", s, decoded, secret)
+		}
+	}
+
+	decoded, err := DecodeSecret("MZXW6===")
+	if err != nil || string(decoded) != "foo" {
+		t.Errorf("padding not accepted: %v %v", decoded, err)
+	}
+
+	_, err = DecodeSecret("!")
+	if err == nil {
+		t.Error("no error for invalid secret")
+	}
+}
+
+func TestGenerateInvalid(t *testing.T) {
+	secret := []byte("12345678901234567890")
+	testcases := []struct {
+		name      string
+		algorithm Algorithm
+		digits    int
+		secret    []byte
+	}{
+		{"algorithm", Algorithm(42), 6, secret},
+		{"few digits", SHA1, 5, secret},
+		{"many digits", SHA1, 9, secret},
+		{"empty secret", SHA1, 6, nil},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := generate(tc.algorithm, tc.digits, tc.secret, 0)
+			if err == nil {
+				t.Error("no error")
+			}
+		})
+	}
+}
+
+func TestAlgorithmString(t *testing.T) {
+	testcases := map[Algorithm]string{SHA1: "SHA1", SHA256: "SHA256", SHA512: "SHA512", Algorithm(42): "unknown"}
+	for a, s := range testcases {
+		if a.String() != s {
+			t.Errorf("wrong string (is: %s, should: %s)", a.String(), s)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otp
+
+import (
+	"sync"
+)
+
+// CounterStore remembers the last accepted counter per key (e.g. per user) to prevent replay attacks.
+//
+// All methods must be safe for concurrent use.
+type CounterStore interface {
+	// Last returns the last accepted counter of key. ok is false if no counter was accepted yet.
+	Last(key string) (counter uint64, ok bool, err error)
+	// Accept stores counter as the last accepted counter of key if it is greater than the current one.
+	// It returns false if the counter was not greater. Checking and storing must be atomic.
+	Accept(key string, counter uint64) (bool, error)
+}
+
+// MemoryCounterStore is an in-memory CounterStore.
+// Counters are lost whenever the program restarts, so codes used shortly before a restart can be replayed afterwards.
+type MemoryCounterStore struct {
+	m        sync.Mutex
+	counters map[string]uint64
+}
+
+// NewMemoryCounterStore returns a new, empty MemoryCounterStore.
+func NewMemoryCounterStore() *MemoryCounterStore {
+	return &MemoryCounterStore{
+		counters: make(map[string]uint64),
+	}
+}
+
+// Last returns the last accepted counter of key. See CounterStore for more information.
+func (s *MemoryCounterStore) Last(key string) (uint64, bool, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	c, ok := s.counters[key]
+	return c, ok, nil
+}
+
+// Accept stores counter if it is greater than the last accepted one. See CounterStore for more information.
+func (s *MemoryCounterStore) Accept(key string, counter uint64) (bool, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	last, ok := s.counters[key]
+	if ok && counter <= last {
+		return false, nil
+	}
+	s.counters[key] = counter
+	return true, nil
+}
+
+// Delete removes the counter of key, e.g. when the user gets a new secret.
+func (s *MemoryCounterStore) Delete(key string) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	delete(s.counters, key)
+}
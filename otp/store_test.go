@@ -0,0 +1,86 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otp
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestMemoryCounterStore(t *testing.T) {
+	s := NewMemoryCounterStore()
+
+	_, ok, err := s.Last("key")
+	if err != nil || ok {
+		t.Errorf("counter for new key found")
+	}
+
+	for _, tc := range []struct {
+		counter  uint64
+		accepted bool
+	}{{5, true}, {5, false}, {4, false}, {6, true}, {0, false}} {
+		accepted, err := s.Accept("key", tc.counter)
+		if err != nil {
+			t.Logf("error occured: %s", err.Error())
+			t.FailNow()
+		}
+		if accepted != tc.accepted {
+			t.Errorf("wrong result for counter %d (is: %t, should: %t)", tc.counter, accepted, tc.accepted)
+		}
+	}
+
+	c, ok, _ := s.Last("key")
+	if !ok || c != 6 {
+		t.Errorf("wrong last counter (is: %d, should: %d)", c, 6)
+	}
+
+	// First counter can be 0
+	accepted, _ := s.Accept("other", 0)
+	if !accepted {
+		t.Error("counter 0 not accepted for new key")
+	}
+
+	s.Delete("key")
+	_, ok, _ = s.Last("key")
+	if ok {
+		t.Error("deleted counter found")
+	}
+}
+
+func TestMemoryCounterStoreConcurrent(t *testing.T) {
+	s := NewMemoryCounterStore()
+	var wg sync.WaitGroup
+	var m sync.Mutex
+	accepted := 0
+
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ok, _ := s.Accept("key", 1)
+			if ok {
+				m.Lock()
+				accepted++
+				m.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if accepted != 1 {
+		t.Errorf("counter accepted multiple times (is: %d, should: %d)", accepted, 1)
+	}
+}
@@ -0,0 +1,121 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otp
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// PeriodDefault is the suggested default period of TOTP.
+const PeriodDefault = 30 * time.Second
+
+// TOTP generates and verifies time based one-time passwords (RFC 6238).
+//
+// Can be used concurrent.
+type TOTP struct {
+	// Algorithm is the hash algorithm.
+	Algorithm Algorithm
+	// Digits is the number of digits of a code (6 to 8).
+	Digits int
+	// Period is the time a code is valid. It must be a positive multiple of a second.
+	Period time.Duration
+	// Skew is the number of periods before and after the current period which are accepted.
+	// This allows for clock differences and the time users need to enter a code.
+	Skew int
+}
+
+// TOTPDefault returns the suggested default configuration for TOTP.
+func TOTPDefault() TOTP {
+	return TOTP{
+		Algorithm: SHA1,
+		Digits:    DigitsDefault,
+		Period:    PeriodDefault,
+		Skew:      1,
+	}
+}
+
+// Counter returns the counter (time step) of now.
+func (t TOTP) Counter(now time.Time) (uint64, error) {
+	if t.Period < time.Second || t.Period%time.Second != 0 {
+		return 0, errors.New("period must be a positive multiple of a second")
+	}
+	unix := now.Unix()
+	if unix < 0 {
+		return 0, errors.New("time before unix epoch")
+	}
+	return uint64(unix) / uint64(t.Period/time.Second), nil
+}
+
+// Generate returns the code for now.
+func (t TOTP) Generate(secret []byte, now time.Time) (string, error) {
+	c, err := t.Counter(now)
+	if err != nil {
+		return "", err
+	}
+	return generate(t.Algorithm, t.Digits, secret, c)
+}
+
+// Verify checks whether code is valid at now, taking Skew into account. The matching counter is returned.
+//
+// Verify does not protect against replays, so a code can be used multiple times while it is valid. Use VerifyOnce to prevent this.
+func (t TOTP) Verify(secret []byte, code string, now time.Time) (matched uint64, ok bool) {
+	current, err := t.Counter(now)
+	if err != nil {
+		return 0, false
+	}
+	code = normaliseCode(code)
+	for i := -t.Skew; i <= t.Skew; i++ {
+		c := current + uint64(i)
+		if (i < 0 && c > current) || (i > 0 && c < current) {
+			// Overflow
+			continue
+		}
+		if check(t.Algorithm, t.Digits, secret, c, code) {
+			return c, true
+		}
+	}
+	return 0, false
+}
+
+// VerifyOnce checks whether code is valid at now and accepts its counter for key in store.
+// Codes of the accepted period and all earlier periods can not be used again.
+//
+// ErrInvalid is returned if the code is not valid. ErrReplay is returned if the code (or a later one) was already used.
+func (t TOTP) VerifyOnce(store CounterStore, key string, secret []byte, code string, now time.Time) error {
+	c, valid := t.Verify(secret, code, now)
+	if !valid {
+		return ErrInvalid
+	}
+	accepted, err := store.Accept(key, c)
+	if err != nil {
+		return err
+	}
+	if !accepted {
+		return ErrReplay
+	}
+	return nil
+}
+
+// URI returns the provisioning URI (otpauth://totp/...) for authenticator apps.
+// issuer is the name of your service, account identifies the user. issuer may be empty.
+func (t TOTP) URI(secret []byte, issuer, account string) string {
+	params := url.Values{}
+	params.Set("period", strconv.FormatInt(int64(t.Period/time.Second), 10))
+	return uri("totp", secret, issuer, account, t.Algorithm, t.Digits, params)
+}
@@ -0,0 +1,176 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otp
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTOTPGenerate(t *testing.T) {
+	// Test vectors from RFC 6238, appendix B
+	secrets := map[Algorithm][]byte{
+		SHA1:   []byte("12345678901234567890"),
+		SHA256: []byte("12345678901234567890123456789012"),
+		SHA512: []byte("1234567890123456789012345678901234567890123456789012345678901234"),
+	}
+	testcases := []struct {
+		time      int64
+		algorithm Algorithm
+		code      string
+	}{
+		{59, SHA1, "94287082"},
+		{59, SHA256, "46119246"},
+		{59, SHA512, "90693936"},
+		{1111111109, SHA1, "07081804"},
+		{1111111109, SHA256, "68084774"},
+		{1111111109, SHA512, "25091201"},
+		{1111111111, SHA1, "14050471"},
+		{1111111111, SHA256, "67062674"},
+		{1111111111, SHA512, "99943326"},
+		{1234567890, SHA1, "89005924"},
+		{1234567890, SHA256, "91819424"},
+		{1234567890, SHA512, "93441116"},
+		{2000000000, SHA1, "69279037"},
+		{2000000000, SHA256, "90698825"},
+		{2000000000, SHA512, "38618901"},
+		{20000000000, SHA1, "65353130"},
+		{20000000000, SHA256, "77737706"},
+		{20000000000, SHA512, "47863826"},
+	}
+
+	for _, tc := range testcases {
+		totp := TOTP{Algorithm: tc.algorithm, Digits: 8, Period: 30 * time.Second}
+		code, err := totp.Generate(secrets[tc.algorithm], time.Unix(tc.time, 0))
+		if err != nil {
+			t.Logf("error occured: %s", err.Error())
+			t.FailNow()
+		}
+		if code != tc.code {
+			t.Errorf("wrong code for %d / %s (is: %s, should: %s)", tc.time, tc.algorithm, code, tc.code)
+		}
+	}
+}
+
+func TestTOTPVerify(t *testing.T) {
+	secret := []byte("12345678901234567890")
+	totp := TOTPDefault()
+	testtime := time.Unix(1111111111, 0)
+
+	code, err := totp.Generate(secret, testtime)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+
+	c, ok := totp.Verify(secret, code, testtime)
+	if !ok || c != 1111111111/30 {
+		t.Errorf("code not accepted (is: %d, should: %d)", c, 1111111111/30)
+	}
+	_, ok = totp.Verify(secret, code, testtime.Add(totp.Period))
+	if !ok {
+		t.Error("code not accepted within skew")
+	}
+	_, ok = totp.Verify(secret, code, testtime.Add(-totp.Period))
+	if !ok {
+		t.Error("code not accepted within negative skew")
+	}
+	_, ok = totp.Verify(secret, code, testtime.Add(2*totp.Period))
+	if ok {
+		t.Error("code accepted after skew")
+	}
+	_, ok = totp.Verify(secret, code, testtime.Add(-2*totp.Period))
+	if ok {
+		t.Error("code accepted before skew")
+	}
+	_, ok = totp.Verify([]byte("other secret"), code, testtime)
+	if ok {
+		t.Error("code accepted for other secret")
+	}
+
+	// Skew at the beginning of the time
+	code, err = totp.Generate(secret, time.Unix(0, 0))
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	_, ok = totp.Verify(secret, code, time.Unix(0, 0))
+	if !ok {
+		t.Error("code not accepted at unix epoch")
+	}
+}
+
+func TestTOTPInvalidPeriod(t *testing.T) {
+	for _, p := range []time.Duration{0, -time.Second, 1500 * time.Millisecond} {
+		totp := TOTP{Algorithm: SHA1, Digits: 6, Period: p}
+		_, err := totp.Generate([]byte("12345678901234567890"), time.Now())
+		if err == nil {
+			t.Errorf("no error for period %s", p)
+		}
+	}
+}
+
+func TestTOTPVerifyOnce(t *testing.T) {
+	secret := []byte("12345678901234567890")
+	totp := TOTPDefault()
+	store := NewMemoryCounterStore()
+	testtime := time.Unix(1111111111, 0)
+
+	code, _ := totp.Generate(secret, testtime)
+	err := totp.VerifyOnce(store, "user", secret, code, testtime)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	err = totp.VerifyOnce(store, "user", secret, code, testtime)
+	if !errors.Is(err, ErrReplay) {
+		t.Errorf("wrong error for replay (is: %v, should: %v)", err, ErrReplay)
+	}
+
+	// Earlier codes can not be used after a later one
+	earlier, _ := totp.Generate(secret, testtime.Add(-totp.Period))
+	err = totp.VerifyOnce(store, "user", secret, earlier, testtime)
+	if !errors.Is(err, ErrReplay) {
+		t.Errorf("wrong error for earlier code (is: %v, should: %v)", err, ErrReplay)
+	}
+
+	later, _ := totp.Generate(secret, testtime.Add(totp.Period))
+	err = totp.VerifyOnce(store, "user", secret, later, testtime.Add(totp.Period))
+	if err != nil {
+		t.Errorf("later code not accepted: %s", err.Error())
+	}
+
+	err = totp.VerifyOnce(store, "user", secret, "abcdef", testtime)
+	if !errors.Is(err, ErrInvalid) {
+		t.Errorf("wrong error for invalid code (is: %v, should: %v)", err, ErrInvalid)
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	totp := TOTPDefault()
+	uri := totp.URI([]byte("12345678901234567890"), "Example Corp", "alice")
+	should := "otpauth://totp/Example%20Corp:alice?algorithm=SHA1&digits=6&issuer=Example+Corp&period=30&secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	if uri != should {
+		t.Errorf("wrong uri (is: %s, should: %s)", uri, should)
+	}
+
+	uri = totp.URI([]byte("12345678901234567890"), "", "alice")
+	should = "otpauth://totp/alice?algorithm=SHA1&digits=6&period=30&secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	if uri != should {
+		t.Errorf("wrong uri without issuer (is: %s, should: %s)", uri, should)
+	}
+}
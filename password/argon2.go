@@ -0,0 +1,382 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package password
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/bits"
+	"strconv"
+	"sync"
+)
+
+const (
+	// Argon2idID is the identifier of Argon2id in the PHC string format.
+	Argon2idID = "argon2id"
+
+	// argon2Version is the supported version of Argon2 (0x13).
+	argon2Version = 19
+
+	// argon2MaxMemory limits the memory in KiB accepted from a hash.
+	argon2MaxMemory = 1 << 20
+
+	// argon2MaxIterations limits the iterations accepted from a hash.
+	argon2MaxIterations = 1 << 16
+
+	// argon2MaxParallelism is the highest degree of parallelism allowed by RFC 9106.
+	argon2MaxParallelism = 1<<24 - 1
+
+	// argon2MinKeySize is the smallest tag length allowed by RFC 9106.
+	argon2MinKeySize = 4
+
+	// argon2SyncPoints is the number of slices of each lane.
+	argon2SyncPoints = 4
+
+	// argon2Type is the type of Argon2id used in the hashing of the parameters.
+	argon2Type = 2
+)
+
+// Argon2id hashes passwords with Argon2id (RFC 9106), version 19.
+// The PHC string has the form "$argon2id$v=19$m=<memory in KiB>,t=<iterations>,p=<parallelism>$<salt>$<hash>".
+// Hashing needs about Memory KiB of memory. The lanes are processed in parallel.
+//
+// Can be used concurrent.
+type Argon2id struct {
+	// Memory is the memory size in KiB. It must be at least 8 * Parallelism.
+	Memory int
+	// Iterations is the number of passes over the memory.
+	Iterations int
+	// Parallelism is the number of lanes.
+	Parallelism int
+	// SaltSize is the size of the salt in bytes.
+	SaltSize int
+	// KeySize is the size of the hash in bytes. It must be at least 4.
+	KeySize int
+}
+
+// Argon2idDefault returns the suggested default parameters for Argon2id (following the OWASP recommendation of 2023, using 19 MiB of memory).
+func Argon2idDefault() Argon2id {
+	return Argon2id{
+		Memory:      19 * 1024,
+		Iterations:  2,
+		Parallelism: 1,
+		SaltSize:    16,
+		KeySize:     32,
+	}
+}
+
+// ID returns the identifier of the algorithm. See Algorithm for more information.
+func (a Argon2id) ID() string {
+	return Argon2idID
+}
+
+// Hash returns the hash of password. See Algorithm for more information.
+func (a Argon2id) Hash(password []byte) (PHC, error) {
+	if !validArgon2Params(a.Memory, a.Iterations, a.Parallelism) {
+		return PHC{}, errors.New("invalid argon2id parameters")
+	}
+	if a.KeySize < argon2MinKeySize {
+		return PHC{}, errors.New("key size must be at least 4")
+	}
+	salt, err := newSalt(a.SaltSize)
+	if err != nil {
+		return PHC{}, err
+	}
+	return PHC{
+		ID:      Argon2idID,
+		Version: argon2Version,
+		Params: []Param{
+			{Name: "m", Value: strconv.Itoa(a.Memory)},
+			{Name: "t", Value: strconv.Itoa(a.Iterations)},
+			{Name: "p", Value: strconv.Itoa(a.Parallelism)},
+		},
+		Salt: salt,
+		Hash: argon2idKey(password, salt, nil, nil, a.Iterations, a.Memory, a.Parallelism, a.KeySize),
+	}, nil
+}
+
+// Key derives the key of password. See Algorithm for more information.
+func (a Argon2id) Key(password []byte, h PHC) ([]byte, error) {
+	m, t, p, err := argon2Params(h)
+	if err != nil {
+		return nil, err
+	}
+	if len(h.Hash) < argon2MinKeySize {
+		return nil, ErrInvalidHash
+	}
+	return argon2idKey(password, h.Salt, nil, nil, t, m, p, len(h.Hash)), nil
+}
+
+// NeedsRehash returns whether h was created with weaker parameters. See Algorithm for more information.
+func (a Argon2id) NeedsRehash(h PHC) bool {
+	m, t, p, err := argon2Params(h)
+	if err != nil {
+		return true
+	}
+	return m < a.Memory || t < a.Iterations || p < a.Parallelism || len(h.Salt) < a.SaltSize || len(h.Hash) < a.KeySize
+}
+
+// argon2Params returns the parameters of h. Only version 19 is supported.
+func argon2Params(h PHC) (m, t, p int, err error) {
+	if h.Version != argon2Version {
+		err = ErrInvalidHash
+		return
+	}
+	m, err = h.IntParam("m")
+	if err != nil {
+		return
+	}
+	t, err = h.IntParam("t")
+	if err != nil {
+		return
+	}
+	p, err = h.IntParam("p")
+	if err != nil {
+		return
+	}
+	if !validArgon2Params(m, t, p) {
+		err = ErrInvalidHash
+	}
+	return
+}
+
+// validArgon2Params returns whether the parameters are allowed by RFC 9106 and within the memory limit.
+func validArgon2Params(m, t, p int) bool {
+	if t < 1 || t > argon2MaxIterations || p < 1 || p > argon2MaxParallelism {
+		return false
+	}
+	return m >= 8*p && m <= argon2MaxMemory
+}
+
+// argon2Block is a block of 1024 bytes.
+type argon2Block [128]uint64
+
+// argon2idKey derives a key of length keySize from password and salt as defined in RFC 9106, section 3.
+// secret and ad are the optional secret value and associated data.
+func argon2idKey(password, salt, secret, ad []byte, t, m, p, keySize int) []byte {
+	h0 := argon2H0(password, salt, secret, ad, t, m, p, keySize)
+
+	// The memory is rounded down to a multiple of 4 * p blocks.
+	laneLength := m / (argon2SyncPoints * p) * argon2SyncPoints
+	b := make([]argon2Block, laneLength*p)
+
+	var buf [1024]byte
+	for lane := 0; lane < p; lane++ {
+		for i := 0; i < 2; i++ {
+			var le [8]byte
+			binary.LittleEndian.PutUint32(le[0:], uint32(i))
+			binary.LittleEndian.PutUint32(le[4:], uint32(lane))
+			copy(buf[:], argon2HashLong(1024, h0, le[:]))
+			for j := range b[lane*laneLength+i] {
+				b[lane*laneLength+i][j] = binary.LittleEndian.Uint64(buf[8*j:])
+			}
+		}
+	}
+
+	var wg sync.WaitGroup
+	for pass := 0; pass < t; pass++ {
+		for slice := 0; slice < argon2SyncPoints; slice++ {
+			wg.Add(p)
+			for lane := 0; lane < p; lane++ {
+				go func(pass, slice, lane int) {
+					argon2Segment(b, pass, slice, lane, t, p, laneLength)
+					wg.Done()
+				}(pass, slice, lane)
+			}
+			wg.Wait()
+		}
+	}
+
+	c := b[laneLength-1]
+	for lane := 1; lane < p; lane++ {
+		for j, v := range b[lane*laneLength+laneLength-1] {
+			c[j] ^= v
+		}
+	}
+	for j := range c {
+		binary.LittleEndian.PutUint64(buf[8*j:], c[j])
+	}
+	return argon2HashLong(keySize, buf[:])
+}
+
+// argon2H0 returns the initial hash H_0 (RFC 9106, section 3.2).
+func argon2H0(password, salt, secret, ad []byte, t, m, p, keySize int) []byte {
+	le := func(v int) []byte {
+		var b [4]byte
+		binary.LittleEndian.PutUint32(b[:], uint32(v))
+		return b[:]
+	}
+	return blake2bSum(64,
+		le(p), le(keySize), le(m), le(t), le(argon2Version), le(argon2Type),
+		le(len(password)), password,
+		le(len(salt)), salt,
+		le(len(secret)), secret,
+		le(len(ad)), ad,
+	)
+}
+
+// argon2HashLong is the variable-length hash function H' (RFC 9106, section 3.3).
+func argon2HashLong(size int, in ...[]byte) []byte {
+	var le [4]byte
+	binary.LittleEndian.PutUint32(le[:], uint32(size))
+	in = append([][]byte{le[:]}, in...)
+	if size <= 64 {
+		return blake2bSum(size, in...)
+	}
+
+	out := make([]byte, 0, size)
+	v := blake2bSum(64, in...)
+	for {
+		out = append(out, v[:32]...)
+		// The last hash has the remaining length, which might be less than 64 bytes.
+		if rest := size - len(out); rest <= 64 {
+			return append(out, blake2bSum(rest, v)...)
+		}
+		v = blake2bSum(64, v)
+	}
+}
+
+// argon2Segment fills the segment of the given lane and slice in the given pass (RFC 9106, section 3.4).
+func argon2Segment(b []argon2Block, pass, slice, lane, t, p, laneLength int) {
+	segmentLength := laneLength / argon2SyncPoints
+
+	// Argon2id uses data-independent addressing in the first half of the first pass.
+	independent := pass == 0 && slice < argon2SyncPoints/2
+	var address, input, zero argon2Block
+	if independent {
+		input[0] = uint64(pass)
+		input[1] = uint64(lane)
+		input[2] = uint64(slice)
+		input[3] = uint64(len(b))
+		input[4] = uint64(t)
+		input[5] = argon2Type
+	}
+
+	index := 0
+	if pass == 0 && slice == 0 {
+		// The first two blocks are already filled.
+		index = 2
+		if independent {
+			input[6]++
+			argon2Compress(&address, &zero, &input, false)
+			argon2Compress(&address, &zero, &address, false)
+		}
+	}
+
+	offset := lane*laneLength + slice*segmentLength + index
+	for ; index < segmentLength; index, offset = index+1, offset+1 {
+		prev := offset - 1
+		if index == 0 && slice == 0 {
+			prev += laneLength
+		}
+
+		var random uint64
+		if independent {
+			if index%len(address) == 0 {
+				input[6]++
+				argon2Compress(&address, &zero, &input, false)
+				argon2Compress(&address, &zero, &address, false)
+			}
+			random = address[index%len(address)]
+		} else {
+			random = b[prev][0]
+		}
+
+		ref := argon2Index(random, pass, slice, lane, index, p, laneLength, segmentLength)
+		// Version 19 XORs the new block into the old one after the first pass.
+		argon2Compress(&b[offset], &b[prev], &b[ref], pass > 0)
+	}
+}
+
+// argon2Index returns the index of the reference block (RFC 9106, section 3.4.1.2).
+func argon2Index(random uint64, pass, slice, lane, index, p, laneLength, segmentLength int) int {
+	refLane := int(random>>32) % p
+	if pass == 0 && slice == 0 {
+		refLane = lane
+	}
+
+	// Determine the blocks which can be referenced: start is the first block and area the number of blocks.
+	var start, area int
+	if pass == 0 {
+		area = slice * segmentLength
+		if refLane == lane {
+			area += index - 1
+		} else if index == 0 {
+			area--
+		}
+	} else {
+		start = (slice + 1) % argon2SyncPoints * segmentLength
+		area = laneLength - segmentLength
+		if refLane == lane {
+			area += index - 1
+		} else if index == 0 {
+			area--
+		}
+	}
+
+	x := random & 0xffffffff
+	x = x * x >> 32
+	x = uint64(area) * x >> 32
+	rel := area - 1 - int(x)
+	return refLane*laneLength + (start+rel)%laneLength
+}
+
+// argon2Compress applies the compression function G (RFC 9106, section 3.5) to x and y and stores the result in out.
+// If xor is true, the result is XORed into out.
+func argon2Compress(out, x, y *argon2Block, xor bool) {
+	var r, q argon2Block
+	for i := range r {
+		r[i] = x[i] ^ y[i]
+	}
+	q = r
+	for i := 0; i < len(q); i += 16 {
+		blamka(&q, i, i+1, i+2, i+3, i+4, i+5, i+6, i+7, i+8, i+9, i+10, i+11, i+12, i+13, i+14, i+15)
+	}
+	for i := 0; i < 16; i += 2 {
+		blamka(&q, i, i+1, i+16, i+17, i+32, i+33, i+48, i+49, i+64, i+65, i+80, i+81, i+96, i+97, i+112, i+113)
+	}
+	if xor {
+		for i := range q {
+			out[i] ^= q[i] ^ r[i]
+		}
+		return
+	}
+	for i := range q {
+		out[i] = q[i] ^ r[i]
+	}
+}
+
+// blamka applies the permutation P (RFC 9106, section 3.6) to the 16 words of b at the given indices.
+func blamka(b *argon2Block, i ...int) {
+	gb := func(a, c, d, e int) {
+		b[a] = b[a] + b[c] + 2*uint64(uint32(b[a]))*uint64(uint32(b[c]))
+		b[e] = bits.RotateLeft64(b[e]^b[a], -32)
+		b[d] = b[d] + b[e] + 2*uint64(uint32(b[d]))*uint64(uint32(b[e]))
+		b[c] = bits.RotateLeft64(b[c]^b[d], -24)
+		b[a] = b[a] + b[c] + 2*uint64(uint32(b[a]))*uint64(uint32(b[c]))
+		b[e] = bits.RotateLeft64(b[e]^b[a], -16)
+		b[d] = b[d] + b[e] + 2*uint64(uint32(b[d]))*uint64(uint32(b[e]))
+		b[c] = bits.RotateLeft64(b[c]^b[d], -63)
+	}
+	gb(i[0], i[4], i[8], i[12])
+	gb(i[1], i[5], i[9], i[13])
+	gb(i[2], i[6], i[10], i[14])
+	gb(i[3], i[7], i[11], i[15])
+	gb(i[0], i[5], i[10], i[15])
+	gb(i[1], i[6], i[11], i[12])
+	gb(i[2], i[7], i[8], i[13])
+	gb(i[3], i[4], i[9], i[14])
+}
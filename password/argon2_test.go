@@ -0,0 +1,106 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package password
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestBlake2b(t *testing.T) {
+	testcases := []struct {
+		in   []byte
+		size int
+		hash string
+	}{
+		{[]byte{}, 64, "786a02f742015903c6c6fd852552d272912f4740e15847618a86e217f71f5419d25e1031afee585313896444934eb04b903a685b1448b755d56f701afe9be2ce"},
+		{[]byte("abc"), 64, "ba80a53f981c4d0d6a2797b69f12f6e94c212f14685ac4b74b12bb6fdbffa2d17d87c5392aab792dc252d5de4533cc9518d38aa8dbf1925ab92386edd4009923"},
+		{bytes.Repeat([]byte("a"), 200), 32, "6b6e59aaf00eb730cf93de53560846722184bbd92f8368c21ffa95380c2f9fe6"},
+		{bytes.Repeat([]byte("x"), 128), 17, "797b4ef9cba92d3898915c647805fdca11"},
+	}
+
+	for _, tc := range testcases {
+		hash := blake2bSum(tc.size, tc.in)
+		if hex.EncodeToString(hash) != tc.hash {
+			t.Errorf("wrong hash for %d bytes (is: %x, should: %s)", len(tc.in), hash, tc.hash)
+		}
+	}
+}
+
+func TestArgon2idKey(t *testing.T) {
+	// Test vector from RFC 9106, section 5.3
+	key := argon2idKey(bytes.Repeat([]byte{1}, 32), bytes.Repeat([]byte{2}, 16), bytes.Repeat([]byte{3}, 8), bytes.Repeat([]byte{4}, 12), 3, 32, 4, 32)
+	should := "0d640df58d78766c08c037a34a8b53c9d01ef0452d75b65eb52520e96b01e659"
+	if hex.EncodeToString(key) != should {
+		t.Errorf("wrong key (is: %x, should: %s)", key, should)
+	}
+}
+
+func TestArgon2idVerify(t *testing.T) {
+	// Hash created by the reference implementation
+	err := Verify("password", "$argon2id$v=19$m=256,t=2,p=1$c29tZXNhbHQ$nf65EOgLrQMR/uIPnA4rEsF5h7TKyQwu9U1bMCHGi/4")
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+}
+
+func TestArgon2id(t *testing.T) {
+	a := Argon2id{Memory: 64, Iterations: 2, Parallelism: 2, SaltSize: 8, KeySize: 20}
+	h, err := a.Hash([]byte("password"))
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if h.ID != Argon2idID || h.Version != 19 || len(h.Salt) != 8 || len(h.Hash) != 20 {
+		t.Errorf("wrong hash: %s", h.String())
+	}
+	m, i, p, err := argon2Params(h)
+	if err != nil || m != 64 || i != 2 || p != 2 {
+		t.Errorf("wrong parameters: %s", h.String())
+	}
+	if a.NeedsRehash(h) {
+		t.Errorf("rehash needed for same parameters")
+	}
+	if !(Argon2id{Memory: 128, Iterations: 2, Parallelism: 2, SaltSize: 8, KeySize: 20}).NeedsRehash(h) {
+		t.Errorf("no rehash needed for more memory")
+	}
+
+	err = Verify("password", h.String())
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	err = Verify("wrong", h.String())
+	if err != ErrMismatch {
+		t.Errorf("wrong password not rejected (is: %v, should: %v)", err, ErrMismatch)
+	}
+
+	for _, a := range []Argon2id{{Memory: 15, Iterations: 1, Parallelism: 2, SaltSize: 8, KeySize: 20}, {Memory: 64, Iterations: 0, Parallelism: 1, SaltSize: 8, KeySize: 20}, {Memory: 64, Iterations: 1, Parallelism: 0, SaltSize: 8, KeySize: 20}, {Memory: 1<<20 + 1, Iterations: 1, Parallelism: 1, SaltSize: 8, KeySize: 20}, {Memory: 64, Iterations: 1, Parallelism: 1, SaltSize: 8, KeySize: 3}} {
+		_, err = a.Hash([]byte("password"))
+		if err == nil {
+			t.Errorf("no error for invalid parameters %+v", a)
+		}
+	}
+
+	for _, s := range []string{"$argon2id$m=64,t=2,p=2$c29tZXNhbHQ$nf65EOgLrQMR/uIPnA4rEsF5h7TKyQwu9U1bMCHGi/4", "$argon2id$v=16$m=64,t=2,p=2$c29tZXNhbHQ$nf65EOgLrQMR/uIPnA4rEsF5h7TKyQwu9U1bMCHGi/4", "$argon2id$v=19$m=64,t=2$c29tZXNhbHQ$nf65EOgLrQMR/uIPnA4rEsF5h7TKyQwu9U1bMCHGi/4", "$argon2id$v=19$m=64,t=2,p=2$c29tZXNhbHQ$bmY2"} {
+		err = Verify("password", s)
+		if err != ErrInvalidHash {
+			t.Errorf("invalid hash %s not rejected (is: %v, should: %v)", s, err, ErrInvalidHash)
+		}
+	}
+}
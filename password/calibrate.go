@@ -0,0 +1,130 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package password
+
+import (
+	"crypto/sha256"
+	"errors"
+	"time"
+)
+
+// calibrationMinTime is the minimal duration of a measurement used for extrapolation.
+const calibrationMinTime = 50 * time.Millisecond
+
+// CalibratePBKDF2 benchmarks PBKDF2 on the current machine and returns parameters so that hashing a password takes about target.
+// All other parameters are taken from PBKDF2Default. Calibration takes up to about twice the target.
+//
+// On slow machines, the result might be weaker than PBKDF2Default. You should check whether this is acceptable.
+func CalibratePBKDF2(target time.Duration) (PBKDF2, error) {
+	if target <= 0 {
+		return PBKDF2{}, errors.New("target must be positive")
+	}
+
+	p := PBKDF2Default()
+	password := []byte("calibration")
+	salt := make([]byte, p.SaltSize)
+
+	iterations := 1000
+	for {
+		start := time.Now()
+		pbkdf2Key(sha256.New, password, salt, iterations, p.KeySize)
+		d := time.Since(start)
+
+		if d >= calibrationMinTime || d >= target || iterations >= pbkdf2MaxIterations/2 {
+			i := float64(iterations) * float64(target) / float64(d)
+			switch {
+			case i < 1:
+				p.Iterations = 1
+			case i > pbkdf2MaxIterations:
+				p.Iterations = pbkdf2MaxIterations
+			default:
+				p.Iterations = int(i)
+			}
+			return p, nil
+		}
+		iterations *= 2
+	}
+}
+
+// CalibrateScrypt benchmarks scrypt on the current machine and returns the highest cost parameter so that hashing a password takes at most about target and uses at most maxMemory bytes.
+// All other parameters are taken from ScryptDefault. Calibration takes up to about twice the target.
+//
+// On slow machines, the result might be weaker than ScryptDefault. You should check whether this is acceptable.
+func CalibrateScrypt(target time.Duration, maxMemory int) (Scrypt, error) {
+	if target <= 0 {
+		return Scrypt{}, errors.New("target must be positive")
+	}
+
+	s := ScryptDefault()
+	memory := func(ln int) int {
+		return 128 * s.R << uint(ln)
+	}
+	if memory(1) > maxMemory {
+		return Scrypt{}, errors.New("maxMemory too small")
+	}
+
+	password := []byte("calibration")
+	salt := make([]byte, s.SaltSize)
+
+	s.LogN = 1
+	for validScryptParams(s.LogN+1, s.R, s.P) && memory(s.LogN+1) <= maxMemory {
+		start := time.Now()
+		scryptKey(password, salt, s.LogN, s.R, s.P, s.KeySize)
+		d := time.Since(start)
+
+		// Doubling N doubles the time.
+		if 2*d > target {
+			break
+		}
+		s.LogN++
+	}
+	return s, nil
+}
+
+// CalibrateArgon2id benchmarks Argon2id on the current machine and returns the number of iterations so that hashing a password takes about target.
+// All other parameters are taken from Argon2idDefault. Calibration takes up to about twice the target.
+//
+// On slow machines, the result might be weaker than Argon2idDefault. You should check whether this is acceptable.
+func CalibrateArgon2id(target time.Duration) (Argon2id, error) {
+	if target <= 0 {
+		return Argon2id{}, errors.New("target must be positive")
+	}
+
+	a := Argon2idDefault()
+	password := []byte("calibration")
+	salt := make([]byte, a.SaltSize)
+
+	iterations := 1
+	for {
+		start := time.Now()
+		argon2idKey(password, salt, nil, nil, iterations, a.Memory, a.Parallelism, a.KeySize)
+		d := time.Since(start)
+
+		if d >= calibrationMinTime || d >= target || iterations >= argon2MaxIterations/2 {
+			i := float64(iterations) * float64(target) / float64(d)
+			switch {
+			case i < 1:
+				a.Iterations = 1
+			case i > argon2MaxIterations:
+				a.Iterations = argon2MaxIterations
+			default:
+				a.Iterations = int(i)
+			}
+			return a, nil
+		}
+		iterations *= 2
+	}
+}
@@ -0,0 +1,89 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package password
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCalibratePBKDF2(t *testing.T) {
+	p, err := CalibratePBKDF2(20 * time.Millisecond)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if p.Iterations < 1 {
+		t.Errorf("invalid iterations: %d", p.Iterations)
+	}
+	if p.SaltSize != PBKDF2Default().SaltSize || p.KeySize != PBKDF2Default().KeySize {
+		t.Errorf("parameters changed: %+v", p)
+	}
+
+	_, err = CalibratePBKDF2(0)
+	if err == nil {
+		t.Error("no error for invalid target")
+	}
+}
+
+func TestCalibrateScrypt(t *testing.T) {
+	s, err := CalibrateScrypt(20*time.Millisecond, 1<<20)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if s.LogN < 1 || 128*s.R<<uint(s.LogN) > 1<<20 {
+		t.Errorf("invalid cost: %d", s.LogN)
+	}
+
+	// Memory limit
+	s, err = CalibrateScrypt(time.Hour, 128*8*16)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if s.LogN != 4 {
+		t.Errorf("memory limit not respected (is: %d, should: %d)", s.LogN, 4)
+	}
+
+	_, err = CalibrateScrypt(0, 1<<20)
+	if err == nil {
+		t.Error("no error for invalid target")
+	}
+	_, err = CalibrateScrypt(time.Second, 1)
+	if err == nil {
+		t.Error("no error for too small memory")
+	}
+}
+
+func TestCalibrateArgon2id(t *testing.T) {
+	a, err := CalibrateArgon2id(20 * time.Millisecond)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if a.Iterations < 1 {
+		t.Errorf("invalid iterations: %d", a.Iterations)
+	}
+	if a.Memory != Argon2idDefault().Memory || a.SaltSize != Argon2idDefault().SaltSize || a.KeySize != Argon2idDefault().KeySize {
+		t.Errorf("parameters changed: %+v", a)
+	}
+
+	_, err = CalibrateArgon2id(0)
+	if err == nil {
+		t.Error("no error for invalid target")
+	}
+}
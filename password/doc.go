@@ -0,0 +1,24 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package password contains methods to hash and verify passwords.
+// Hashes are encoded in the PHC string format (e.g. "$pbkdf2-sha256$i=600000$salt$hash"), so that the algorithm and its parameters are stored together with the hash.
+//
+// PBKDF2-SHA256, scrypt and Argon2id are included. Other algorithms can be added through Register.
+// Verification is done in constant time.
+//
+// Recommended parameters increase over time. NeedsRehash reports hashes created with weaker parameters, so they can be replaced after the next successful login.
+// The cost of the algorithms can be tuned to the current hardware with CalibratePBKDF2, CalibrateScrypt and CalibrateArgon2id.
+package password
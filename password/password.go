@@ -0,0 +1,138 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"sync"
+)
+
+var (
+	// ErrMismatch is returned if a password does not match a hash.
+	ErrMismatch = errors.New("password: mismatch")
+
+	// ErrUnknownAlgorithm is returned if the algorithm of a hash is not registered.
+	ErrUnknownAlgorithm = errors.New("password: unknown algorithm")
+)
+
+// Algorithm is a password hashing algorithm together with its parameters for new hashes.
+type Algorithm interface {
+	// ID returns the identifier of the algorithm in the PHC string format.
+	ID() string
+	// Hash returns the hash of password using a new random salt.
+	Hash(password []byte) (PHC, error)
+	// Key derives the key of password using the parameters and salt of h. The key must have the length of h.Hash.
+	// Only the parameters of h are used, not the ones of the algorithm. ErrInvalidHash is returned if the parameters are invalid.
+	Key(password []byte, h PHC) ([]byte, error)
+	// NeedsRehash returns whether h was created with weaker parameters than the ones of the algorithm.
+	NeedsRehash(h PHC) bool
+}
+
+var (
+	algorithms      = make(map[string]Algorithm)
+	algorithmsMutex sync.RWMutex
+)
+
+func init() {
+	Register(PBKDF2Default())
+	Register(ScryptDefault())
+	Register(Argon2idDefault())
+}
+
+// Register makes an algorithm available to Verify. An already registered algorithm with the same id is replaced.
+// PBKDF2, Scrypt and Argon2id are registered by default.
+//
+// Can be used concurrent.
+func Register(a Algorithm) {
+	algorithmsMutex.Lock()
+	defer algorithmsMutex.Unlock()
+	algorithms[a.ID()] = a
+}
+
+// Hash returns the hash of password in the PHC string format using the given algorithm.
+//
+// Can be used concurrent.
+func Hash(a Algorithm, password string) (string, error) {
+	h, err := a.Hash([]byte(password))
+	if err != nil {
+		return "", err
+	}
+	return h.String(), nil
+}
+
+// Verify checks whether password matches hash. The comparison is done in constant time.
+// The algorithm is determined by the hash, so hashes created with other parameters or algorithms (e.g. before an upgrade) can still be verified as long as the algorithm is registered.
+//
+// nil is returned if the password matches, ErrMismatch if it does not. ErrInvalidHash or ErrUnknownAlgorithm is returned if the hash can not be used.
+//
+// Can be used concurrent.
+func Verify(password, hash string) error {
+	h, err := ParsePHC(hash)
+	if err != nil {
+		return err
+	}
+	if len(h.Salt) == 0 || len(h.Hash) == 0 {
+		return ErrInvalidHash
+	}
+
+	algorithmsMutex.RLock()
+	a, ok := algorithms[h.ID]
+	algorithmsMutex.RUnlock()
+	if !ok {
+		return ErrUnknownAlgorithm
+	}
+
+	key, err := a.Key([]byte(password), h)
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare(key, h.Hash) != 1 {
+		return ErrMismatch
+	}
+	return nil
+}
+
+// NeedsRehash returns whether hash should be replaced by a new hash created with the given algorithm.
+// This is the case if hash uses another algorithm or weaker parameters. Invalid hashes always need a rehash.
+//
+// A rehash can only be done after the password was verified, usually during login.
+//
+// Can be used concurrent.
+func NeedsRehash(a Algorithm, hash string) bool {
+	h, err := ParsePHC(hash)
+	if err != nil {
+		return true
+	}
+	if h.ID != a.ID() {
+		return true
+	}
+	return a.NeedsRehash(h)
+}
+
+// newSalt returns a new random salt.
+func newSalt(size int) ([]byte, error) {
+	if size < 1 {
+		return nil, errors.New("salt size must be positive")
+	}
+	salt := make([]byte, size)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return nil, err
+	}
+	return salt, nil
+}
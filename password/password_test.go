@@ -0,0 +1,190 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package password
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// testAlgorithm is a fast algorithm for tests. The key is password reversed.
+type testAlgorithm struct {
+	cost int
+}
+
+func (a testAlgorithm) ID() string {
+	return "test"
+}
+
+func (a testAlgorithm) Hash(password []byte) (PHC, error) {
+	h := PHC{ID: "test", Version: 1, Params: []Param{{"c", "1"}}, Salt: []byte("salt"), Hash: make([]byte, len(password))}
+	key, err := a.Key(password, h)
+	h.Hash = key
+	return h, err
+}
+
+func (a testAlgorithm) Key(password []byte, h PHC) ([]byte, error) {
+	key := make([]byte, len(password))
+	for i := range password {
+		key[len(key)-1-i] = password[i]
+	}
+	if len(key) != len(h.Hash) {
+		return make([]byte, len(h.Hash)), nil
+	}
+	return key, nil
+}
+
+func (a testAlgorithm) NeedsRehash(h PHC) bool {
+	c, err := h.IntParam("c")
+	return err != nil || c < a.cost
+}
+
+func TestHashVerify(t *testing.T) {
+	algorithms := []Algorithm{
+		PBKDF2{Iterations: 100, SaltSize: 16, KeySize: 32},
+		Scrypt{LogN: 4, R: 8, P: 1, SaltSize: 16, KeySize: 32},
+	}
+
+	for _, a := range algorithms {
+		t.Run(a.ID(), func(t *testing.T) {
+			h, err := Hash(a, "password")
+			if err != nil {
+				t.Logf("error occured: %s", err.Error())
+				t.FailNow()
+			}
+			if !strings.HasPrefix(h, "$"+a.ID()+"$") {
+				t.Errorf("wrong prefix: %s", h)
+			}
+
+			err = Verify("password", h)
+			if err != nil {
+				t.Errorf("can not verify password: %s", err.Error())
+			}
+			err = Verify("Password", h)
+			if !errors.Is(err, ErrMismatch) {
+				t.Errorf("wrong error for wrong password (is: %v, should: %v)", err, ErrMismatch)
+			}
+			err = Verify("", h)
+			if !errors.Is(err, ErrMismatch) {
+				t.Errorf("wrong error for empty password (is: %v, should: %v)", err, ErrMismatch)
+			}
+
+			// Salts must be random
+			h2, err := Hash(a, "password")
+			if err != nil {
+				t.Logf("error occured: %s", err.Error())
+				t.FailNow()
+			}
+			if h == h2 {
+				t.Errorf("hash not salted: %s", h)
+			}
+		})
+	}
+}
+
+func TestVerifyInvalid(t *testing.T) {
+	testcases := []struct {
+		hash string
+		err  error
+	}{
+		{"", ErrInvalidHash},
+		{"password", ErrInvalidHash},
+		{"$unknown$c2FsdA$aGFzaA", ErrUnknownAlgorithm},
+		{"$pbkdf2-sha256$i=1000$c2FsdA", ErrInvalidHash},
+		{"$pbkdf2-sha256$i=0$c2FsdA$aGFzaA", ErrInvalidHash},
+		{"$pbkdf2-sha256$c2FsdA$aGFzaA", ErrInvalidHash},
+		{"$scrypt$ln=4,r=8$c2FsdA$aGFzaA", ErrInvalidHash},
+		{"$scrypt$ln=40,r=8,p=1$c2FsdA$aGFzaA", ErrInvalidHash},
+		{"$scrypt$ln=20,r=1024,p=1$c2FsdA$aGFzaA", ErrInvalidHash},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.hash, func(t *testing.T) {
+			err := Verify("password", tc.hash)
+			if !errors.Is(err, tc.err) {
+				t.Errorf("wrong error (is: %v, should: %v)", err, tc.err)
+			}
+		})
+	}
+}
+
+func TestRegister(t *testing.T) {
+	h, err := Hash(testAlgorithm{}, "password")
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+
+	err = Verify("password", h)
+	if !errors.Is(err, ErrUnknownAlgorithm) {
+		t.Errorf("wrong error for unregistered algorithm (is: %v, should: %v)", err, ErrUnknownAlgorithm)
+	}
+
+	Register(testAlgorithm{})
+	defer func() {
+		algorithmsMutex.Lock()
+		delete(algorithms, "test")
+		algorithmsMutex.Unlock()
+	}()
+
+	err = Verify("password", h)
+	if err != nil {
+		t.Errorf("can not verify password: %s", err.Error())
+	}
+	err = Verify("wrong", h)
+	if !errors.Is(err, ErrMismatch) {
+		t.Errorf("wrong error for wrong password (is: %v, should: %v)", err, ErrMismatch)
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	weak := PBKDF2{Iterations: 100, SaltSize: 16, KeySize: 32}
+	strong := PBKDF2{Iterations: 200, SaltSize: 16, KeySize: 32}
+
+	h, err := Hash(weak, "password")
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if NeedsRehash(weak, h) {
+		t.Error("rehash needed for same parameters")
+	}
+	if !NeedsRehash(strong, h) {
+		t.Error("no rehash needed for stronger parameters")
+	}
+	if !NeedsRehash(PBKDF2{Iterations: 100, SaltSize: 32, KeySize: 32}, h) {
+		t.Error("no rehash needed for larger salt")
+	}
+	if !NeedsRehash(Scrypt{LogN: 4, R: 8, P: 1, SaltSize: 16, KeySize: 32}, h) {
+		t.Error("no rehash needed for other algorithm")
+	}
+	if !NeedsRehash(weak, "invalid") {
+		t.Error("no rehash needed for invalid hash")
+	}
+
+	h, err = Hash(Scrypt{LogN: 4, R: 8, P: 1, SaltSize: 16, KeySize: 32}, "password")
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if NeedsRehash(Scrypt{LogN: 4, R: 8, P: 1, SaltSize: 16, KeySize: 32}, h) {
+		t.Error("rehash needed for same scrypt parameters")
+	}
+	if !NeedsRehash(Scrypt{LogN: 5, R: 8, P: 1, SaltSize: 16, KeySize: 32}, h) {
+		t.Error("no rehash needed for higher scrypt cost")
+	}
+}
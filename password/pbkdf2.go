@@ -0,0 +1,132 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package password
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"strconv"
+)
+
+const (
+	// PBKDF2ID is the identifier of PBKDF2-SHA256 in the PHC string format.
+	PBKDF2ID = "pbkdf2-sha256"
+
+	// pbkdf2MaxIterations limits the iterations accepted from a hash.
+	pbkdf2MaxIterations = 1 << 30
+)
+
+// PBKDF2 hashes passwords with PBKDF2-HMAC-SHA256 (RFC 8018).
+// The PHC string has the form "$pbkdf2-sha256$i=<iterations>$<salt>$<hash>".
+//
+// Can be used concurrent.
+type PBKDF2 struct {
+	// Iterations is the number of iterations.
+	Iterations int
+	// SaltSize is the size of the salt in bytes.
+	SaltSize int
+	// KeySize is the size of the hash in bytes.
+	KeySize int
+}
+
+// PBKDF2Default returns the suggested default parameters for PBKDF2 (following the OWASP recommendation of 2023).
+func PBKDF2Default() PBKDF2 {
+	return PBKDF2{
+		Iterations: 600000,
+		SaltSize:   16,
+		KeySize:    32,
+	}
+}
+
+// ID returns the identifier of the algorithm. See Algorithm for more information.
+func (p PBKDF2) ID() string {
+	return PBKDF2ID
+}
+
+// Hash returns the hash of password. See Algorithm for more information.
+func (p PBKDF2) Hash(password []byte) (PHC, error) {
+	if p.Iterations < 1 || p.Iterations > pbkdf2MaxIterations {
+		return PHC{}, errors.New("invalid number of iterations")
+	}
+	if p.KeySize < 1 {
+		return PHC{}, errors.New("key size must be positive")
+	}
+	salt, err := newSalt(p.SaltSize)
+	if err != nil {
+		return PHC{}, err
+	}
+	return PHC{
+		ID:      PBKDF2ID,
+		Version: -1,
+		Params:  []Param{{Name: "i", Value: strconv.Itoa(p.Iterations)}},
+		Salt:    salt,
+		Hash:    pbkdf2Key(sha256.New, password, salt, p.Iterations, p.KeySize),
+	}, nil
+}
+
+// Key derives the key of password. See Algorithm for more information.
+func (p PBKDF2) Key(password []byte, h PHC) ([]byte, error) {
+	i, err := h.IntParam("i")
+	if err != nil {
+		return nil, err
+	}
+	if i > pbkdf2MaxIterations || len(h.Hash) == 0 {
+		return nil, ErrInvalidHash
+	}
+	return pbkdf2Key(sha256.New, password, h.Salt, i, len(h.Hash)), nil
+}
+
+// NeedsRehash returns whether h was created with weaker parameters. See Algorithm for more information.
+func (p PBKDF2) NeedsRehash(h PHC) bool {
+	i, err := h.IntParam("i")
+	if err != nil {
+		return true
+	}
+	return i < p.Iterations || len(h.Salt) < p.SaltSize || len(h.Hash) < p.KeySize
+}
+
+// pbkdf2Key derives a key of length keySize from password and salt as defined in RFC 8018, section 5.2.
+func pbkdf2Key(h func() hash.Hash, password, salt []byte, iterations, keySize int) []byte {
+	prf := hmac.New(h, password)
+	hashSize := prf.Size()
+	blocks := (keySize + hashSize - 1) / hashSize
+
+	key := make([]byte, 0, blocks*hashSize)
+	u := make([]byte, hashSize)
+	var index [4]byte
+	for block := 1; block <= blocks; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		binary.BigEndian.PutUint32(index[:], uint32(block))
+		prf.Write(index[:])
+		key = prf.Sum(key)
+
+		t := key[len(key)-hashSize:]
+		copy(u, t)
+		for n := 1; n < iterations; n++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for x := range u {
+				t[x] ^= u[x]
+			}
+		}
+	}
+	return key[:keySize]
+}
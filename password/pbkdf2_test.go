@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package password
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+func TestPBKDF2Key(t *testing.T) {
+	// Test vectors from RFC 7914, section 11
+	testcases := []struct {
+		password   string
+		salt       string
+		iterations int
+		key        string
+	}{
+		{"passwd", "salt", 1, "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783"},
+		{"Password", "NaCl", 80000, "4ddcd8f60b98be21830cee5ef22701f9641a4418d04c0414aeff08876b34ab56a1d425a1225833549adb841b51c9b3176a272bdebba1d078478f62b397f33c8d"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.password, func(t *testing.T) {
+			key := pbkdf2Key(sha256.New, []byte(tc.password), []byte(tc.salt), tc.iterations, 64)
+			if hex.EncodeToString(key) != tc.key {
+				t.Errorf("wrong key (is: %x, should: %s)", key, tc.key)
+			}
+
+			// Shorter keys are prefixes
+			key = pbkdf2Key(sha256.New, []byte(tc.password), []byte(tc.salt), tc.iterations, 20)
+			if hex.EncodeToString(key) != tc.key[:40] {
+				t.Errorf("wrong short key (is: %x, should: %s)", key, tc.key[:40])
+			}
+		})
+	}
+}
+
+func TestPBKDF2(t *testing.T) {
+	p := PBKDF2{Iterations: 1000, SaltSize: 8, KeySize: 20}
+	h, err := p.Hash([]byte("password"))
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if h.ID != PBKDF2ID || len(h.Salt) != 8 || len(h.Hash) != 20 {
+		t.Errorf("wrong hash: %s", h.String())
+	}
+	if v, _ := h.Param("i"); v != "1000" {
+		t.Errorf("wrong iterations (is: %s, should: %s)", v, "1000")
+	}
+
+	for _, p := range []PBKDF2{{Iterations: 0, SaltSize: 8, KeySize: 20}, {Iterations: 1000, SaltSize: 0, KeySize: 20}, {Iterations: 1000, SaltSize: 8, KeySize: 0}} {
+		_, err = p.Hash([]byte("password"))
+		if err == nil {
+			t.Errorf("no error for invalid parameters %+v", p)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package password
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidHash is returned if a hash is not a valid PHC string.
+var ErrInvalidHash = errors.New("password: invalid hash")
+
+// b64 is the base64 encoding used by the PHC string format.
+var b64 = base64.RawStdEncoding
+
+// Param is a parameter of a PHC string.
+type Param struct {
+	Name  string
+	Value string
+}
+
+// PHC is a decoded hash in the PHC string format:
+//
+//	$<id>[$v=<version>][$<param>=<value>(,<param>=<value>)*][$<salt>[$<hash>]]
+type PHC struct {
+	// ID identifies the algorithm.
+	ID string
+	// Version is the version of the algorithm. It is -1 if the string contains no version.
+	Version int
+	// Params contains the parameters in their order.
+	Params []Param
+	// Salt contains the salt.
+	Salt []byte
+	// Hash contains the hash.
+	Hash []byte
+}
+
+// ParsePHC decodes a hash in the PHC string format.
+// ErrInvalidHash is returned if s is malformed.
+func ParsePHC(s string) (PHC, error) {
+	p := PHC{Version: -1}
+
+	parts := strings.Split(s, "$")
+	if len(parts) < 2 || parts[0] != "" || !validName(parts[1]) {
+		return PHC{}, ErrInvalidHash
+	}
+	p.ID = parts[1]
+	parts = parts[2:]
+
+	if len(parts) > 0 && strings.HasPrefix(parts[0], "v=") {
+		v, err := strconv.Atoi(parts[0][2:])
+		if err != nil || v < 0 {
+			return PHC{}, ErrInvalidHash
+		}
+		p.Version = v
+		parts = parts[1:]
+	}
+
+	if len(parts) > 0 && strings.Contains(parts[0], "=") {
+		for _, param := range strings.Split(parts[0], ",") {
+			kv := strings.SplitN(param, "=", 2)
+			if len(kv) != 2 || !validName(kv[0]) || kv[1] == "" {
+				return PHC{}, ErrInvalidHash
+			}
+			p.Params = append(p.Params, Param{Name: kv[0], Value: kv[1]})
+		}
+		parts = parts[1:]
+	}
+
+	if len(parts) > 2 {
+		return PHC{}, ErrInvalidHash
+	}
+	var err error
+	if len(parts) > 0 {
+		p.Salt, err = b64.DecodeString(parts[0])
+		if err != nil {
+			return PHC{}, ErrInvalidHash
+		}
+	}
+	if len(parts) > 1 {
+		p.Hash, err = b64.DecodeString(parts[1])
+		if err != nil {
+			return PHC{}, ErrInvalidHash
+		}
+	}
+	return p, nil
+}
+
+// String returns the PHC string of p.
+func (p PHC) String() string {
+	var sb strings.Builder
+	sb.WriteString("$")
+	sb.WriteString(p.ID)
+	if p.Version >= 0 {
+		sb.WriteString("$v=")
+		sb.WriteString(strconv.Itoa(p.Version))
+	}
+	if len(p.Params) != 0 {
+		sb.WriteString("$")
+		for i := range p.Params {
+			if i != 0 {
+				sb.WriteString(",")
+			}
+			sb.WriteString(p.Params[i].Name)
+			sb.WriteString("=")
+			sb.WriteString(p.Params[i].Value)
+		}
+	}
+	if p.Salt != nil || p.Hash != nil {
+		sb.WriteString("$")
+		sb.WriteString(b64.EncodeToString(p.Salt))
+	}
+	if p.Hash != nil {
+		sb.WriteString("$")
+		sb.WriteString(b64.EncodeToString(p.Hash))
+	}
+	return sb.String()
+}
+
+// Param returns the value of the parameter with the given name.
+func (p PHC) Param(name string) (string, bool) {
+	for i := range p.Params {
+		if p.Params[i].Name == name {
+			return p.Params[i].Value, true
+		}
+	}
+	return "", false
+}
+
+// IntParam returns the value of the parameter with the given name as a positive integer.
+// ErrInvalidHash is returned if the parameter is missing or not a positive integer.
+func (p PHC) IntParam(name string) (int, error) {
+	v, ok := p.Param(name)
+	if !ok {
+		return 0, ErrInvalidHash
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil || i < 1 {
+		return 0, ErrInvalidHash
+	}
+	return i, nil
+}
+
+// validName returns whether s is a valid algorithm or parameter name.
+func validName(s string) bool {
+	if s == "" || len(s) > 32 {
+		return false
+	}
+	for _, c := range s {
+		if !(c >= 'a' && c <= 'z') && !(c >= '0' && c <= '9') && c != '-' {
+			return false
+		}
+	}
+	return true
+}
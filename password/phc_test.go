@@ -0,0 +1,111 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package password
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestParsePHC(t *testing.T) {
+	testcases := []struct {
+		s       string
+		id      string
+		version int
+		params  []Param
+		salt    []byte
+		hash    []byte
+	}{
+		{"$pbkdf2-sha256$i=1000$c2FsdA$aGFzaA", "pbkdf2-sha256", -1, []Param{{"i", "1000"}}, []byte("salt"), []byte("hash")},
+		{"$argon2id$v=19$m=65536,t=2,p=1$c2FsdA$aGFzaA", "argon2id", 19, []Param{{"m", "65536"}, {"t", "2"}, {"p", "1"}}, []byte("salt"), []byte("hash")},
+		{"$test", "test", -1, nil, nil, nil},
+		{"$test$c2FsdA", "test", -1, nil, []byte("salt"), nil},
+		{"$test$v=1$c2FsdA", "test", 1, nil, []byte("salt"), nil},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.s, func(t *testing.T) {
+			p, err := ParsePHC(tc.s)
+			if err != nil {
+				t.Logf("error occured: %s", err.Error())
+				t.FailNow()
+			}
+			if p.ID != tc.id {
+				t.Errorf("wrong id (is: %s, should: %s)", p.ID, tc.id)
+			}
+			if p.Version != tc.version {
+				t.Errorf("wrong version (is: %d, should: %d)", p.Version, tc.version)
+			}
+			if len(p.Params) != len(tc.params) {
+				t.Fatalf("wrong number of params (is: %d, should: %d)", len(p.Params), len(tc.params))
+			}
+			for i := range p.Params {
+				if p.Params[i] != tc.params[i] {
+					t.Errorf("wrong param %d (is: %v, should: %v)", i, p.Params[i], tc.params[i])
+				}
+			}
+			if !bytes.Equal(p.Salt, tc.salt) {
+				t.Errorf("wrong salt (is: %v, should: %v)", p.Salt, tc.salt)
+			}
+			if !bytes.Equal(p.Hash, tc.hash) {
+				t.Errorf("wrong hash (is: %v, should: %v)", p.Hash, tc.hash)
+			}
+			if p.String() != tc.s {
+				t.Errorf("wrong string (is: %s, should: %s)", p.String(), tc.s)
+			}
+		})
+	}
+}
+
+func TestParsePHCInvalid(t *testing.T) {
+	testcases := []string{
+		"",
+		"pbkdf2-sha256$i=1000$c2FsdA$aGFzaA",
+		"$",
+		"$PBKDF2$i=1000$c2FsdA$aGFzaA",
+		"$pbkdf2-sha256$i=$c2FsdA$aGFzaA",
+		"$pbkdf2-sha256$i=1000,$c2FsdA$aGFzaA",
+		"$pbkdf2-sha256$i=1000$c2FsdA$aGFzaA$aGFzaA",
+		"$pbkdf2-sha256$i=1000$c2FsdA==$aGFzaA",
+		"$pbkdf2-sha256$i=1000$c2FsdA$!",
+		"$test$v=a",
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc, func(t *testing.T) {
+			_, err := ParsePHC(tc)
+			if !errors.Is(err, ErrInvalidHash) {
+				t.Errorf("wrong error (is: %v, should: %v)", err, ErrInvalidHash)
+			}
+		})
+	}
+}
+
+func TestPHCIntParam(t *testing.T) {
+	p := PHC{Params: []Param{{"a", "1"}, {"b", "0"}, {"c", "x"}}}
+
+	i, err := p.IntParam("a")
+	if err != nil || i != 1 {
+		t.Errorf("wrong value (is: %d, should: %d)", i, 1)
+	}
+	for _, name := range []string{"b", "c", "d"} {
+		_, err = p.IntParam(name)
+		if !errors.Is(err, ErrInvalidHash) {
+			t.Errorf("%s: wrong error (is: %v, should: %v)", name, err, ErrInvalidHash)
+		}
+	}
+}
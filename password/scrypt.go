@@ -0,0 +1,244 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package password
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"math/bits"
+	"strconv"
+)
+
+const (
+	// ScryptID is the identifier of scrypt in the PHC string format.
+	ScryptID = "scrypt"
+
+	// scryptMaxMemory limits the memory (128 * r * 2^ln bytes) accepted from a hash.
+	scryptMaxMemory = 1 << 30
+)
+
+// Scrypt hashes passwords with scrypt (RFC 7914).
+// The PHC string has the form "$scrypt$ln=<log2 of N>,r=<block size>,p=<parallelisation>$<salt>$<hash>".
+// Hashing needs 128 * R * 2^LogN bytes of memory.
+//
+// Can be used concurrent.
+type Scrypt struct {
+	// LogN is the base 2 logarithm of the cost parameter N.
+	LogN int
+	// R is the block size.
+	R int
+	// P is the parallelisation parameter.
+	P int
+	// SaltSize is the size of the salt in bytes.
+	SaltSize int
+	// KeySize is the size of the hash in bytes.
+	KeySize int
+}
+
+// ScryptDefault returns the suggested default parameters for scrypt (following the OWASP recommendation of 2023, using 32 MiB of memory).
+func ScryptDefault() Scrypt {
+	return Scrypt{
+		LogN:     15,
+		R:        8,
+		P:        3,
+		SaltSize: 16,
+		KeySize:  32,
+	}
+}
+
+// ID returns the identifier of the algorithm. See Algorithm for more information.
+func (s Scrypt) ID() string {
+	return ScryptID
+}
+
+// Hash returns the hash of password. See Algorithm for more information.
+func (s Scrypt) Hash(password []byte) (PHC, error) {
+	if !validScryptParams(s.LogN, s.R, s.P) {
+		return PHC{}, errors.New("invalid scrypt parameters")
+	}
+	if s.KeySize < 1 {
+		return PHC{}, errors.New("key size must be positive")
+	}
+	salt, err := newSalt(s.SaltSize)
+	if err != nil {
+		return PHC{}, err
+	}
+	return PHC{
+		ID:      ScryptID,
+		Version: -1,
+		Params: []Param{
+			{Name: "ln", Value: strconv.Itoa(s.LogN)},
+			{Name: "r", Value: strconv.Itoa(s.R)},
+			{Name: "p", Value: strconv.Itoa(s.P)},
+		},
+		Salt: salt,
+		Hash: scryptKey(password, salt, s.LogN, s.R, s.P, s.KeySize),
+	}, nil
+}
+
+// Key derives the key of password. See Algorithm for more information.
+func (s Scrypt) Key(password []byte, h PHC) ([]byte, error) {
+	ln, r, p, err := scryptParams(h)
+	if err != nil {
+		return nil, err
+	}
+	if len(h.Hash) == 0 {
+		return nil, ErrInvalidHash
+	}
+	return scryptKey(password, h.Salt, ln, r, p, len(h.Hash)), nil
+}
+
+// NeedsRehash returns whether h was created with weaker parameters. See Algorithm for more information.
+func (s Scrypt) NeedsRehash(h PHC) bool {
+	ln, r, p, err := scryptParams(h)
+	if err != nil {
+		return true
+	}
+	return ln < s.LogN || r < s.R || p < s.P || len(h.Salt) < s.SaltSize || len(h.Hash) < s.KeySize
+}
+
+// scryptParams returns the parameters of h.
+func scryptParams(h PHC) (ln, r, p int, err error) {
+	ln, err = h.IntParam("ln")
+	if err != nil {
+		return
+	}
+	r, err = h.IntParam("r")
+	if err != nil {
+		return
+	}
+	p, err = h.IntParam("p")
+	if err != nil {
+		return
+	}
+	if !validScryptParams(ln, r, p) {
+		err = ErrInvalidHash
+	}
+	return
+}
+
+// validScryptParams returns whether the parameters are allowed by RFC 7914 and within the memory limit.
+func validScryptParams(ln, r, p int) bool {
+	if ln < 1 || ln > 30 || r < 1 || p < 1 {
+		return false
+	}
+	if uint64(r)*uint64(p) >= 1<<30 {
+		return false
+	}
+	return uint64(128)*uint64(r)<<uint(ln) <= scryptMaxMemory
+}
+
+// scryptKey derives a key of length keySize from password and salt as defined in RFC 7914, section 6.
+func scryptKey(password, salt []byte, ln, r, p, keySize int) []byte {
+	n := 1 << uint(ln)
+	b := pbkdf2Key(sha256.New, password, salt, 1, p*128*r)
+
+	xy := make([]uint32, 64*r)
+	v := make([]uint32, 32*n*r)
+	for i := 0; i < p; i++ {
+		roMix(b[i*128*r:], r, n, v, xy)
+	}
+	return pbkdf2Key(sha256.New, password, b, 1, keySize)
+}
+
+// roMix applies scryptROMix (RFC 7914, section 5) to the block b in place. v and xy are working memory.
+func roMix(b []byte, r, n int, v, xy []uint32) {
+	var tmp [16]uint32
+	size := 32 * r
+	x := xy[:size]
+	y := xy[size:]
+
+	for i := range x {
+		x[i] = binary.LittleEndian.Uint32(b[4*i:])
+	}
+	for i := 0; i < n; i += 2 {
+		copy(v[i*size:], x)
+		blockMix(&tmp, x, y, r)
+		copy(v[(i+1)*size:], y)
+		blockMix(&tmp, y, x, r)
+	}
+	for i := 0; i < n; i += 2 {
+		j := int(integerify(x, r) & uint64(n-1))
+		xorBlock(x, v[j*size:(j+1)*size])
+		blockMix(&tmp, x, y, r)
+
+		j = int(integerify(y, r) & uint64(n-1))
+		xorBlock(y, v[j*size:(j+1)*size])
+		blockMix(&tmp, y, x, r)
+	}
+	for i := range x {
+		binary.LittleEndian.PutUint32(b[4*i:], x[i])
+	}
+}
+
+// blockMix applies scryptBlockMix (RFC 7914, section 4) to in and writes the result to out.
+func blockMix(tmp *[16]uint32, in, out []uint32, r int) {
+	copy(tmp[:], in[(2*r-1)*16:])
+	for i := 0; i < 2*r; i += 2 {
+		salsaXOR(tmp, in[i*16:], out[i*8:])
+		salsaXOR(tmp, in[i*16+16:], out[i*8+r*16:])
+	}
+}
+
+// integerify returns the first 64 bit of the last 64 byte block of b.
+func integerify(b []uint32, r int) uint64 {
+	j := (2*r - 1) * 16
+	return uint64(b[j]) | uint64(b[j+1])<<32
+}
+
+// xorBlock sets dst to dst XOR src.
+func xorBlock(dst, src []uint32) {
+	for i := range dst {
+		dst[i] ^= src[i]
+	}
+}
+
+// salsaXOR applies Salsa20/8 (RFC 7914, section 3) to tmp XOR in and writes the result to out and tmp.
+func salsaXOR(tmp *[16]uint32, in, out []uint32) {
+	var w, x [16]uint32
+	for i := range w {
+		w[i] = tmp[i] ^ in[i]
+	}
+	x = w
+
+	for i := 0; i < 8; i += 2 {
+		// Columns
+		quarterRound(&x, 0, 4, 8, 12)
+		quarterRound(&x, 5, 9, 13, 1)
+		quarterRound(&x, 10, 14, 2, 6)
+		quarterRound(&x, 15, 3, 7, 11)
+		// Rows
+		quarterRound(&x, 0, 1, 2, 3)
+		quarterRound(&x, 5, 6, 7, 4)
+		quarterRound(&x, 10, 11, 8, 9)
+		quarterRound(&x, 15, 12, 13, 14)
+	}
+
+	for i := range x {
+		x[i] += w[i]
+		out[i] = x[i]
+		tmp[i] = x[i]
+	}
+}
+
+// quarterRound applies the Salsa20 quarter round to the words a, b, c and d of x.
+func quarterRound(x *[16]uint32, a, b, c, d int) {
+	x[b] ^= bits.RotateLeft32(x[a]+x[d], 7)
+	x[c] ^= bits.RotateLeft32(x[b]+x[a], 9)
+	x[d] ^= bits.RotateLeft32(x[c]+x[b], 13)
+	x[a] ^= bits.RotateLeft32(x[d]+x[c], 18)
+}
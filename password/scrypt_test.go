@@ -0,0 +1,68 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package password
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestScryptKey(t *testing.T) {
+	// Test vectors from RFC 7914, section 12
+	testcases := []struct {
+		password string
+		salt     string
+		ln       int
+		r        int
+		p        int
+		key      string
+	}{
+		{"", "", 4, 1, 1, "77d6576238657b203b19ca42c18a0497f16b4844e3074ae8dfdffa3fede21442fcd0069ded0948f8326a753a0fc81f17e8d3e0fb2e0d3628cf35e20c38d18906"},
+		{"password", "NaCl", 10, 8, 16, "fdbabe1c9d3472007856e7190d01e9fe7c6ad7cbc8237830e77376634b3731622eaf30d92e22a3886ff109279d9830dac727afb94a83ee6d8360cbdfa2cc0640"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.password, func(t *testing.T) {
+			key := scryptKey([]byte(tc.password), []byte(tc.salt), tc.ln, tc.r, tc.p, 64)
+			if hex.EncodeToString(key) != tc.key {
+				t.Errorf("wrong key (is: %x, should: %s)", key, tc.key)
+			}
+		})
+	}
+}
+
+func TestScrypt(t *testing.T) {
+	s := Scrypt{LogN: 4, R: 2, P: 1, SaltSize: 8, KeySize: 20}
+	h, err := s.Hash([]byte("password"))
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if h.ID != ScryptID || len(h.Salt) != 8 || len(h.Hash) != 20 {
+		t.Errorf("wrong hash: %s", h.String())
+	}
+	ln, r, p, err := scryptParams(h)
+	if err != nil || ln != 4 || r != 2 || p != 1 {
+		t.Errorf("wrong parameters: %s", h.String())
+	}
+
+	for _, s := range []Scrypt{{LogN: 0, R: 8, P: 1, SaltSize: 8, KeySize: 20}, {LogN: 4, R: 0, P: 1, SaltSize: 8, KeySize: 20}, {LogN: 4, R: 8, P: 0, SaltSize: 8, KeySize: 20}, {LogN: 30, R: 8, P: 1, SaltSize: 8, KeySize: 20}, {LogN: 4, R: 8, P: 1, SaltSize: 8, KeySize: 0}} {
+		_, err = s.Hash([]byte("password"))
+		if err == nil {
+			t.Errorf("no error for invalid parameters %+v", s)
+		}
+	}
+}
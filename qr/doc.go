@@ -0,0 +1,20 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package qr contains a QR code encoder (ISO/IEC 18004), e.g. to show provisioning URIs of package otp or ids of package data to phones.
+// Data is encoded in byte mode using the smallest version (1 to 40) fitting the data at the requested error correction level. The mask is chosen using the penalty rules of the standard.
+//
+// Codes can be rendered as image.Image, PNG or SVG.
+package qr
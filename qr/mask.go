@@ -0,0 +1,149 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package qr
+
+// Penalty weights of the standard
+const (
+	penaltyN1 = 3
+	penaltyN2 = 3
+	penaltyN3 = 40
+	penaltyN4 = 10
+)
+
+// applyMask inverts all data modules selected by the given mask. Applying the same mask twice removes it.
+func (c *Code) applyMask(mask int) {
+	for y := 0; y < c.size; y++ {
+		for x := 0; x < c.size; x++ {
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert && !c.function[y*c.size+x] {
+				c.modules[y*c.size+x] = !c.modules[y*c.size+x]
+			}
+		}
+	}
+}
+
+// penalty returns the penalty score of the code as defined by the standard. A lower score means the code is easier to read.
+func (c *Code) penalty() int {
+	result := 0
+
+	// Runs of the same colour and finder-like patterns in rows and columns
+	for _, column := range []bool{false, true} {
+		for a := 0; a < c.size; a++ {
+			runColor := false
+			runLength := 0
+			var history runHistory
+			for b := 0; b < c.size; b++ {
+				x, y := b, a
+				if column {
+					x, y = a, b
+				}
+				if c.Module(x, y) == runColor {
+					runLength++
+					if runLength == 5 {
+						result += penaltyN1
+					} else if runLength > 5 {
+						result++
+					}
+				} else {
+					history.add(runLength, c.size)
+					if !runColor {
+						result += history.countPatterns() * penaltyN3
+					}
+					runColor = c.Module(x, y)
+					runLength = 1
+				}
+			}
+			result += history.terminateAndCount(runColor, runLength, c.size) * penaltyN3
+		}
+	}
+
+	// 2x2 blocks of the same colour
+	for y := 0; y < c.size-1; y++ {
+		for x := 0; x < c.size-1; x++ {
+			color := c.Module(x, y)
+			if color == c.Module(x+1, y) && color == c.Module(x, y+1) && color == c.Module(x+1, y+1) {
+				result += penaltyN2
+			}
+		}
+	}
+
+	// Balance of dark and light modules
+	dark := 0
+	for i := range c.modules {
+		if c.modules[i] {
+			dark++
+		}
+	}
+	total := c.size * c.size
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	result += k * penaltyN4
+	return result
+}
+
+// runHistory contains the lengths of the last runs in a row or column, newest first.
+type runHistory [7]int
+
+// add adds a run to the history. The light border before the code is added to the first run.
+func (h *runHistory) add(length, size int) {
+	if h[0] == 0 {
+		length += size
+	}
+	copy(h[1:], h[:len(h)-1])
+	h[0] = length
+}
+
+// countPatterns returns the number of finder-like patterns (1:1:3:1:1 with light space of 4 on one side) ending at the newest run.
+func (h *runHistory) countPatterns() int {
+	n := h[1]
+	core := n > 0 && h[2] == n && h[3] == n*3 && h[4] == n && h[5] == n
+	count := 0
+	if core && h[0] >= n*4 && h[6] >= n {
+		count++
+	}
+	if core && h[6] >= n*4 && h[0] >= n {
+		count++
+	}
+	return count
+}
+
+// terminateAndCount adds the final run and the light border after the code and returns the number of finder-like patterns.
+func (h *runHistory) terminateAndCount(runColor bool, runLength, size int) int {
+	if runColor {
+		h.add(runLength, size)
+		runLength = 0
+	}
+	runLength += size
+	h.add(runLength, size)
+	return h.countPatterns()
+}
@@ -0,0 +1,109 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package qr
+
+import (
+	"testing"
+)
+
+func TestApplyMask(t *testing.T) {
+	c, err := Encode([]byte("mask"), Low)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	original := make([]bool, len(c.modules))
+	copy(original, c.modules)
+
+	for m := 0; m < 8; m++ {
+		c.applyMask(m)
+		changed := false
+		for i := range c.modules {
+			if c.modules[i] != original[i] {
+				changed = true
+				if c.function[i] {
+					t.Errorf("mask %d changed function module %d", m, i)
+				}
+			}
+		}
+		if !changed {
+			t.Errorf("mask %d changed nothing", m)
+		}
+
+		c.applyMask(m)
+		for i := range c.modules {
+			if c.modules[i] != original[i] {
+				t.Errorf("mask %d not removed by applying it twice", m)
+				break
+			}
+		}
+	}
+}
+
+func TestPenalty(t *testing.T) {
+	size := 21
+	newCode := func() *Code {
+		return &Code{Version: 1, size: size, modules: make([]bool, size*size), function: make([]bool, size*size)}
+	}
+
+	// All light: every row and column is a run of 21 (3 + 16), 400 2x2 blocks, maximal imbalance
+	c := newCode()
+	should := 2*size*(penaltyN1+size-5) + (size-1)*(size-1)*penaltyN2 + 9*penaltyN4
+	if p := c.penalty(); p != should {
+		t.Errorf("wrong penalty for light code (is: %d, should: %d)", p, should)
+	}
+
+	// Checkerboard: no runs, no blocks, balanced
+	c = newCode()
+	for i := range c.modules {
+		c.modules[i] = (i/size+i%size)%2 == 0
+	}
+	if p := c.penalty(); p != 0 {
+		t.Errorf("wrong penalty for checkerboard (is: %d, should: %d)", p, 0)
+	}
+
+	// A finder-like pattern adds a penalty
+	c = newCode()
+	for i := range c.modules {
+		c.modules[i] = (i/size+i%size)%2 == 0
+	}
+	base := c.penalty()
+	row := "#.###.#...."
+	for x := range row {
+		c.modules[10*size+x] = row[x] == '#'
+	}
+	if p := c.penalty(); p < base+penaltyN3 {
+		t.Errorf("finder-like pattern not penalised (is: %d, should be at least: %d)", p, base+penaltyN3)
+	}
+
+	// The chosen mask has the lowest penalty
+	code, err := Encode([]byte("penalty"), Medium)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	chosen := code.penalty()
+	for m := 0; m < 8; m++ {
+		other, err := encode([]byte("penalty"), Medium, m)
+		if err != nil {
+			t.Logf("error occured: %s", err.Error())
+			t.FailNow()
+		}
+		if other.penalty() < chosen {
+			t.Errorf("mask %d has lower penalty than chosen mask %d (%d < %d)", m, code.Mask, other.penalty(), chosen)
+		}
+	}
+}
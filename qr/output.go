@@ -0,0 +1,104 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package qr
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"strconv"
+	"strings"
+)
+
+// BorderDefault is the width of the quiet zone (in modules) required by the standard.
+const BorderDefault = 4
+
+// Image returns the code as an image. Every module is scale × scale pixels, the light quiet zone around the code is border modules wide.
+func (c *Code) Image(scale, border int) (image.Image, error) {
+	if scale < 1 {
+		return nil, errors.New("scale must be positive")
+	}
+	if border < 0 {
+		return nil, errors.New("border must not be negative")
+	}
+
+	size := (c.size + 2*border) * scale
+	img := image.NewPaletted(image.Rect(0, 0, size, size), color.Palette{color.White, color.Black})
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			if c.Module(x/scale-border, y/scale-border) {
+				img.Pix[y*img.Stride+x] = 1
+			}
+		}
+	}
+	return img, nil
+}
+
+// PNG returns the code encoded as PNG. See Image for the meaning of scale and border.
+func (c *Code) PNG(scale, border int) ([]byte, error) {
+	img, err := c.Image(scale, border)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	err = png.Encode(&buf, img)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SVG returns the code as SVG image. One unit of the image is one module, so the image can be scaled freely. The light quiet zone around the code is border modules wide.
+func (c *Code) SVG(border int) (string, error) {
+	if border < 0 {
+		return "", errors.New("border must not be negative")
+	}
+
+	size := strconv.Itoa(c.size + 2*border)
+	var sb strings.Builder
+	sb.WriteString(`<?xml version="1.0" encoding="UTF-8"?>`)
+	sb.WriteString("\n")
+	sb.WriteString(`<svg xmlns="http://www.w3.org/2000/svg" version="1.1" viewBox="0 0 `)
+	sb.WriteString(size + " " + size)
+	sb.WriteString(`" shape-rendering="crispEdges">`)
+	sb.WriteString("\n")
+	sb.WriteString(`<rect width="100%" height="100%" fill="#ffffff"/>`)
+	sb.WriteString("\n")
+	sb.WriteString(`<path fill="#000000" d="`)
+	first := true
+	for y := 0; y < c.size; y++ {
+		for x := 0; x < c.size; x++ {
+			if !c.Module(x, y) {
+				continue
+			}
+			if !first {
+				sb.WriteString(" ")
+			}
+			first = false
+			sb.WriteString("M")
+			sb.WriteString(strconv.Itoa(x + border))
+			sb.WriteString(",")
+			sb.WriteString(strconv.Itoa(y + border))
+			sb.WriteString("h1v1h-1z")
+		}
+	}
+	sb.WriteString(`"/>`)
+	sb.WriteString("\n")
+	sb.WriteString("</svg>\n")
+	return sb.String(), nil
+}
@@ -0,0 +1,128 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package qr
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestImage(t *testing.T) {
+	c, err := Encode([]byte("image"), Medium)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+
+	img, err := c.Image(3, BorderDefault)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	size := (c.Size() + 2*BorderDefault) * 3
+	if img.Bounds().Dx() != size || img.Bounds().Dy() != size {
+		t.Fatalf("wrong image size (is: %dx%d, should: %dx%d)", img.Bounds().Dx(), img.Bounds().Dy(), size, size)
+	}
+
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			r, _, _, _ := img.At(x, y).RGBA()
+			dark := r == 0
+			if dark != c.Module(x/3-BorderDefault, y/3-BorderDefault) {
+				t.Fatalf("wrong pixel at %d, %d", x, y)
+			}
+		}
+	}
+
+	_, err = c.Image(0, 4)
+	if err == nil {
+		t.Error("no error for invalid scale")
+	}
+	_, err = c.Image(1, -1)
+	if err == nil {
+		t.Error("no error for invalid border")
+	}
+}
+
+func TestPNG(t *testing.T) {
+	c, err := Encode([]byte("png"), Medium)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+
+	b, err := c.PNG(2, 1)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	img, err := png.Decode(bytes.NewReader(b))
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	size := (c.Size() + 2) * 2
+	if img.Bounds().Dx() != size || img.Bounds().Dy() != size {
+		t.Errorf("wrong image size (is: %dx%d, should: %dx%d)", img.Bounds().Dx(), img.Bounds().Dy(), size, size)
+	}
+	r, _, _, _ := img.At(2, 2).RGBA()
+	if r != 0 {
+		t.Error("finder pattern not dark")
+	}
+	r, _, _, _ = img.At(0, 0).RGBA()
+	if r == 0 {
+		t.Error("border not light")
+	}
+}
+
+func TestSVG(t *testing.T) {
+	c, err := Encode([]byte("svg"), Medium)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+
+	s, err := c.SVG(BorderDefault)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if !strings.Contains(s, `viewBox="0 0 29 29"`) {
+		t.Errorf("wrong view box: %s", s)
+	}
+
+	dark := 0
+	for y := 0; y < c.Size(); y++ {
+		for x := 0; x < c.Size(); x++ {
+			if c.Module(x, y) {
+				dark++
+			}
+		}
+	}
+	if n := strings.Count(s, "h1v1h-1z"); n != dark {
+		t.Errorf("wrong number of modules (is: %d, should: %d)", n, dark)
+	}
+	if !strings.Contains(s, "M4,4h1v1h-1z") {
+		t.Error("top left module missing")
+	}
+
+	_, err = c.SVG(-1)
+	if err == nil {
+		t.Error("no error for invalid border")
+	}
+}
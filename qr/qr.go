@@ -0,0 +1,445 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package qr
+
+import (
+	"errors"
+)
+
+const (
+	// MinVersion is the smallest version of a QR code.
+	MinVersion = 1
+
+	// MaxVersion is the largest version of a QR code.
+	MaxVersion = 40
+)
+
+// ErrTooLong is returned if data does not fit into a QR code of the largest version.
+var ErrTooLong = errors.New("qr: data too long")
+
+// Level is the error correction level of a QR code. Higher levels can be restored from more damage but need more space.
+type Level int
+
+const (
+	// Low recovers about 7% of the code.
+	Low Level = iota
+	// Medium recovers about 15% of the code.
+	Medium
+	// Quartile recovers about 25% of the code.
+	Quartile
+	// High recovers about 30% of the code.
+	High
+)
+
+// String returns the name of the level as used by the standard (L, M, Q or H).
+func (l Level) String() string {
+	switch l {
+	case Low:
+		return "L"
+	case Medium:
+		return "M"
+	case Quartile:
+		return "Q"
+	case High:
+		return "H"
+	default:
+		return "unknown"
+	}
+}
+
+// formatBits returns the bits encoding the level in the format information.
+func (l Level) formatBits() int {
+	return [...]int{1, 0, 3, 2}[l]
+}
+
+// eccCodewordsPerBlock contains the number of error correction codewords per block, indexed by level and version.
+var eccCodewordsPerBlock = [4][MaxVersion + 1]int{
+	{-1, 7, 10, 15, 20, 26, 18, 20, 24, 30, 18, 20, 24, 26, 30, 22, 24, 28, 30, 28, 28, 28, 28, 30, 30, 26, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26, 26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28},
+	{-1, 13, 22, 18, 26, 18, 24, 18, 22, 20, 24, 28, 26, 24, 20, 30, 24, 28, 28, 26, 30, 28, 30, 30, 30, 30, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 17, 28, 22, 16, 22, 28, 26, 26, 24, 28, 24, 28, 22, 24, 24, 30, 28, 28, 26, 28, 30, 24, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+}
+
+// errorCorrectionBlocks contains the number of error correction blocks, indexed by level and version.
+var errorCorrectionBlocks = [4][MaxVersion + 1]int{
+	{-1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 4, 4, 4, 4, 4, 6, 6, 6, 6, 7, 8, 8, 9, 9, 10, 12, 12, 12, 13, 14, 15, 16, 17, 18, 19, 19, 20, 21, 22, 24, 25},
+	{-1, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16, 17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49},
+	{-1, 1, 1, 2, 2, 4, 4, 6, 6, 8, 8, 8, 10, 12, 16, 12, 17, 16, 18, 21, 20, 23, 23, 25, 27, 29, 34, 34, 35, 38, 40, 43, 45, 48, 51, 53, 56, 59, 62, 65, 68},
+	{-1, 1, 1, 2, 4, 4, 4, 5, 6, 8, 8, 11, 11, 16, 16, 18, 16, 19, 21, 25, 25, 25, 34, 30, 32, 35, 37, 40, 42, 45, 48, 51, 54, 57, 60, 63, 66, 70, 74, 77, 81},
+}
+
+// Code is an encoded QR code.
+type Code struct {
+	// Version is the version (1 to 40) of the code. The code has 4 * Version + 17 modules per side.
+	Version int
+	// Level is the error correction level of the code.
+	Level Level
+	// Mask is the mask (0 to 7) applied to the code.
+	Mask int
+
+	size     int
+	modules  []bool
+	function []bool
+}
+
+// Encode returns a QR code containing data in byte mode. The smallest version fitting data at the given level is used.
+// ErrTooLong is returned if data does not fit into a code of the largest version.
+//
+// Can be used concurrent.
+func Encode(data []byte, level Level) (*Code, error) {
+	return encode(data, level, -1)
+}
+
+// EncodeString returns a QR code containing s. See Encode for more information.
+//
+// Can be used concurrent.
+func EncodeString(s string, level Level) (*Code, error) {
+	return Encode([]byte(s), level)
+}
+
+// encode returns a QR code containing data. If mask is negative, the mask with the lowest penalty is used.
+func encode(data []byte, level Level, mask int) (*Code, error) {
+	if level < Low || level > High {
+		return nil, errors.New("unknown level")
+	}
+	if mask > 7 {
+		return nil, errors.New("mask must be between 0 and 7")
+	}
+
+	version := 0
+	for v := MinVersion; v <= MaxVersion; v++ {
+		if 4+charCountBits(v)+8*len(data) <= 8*dataCodewords(v, level) {
+			version = v
+			break
+		}
+	}
+	if version == 0 || len(data) >= 1<<uint(charCountBits(version)) {
+		return nil, ErrTooLong
+	}
+
+	// Data segment in byte mode
+	var bb bitBuffer
+	bb.appendBits(0x4, 4)
+	bb.appendBits(len(data), charCountBits(version))
+	for _, b := range data {
+		bb.appendBits(int(b), 8)
+	}
+
+	// Terminator and padding
+	capacity := 8 * dataCodewords(version, level)
+	terminator := capacity - len(bb)
+	if terminator > 4 {
+		terminator = 4
+	}
+	bb.appendBits(0, terminator)
+	bb.appendBits(0, (8-len(bb)%8)%8)
+	for pad := 0xec; len(bb) < capacity; pad ^= 0xec ^ 0x11 {
+		bb.appendBits(pad, 8)
+	}
+
+	c := &Code{
+		Version:  version,
+		Level:    level,
+		size:     4*version + 17,
+		modules:  make([]bool, (4*version+17)*(4*version+17)),
+		function: make([]bool, (4*version+17)*(4*version+17)),
+	}
+	c.drawFunctionPatterns()
+	c.drawCodewords(c.addErrorCorrection(bb.bytes()))
+
+	if mask < 0 {
+		minPenalty := -1
+		for m := 0; m < 8; m++ {
+			c.applyMask(m)
+			c.drawFormatBits(m)
+			penalty := c.penalty()
+			if minPenalty < 0 || penalty < minPenalty {
+				mask = m
+				minPenalty = penalty
+			}
+			// Applying the mask again removes it.
+			c.applyMask(m)
+		}
+	}
+	c.Mask = mask
+	c.applyMask(mask)
+	c.drawFormatBits(mask)
+	return c, nil
+}
+
+// Size returns the number of modules per side of the code (without the quiet zone).
+func (c *Code) Size() int {
+	return c.size
+}
+
+// Module returns whether the module at column x and row y is dark. Coordinates outside of the code are light.
+func (c *Code) Module(x, y int) bool {
+	if x < 0 || y < 0 || x >= c.size || y >= c.size {
+		return false
+	}
+	return c.modules[y*c.size+x]
+}
+
+// charCountBits returns the size of the character count indicator of byte mode for the given version.
+func charCountBits(version int) int {
+	if version <= 9 {
+		return 8
+	}
+	return 16
+}
+
+// rawDataModules returns the number of modules available for data and error correction codewords of the given version.
+func rawDataModules(version int) int {
+	result := (16*version+128)*version + 64
+	if version >= 2 {
+		numAlign := version/7 + 2
+		result -= (25*numAlign-10)*numAlign - 55
+		if version >= 7 {
+			result -= 36
+		}
+	}
+	return result
+}
+
+// dataCodewords returns the number of data codewords of the given version and level.
+func dataCodewords(version int, level Level) int {
+	return rawDataModules(version)/8 - eccCodewordsPerBlock[level][version]*errorCorrectionBlocks[level][version]
+}
+
+// addErrorCorrection splits data into blocks, adds the error correction codewords to each block and interleaves the blocks.
+func (c *Code) addErrorCorrection(data []byte) []byte {
+	numBlocks := errorCorrectionBlocks[c.Level][c.Version]
+	eccLen := eccCodewordsPerBlock[c.Level][c.Version]
+	rawCodewords := rawDataModules(c.Version) / 8
+	numShortBlocks := numBlocks - rawCodewords%numBlocks
+	shortBlockLen := rawCodewords / numBlocks
+
+	divisor := rsDivisor(eccLen)
+	blocks := make([][]byte, numBlocks)
+	k := 0
+	for i := range blocks {
+		l := shortBlockLen - eccLen
+		if i >= numShortBlocks {
+			l++
+		}
+		block := make([]byte, 0, shortBlockLen+1)
+		block = append(block, data[k:k+l]...)
+		k += l
+		if i < numShortBlocks {
+			// Placeholder, so that all blocks have the same length.
+			block = append(block, 0)
+		}
+		blocks[i] = append(block, rsRemainder(data[k-l:k], divisor)...)
+	}
+
+	result := make([]byte, 0, rawCodewords)
+	for i := range blocks[0] {
+		for j := range blocks {
+			if i != shortBlockLen-eccLen || j >= numShortBlocks {
+				result = append(result, blocks[j][i])
+			}
+		}
+	}
+	return result
+}
+
+// setFunction sets the module at x, y and marks it as part of a function pattern.
+func (c *Code) setFunction(x, y int, dark bool) {
+	c.modules[y*c.size+x] = dark
+	c.function[y*c.size+x] = true
+}
+
+// drawFunctionPatterns draws the finder, timing and alignment patterns and reserves the space of format and version information.
+func (c *Code) drawFunctionPatterns() {
+	for i := 0; i < c.size; i++ {
+		c.setFunction(6, i, i%2 == 0)
+		c.setFunction(i, 6, i%2 == 0)
+	}
+
+	c.drawFinderPattern(3, 3)
+	c.drawFinderPattern(c.size-4, 3)
+	c.drawFinderPattern(3, c.size-4)
+
+	positions := alignmentPatternPositions(c.Version)
+	for i := range positions {
+		for j := range positions {
+			// Skip the corners with finder patterns.
+			if (i == 0 && j == 0) || (i == 0 && j == len(positions)-1) || (i == len(positions)-1 && j == 0) {
+				continue
+			}
+			c.drawAlignmentPattern(positions[i], positions[j])
+		}
+	}
+
+	c.drawFormatBits(0)
+	c.drawVersion()
+}
+
+// drawFinderPattern draws a finder pattern including its separator centred at x, y.
+func (c *Code) drawFinderPattern(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx < 0 || yy < 0 || xx >= c.size || yy >= c.size {
+				continue
+			}
+			dist := max(abs(dx), abs(dy))
+			c.setFunction(xx, yy, dist != 2 && dist != 4)
+		}
+	}
+}
+
+// drawAlignmentPattern draws an alignment pattern centred at x, y.
+func (c *Code) drawAlignmentPattern(x, y int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			c.setFunction(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+// alignmentPatternPositions returns the row and column positions of the alignment patterns of the given version.
+func alignmentPatternPositions(version int) []int {
+	if version == 1 {
+		return nil
+	}
+	numAlign := version/7 + 2
+	step := (version*8 + numAlign*3 + 5) / (numAlign*4 - 4) * 2
+	size := 4*version + 17
+
+	result := make([]int, numAlign)
+	result[0] = 6
+	for i := 1; i < numAlign; i++ {
+		result[numAlign-i] = size - 7 - (i-1)*step
+	}
+	return result
+}
+
+// drawFormatBits draws both copies of the format information (level and mask) and the dark module.
+func (c *Code) drawFormatBits(mask int) {
+	data := c.Level.formatBits()<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+
+	// First copy around the top left finder pattern
+	for i := 0; i <= 5; i++ {
+		c.setFunction(8, i, bit(bits, i))
+	}
+	c.setFunction(8, 7, bit(bits, 6))
+	c.setFunction(8, 8, bit(bits, 7))
+	c.setFunction(7, 8, bit(bits, 8))
+	for i := 9; i < 15; i++ {
+		c.setFunction(14-i, 8, bit(bits, i))
+	}
+
+	// Second copy split between the other finder patterns
+	for i := 0; i < 8; i++ {
+		c.setFunction(c.size-1-i, 8, bit(bits, i))
+	}
+	for i := 8; i < 15; i++ {
+		c.setFunction(8, c.size-15+i, bit(bits, i))
+	}
+	c.setFunction(8, c.size-8, true)
+}
+
+// drawVersion draws both copies of the version information (only for version 7 and larger).
+func (c *Code) drawVersion() {
+	if c.Version < 7 {
+		return
+	}
+	rem := c.Version
+	for i := 0; i < 12; i++ {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1f25)
+	}
+	bits := c.Version<<12 | rem
+
+	for i := 0; i < 18; i++ {
+		a := c.size - 11 + i%3
+		b := i / 3
+		c.setFunction(a, b, bit(bits, i))
+		c.setFunction(b, a, bit(bits, i))
+	}
+}
+
+// drawCodewords places data in the zigzag pattern of the standard, skipping function patterns.
+func (c *Code) drawCodewords(data []byte) {
+	i := 0
+	for right := c.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			// Skip the vertical timing pattern.
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < c.size; vert++ {
+			y := vert
+			if upward {
+				y = c.size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				x := right - j
+				if c.function[y*c.size+x] || i >= len(data)*8 {
+					// Remaining modules (remainder bits) stay light.
+					continue
+				}
+				c.modules[y*c.size+x] = bit(int(data[i>>3]), 7-i&7)
+				i++
+			}
+		}
+	}
+}
+
+// bitBuffer is a sequence of bits.
+type bitBuffer []bool
+
+// appendBits appends the lowest n bits of value, starting with the most significant one.
+func (bb *bitBuffer) appendBits(value, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*bb = append(*bb, bit(value, i))
+	}
+}
+
+// bytes returns the bits packed into bytes. The length of bb must be a multiple of 8.
+func (bb bitBuffer) bytes() []byte {
+	result := make([]byte, len(bb)/8)
+	for i := range bb {
+		if bb[i] {
+			result[i>>3] |= 1 << uint(7-i&7)
+		}
+	}
+	return result
+}
+
+// bit returns whether bit i of x is set.
+func bit(x, i int) bool {
+	return (x>>uint(i))&1 != 0
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
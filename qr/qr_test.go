@@ -0,0 +1,358 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package qr
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// decode reads the data of c back by reversing the encoding steps. It is used to check the placement of the codewords.
+func decode(t *testing.T, c *Code) []byte {
+	t.Helper()
+
+	// Format information
+	bits := 0
+	for i := 0; i <= 5; i++ {
+		if c.Module(8, i) {
+			bits |= 1 << uint(i)
+		}
+	}
+	if c.Module(8, 7) {
+		bits |= 1 << 6
+	}
+	if c.Module(8, 8) {
+		bits |= 1 << 7
+	}
+	if c.Module(7, 8) {
+		bits |= 1 << 8
+	}
+	for i := 9; i < 15; i++ {
+		if c.Module(14-i, 8) {
+			bits |= 1 << uint(i)
+		}
+	}
+	bits ^= 0x5412
+	if bits>>13 != c.Level.formatBits() {
+		t.Errorf("wrong level in format information (is: %d, should: %d)", bits>>13, c.Level.formatBits())
+	}
+	mask := (bits >> 10) & 7
+	if mask != c.Mask {
+		t.Errorf("wrong mask in format information (is: %d, should: %d)", mask, c.Mask)
+	}
+
+	// Codewords
+	c.applyMask(mask)
+	defer c.applyMask(mask)
+	var bb bitBuffer
+	for right := c.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < c.size; vert++ {
+			y := vert
+			if (right+1)&2 == 0 {
+				y = c.size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				if !c.function[y*c.size+right-j] {
+					bb = append(bb, c.Module(right-j, y))
+				}
+			}
+		}
+	}
+	codewords := bb[:len(bb)/8*8].bytes()
+
+	// Deinterleave and check error correction
+	numBlocks := errorCorrectionBlocks[c.Level][c.Version]
+	eccLen := eccCodewordsPerBlock[c.Level][c.Version]
+	numShortBlocks := numBlocks - len(codewords)%numBlocks
+	shortBlockLen := len(codewords) / numBlocks
+	blocks := make([][]byte, numBlocks)
+	k := 0
+	for i := 0; i < shortBlockLen+1; i++ {
+		for j := range blocks {
+			if i != shortBlockLen-eccLen || j >= numShortBlocks {
+				blocks[j] = append(blocks[j], codewords[k])
+				k++
+			}
+		}
+	}
+	var data []byte
+	for i := range blocks {
+		r := rsRemainder(blocks[i], rsDivisor(eccLen))
+		if !bytes.Equal(r, make([]byte, eccLen)) {
+			t.Errorf("block %d is not a valid codeword", i)
+		}
+		data = append(data, blocks[i][:len(blocks[i])-eccLen]...)
+	}
+
+	// Byte mode segment
+	if data[0]>>4 != 0x4 {
+		t.Fatalf("wrong mode (is: %#x, should: %#x)", data[0]>>4, 0x4)
+	}
+	var length, start int
+	if charCountBits(c.Version) == 8 {
+		length = int(data[0]&0xf)<<4 | int(data[1]>>4)
+		start = 1
+	} else {
+		length = int(data[0]&0xf)<<12 | int(data[1])<<4 | int(data[2]>>4)
+		start = 2
+	}
+	result := make([]byte, length)
+	for i := range result {
+		result[i] = data[start+i]<<4 | data[start+i+1]>>4
+	}
+	return result
+}
+
+func TestEncode(t *testing.T) {
+	testcases := []struct {
+		name    string
+		data    []byte
+		level   Level
+		version int
+	}{
+		{"empty", []byte{}, Low, 1},
+		{"short", []byte("hello"), Medium, 1},
+		{"full version 1-L", bytes.Repeat([]byte("a"), 17), Low, 1},
+		{"version 2-L", bytes.Repeat([]byte("a"), 18), Low, 2},
+		{"full version 1-H", bytes.Repeat([]byte("a"), 7), High, 1},
+		{"otpauth", []byte("otpauth://totp/Example:alice@example.com?algorithm=SHA1&digits=6&issuer=Example&period=30&secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"), Medium, 8},
+		{"version 12", bytes.Repeat([]byte{0xff, 0x00}, 100), Quartile, 12},
+		{"version 40-L", bytes.Repeat([]byte("x"), 2953), Low, 40},
+		{"version 40-H", bytes.Repeat([]byte("x"), 1273), High, 40},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := Encode(tc.data, tc.level)
+			if err != nil {
+				t.Logf("error occured: %s", err.Error())
+				t.FailNow()
+			}
+			if c.Version != tc.version {
+				t.Errorf("wrong version (is: %d, should: %d)", c.Version, tc.version)
+			}
+			if c.Size() != 4*tc.version+17 {
+				t.Errorf("wrong size (is: %d, should: %d)", c.Size(), 4*tc.version+17)
+			}
+			if c.Level != tc.level {
+				t.Errorf("wrong level (is: %s, should: %s)", c.Level, tc.level)
+			}
+			if c.Mask < 0 || c.Mask > 7 {
+				t.Errorf("invalid mask: %d", c.Mask)
+			}
+			d := decode(t, c)
+			if !bytes.Equal(d, tc.data) {
+				t.Errorf("wrong data decoded (is: %v, should: %v)", d, tc.data)
+			}
+		})
+	}
+}
+
+func TestEncodeAllMasks(t *testing.T) {
+	for m := 0; m < 8; m++ {
+		c, err := encode([]byte("mask test"), Quartile, m)
+		if err != nil {
+			t.Logf("error occured: %s", err.Error())
+			t.FailNow()
+		}
+		if c.Mask != m {
+			t.Errorf("wrong mask (is: %d, should: %d)", c.Mask, m)
+		}
+		d := decode(t, c)
+		if string(d) != "mask test" {
+			t.Errorf("wrong data decoded with mask %d (is: %s, should: %s)", m, d, "mask test")
+		}
+	}
+}
+
+func TestEncodeTooLong(t *testing.T) {
+	_, err := Encode(bytes.Repeat([]byte("x"), 2954), Low)
+	if !errors.Is(err, ErrTooLong) {
+		t.Errorf("wrong error (is: %v, should: %v)", err, ErrTooLong)
+	}
+	_, err = Encode(bytes.Repeat([]byte("x"), 1274), High)
+	if !errors.Is(err, ErrTooLong) {
+		t.Errorf("wrong error (is: %v, should: %v)", err, ErrTooLong)
+	}
+	_, err = Encode([]byte("x"), Level(42))
+	if err == nil {
+		t.Error("no error for invalid level")
+	}
+}
+
+func TestEncodeString(t *testing.T) {
+	c, err := EncodeString("string", Medium)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if string(decode(t, c)) != "string" {
+		t.Error("wrong data decoded")
+	}
+}
+
+func TestFunctionPatterns(t *testing.T) {
+	c, err := Encode([]byte("function patterns"), Low)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+
+	// Finder pattern in the top left corner
+	finder := []string{
+		"#######.",
+		"#.....#.",
+		"#.###.#.",
+		"#.###.#.",
+		"#.###.#.",
+		"#.....#.",
+		"#######.",
+		"........",
+	}
+	for y := range finder {
+		for x := range finder[y] {
+			if c.Module(x, y) != (finder[y][x] == '#') {
+				t.Errorf("wrong finder module at %d, %d", x, y)
+			}
+		}
+	}
+
+	// Timing pattern
+	for i := 8; i < c.Size()-8; i++ {
+		if c.Module(i, 6) != (i%2 == 0) || c.Module(6, i) != (i%2 == 0) {
+			t.Errorf("wrong timing module at %d", i)
+		}
+	}
+
+	// Dark module
+	if !c.Module(8, c.Size()-8) {
+		t.Error("dark module not set")
+	}
+}
+
+func TestFormatBits(t *testing.T) {
+	// Values from the table of all format information strings of the standard
+	testcases := []struct {
+		level Level
+		mask  int
+		bits  string
+	}{
+		{Low, 0, "111011111000100"},
+		{Low, 7, "110100101110110"},
+		{Medium, 0, "101010000010010"},
+		{Medium, 4, "100010111111001"},
+		{Quartile, 0, "011010101011111"},
+		{High, 0, "001011010001001"},
+		{High, 7, "000100000111011"},
+	}
+
+	for _, tc := range testcases {
+		c := &Code{Version: 1, Level: tc.level, size: 21, modules: make([]bool, 21*21), function: make([]bool, 21*21)}
+		c.drawFormatBits(tc.mask)
+		var sb strings.Builder
+		// Second copy: bits 14 to 8 are in column 8 from the bottom up, bits 7 to 0 in row 8 from the left to the right.
+		for i := 14; i >= 8; i-- {
+			if c.Module(8, c.size-15+i) {
+				sb.WriteString("1")
+			} else {
+				sb.WriteString("0")
+			}
+		}
+		for i := 7; i >= 0; i-- {
+			if c.Module(c.size-1-i, 8) {
+				sb.WriteString("1")
+			} else {
+				sb.WriteString("0")
+			}
+		}
+		if sb.String() != tc.bits {
+			t.Errorf("wrong format bits for %s / %d (is: %s, should: %s)", tc.level, tc.mask, sb.String(), tc.bits)
+		}
+	}
+}
+
+func TestVersionBits(t *testing.T) {
+	// Values from the table of version information of the standard
+	testcases := map[int]int{7: 0x07c94, 8: 0x085bc, 21: 0x15683, 40: 0x28c69}
+	for version, should := range testcases {
+		size := 4*version + 17
+		c := &Code{Version: version, size: size, modules: make([]bool, size*size), function: make([]bool, size*size)}
+		c.drawVersion()
+		bits := 0
+		for i := 0; i < 18; i++ {
+			if c.Module(size-11+i%3, i/3) {
+				bits |= 1 << uint(i)
+			}
+		}
+		if bits != should {
+			t.Errorf("wrong version bits for version %d (is: %#x, should: %#x)", version, bits, should)
+		}
+	}
+}
+
+func TestAlignmentPatternPositions(t *testing.T) {
+	testcases := map[int][]int{
+		1:  nil,
+		2:  {6, 18},
+		7:  {6, 22, 38},
+		14: {6, 26, 46, 66},
+		32: {6, 34, 60, 86, 112, 138},
+		40: {6, 30, 58, 86, 114, 142, 170},
+	}
+	for version, should := range testcases {
+		p := alignmentPatternPositions(version)
+		if len(p) != len(should) {
+			t.Errorf("wrong number of positions for version %d (is: %v, should: %v)", version, p, should)
+			continue
+		}
+		for i := range p {
+			if p[i] != should[i] {
+				t.Errorf("wrong positions for version %d (is: %v, should: %v)", version, p, should)
+				break
+			}
+		}
+	}
+}
+
+func TestDataCodewords(t *testing.T) {
+	// Values from the capacity table of the standard
+	testcases := []struct {
+		version int
+		level   Level
+		should  int
+	}{
+		{1, Low, 19},
+		{1, High, 9},
+		{5, Quartile, 62},
+		{10, Medium, 216},
+		{20, Low, 861},
+		{40, Low, 2956},
+		{40, Medium, 2334},
+		{40, Quartile, 1666},
+		{40, High, 1276},
+	}
+	for _, tc := range testcases {
+		d := dataCodewords(tc.version, tc.level)
+		if d != tc.should {
+			t.Errorf("wrong data codewords for %d-%s (is: %d, should: %d)", tc.version, tc.level, d, tc.should)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package qr
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestGFMultiply(t *testing.T) {
+	testcases := []struct {
+		x, y, z byte
+	}{
+		{0, 0, 0},
+		{1, 1, 1},
+		{2, 2, 4},
+		{0x80, 2, 0x1d},
+		{0x53, 0xca, 0x8f},
+		{0xff, 1, 0xff},
+	}
+
+	for _, tc := range testcases {
+		z := gfMultiply(tc.x, tc.y)
+		if z != tc.z {
+			t.Errorf("wrong product of %#x and %#x (is: %#x, should: %#x)", tc.x, tc.y, z, tc.z)
+		}
+		if gfMultiply(tc.y, tc.x) != z {
+			t.Errorf("multiplication of %#x and %#x not commutative", tc.x, tc.y)
+		}
+	}
+}
+
+func TestRSRemainder(t *testing.T) {
+	// "HELLO WORLD" as version 1-M (alphanumeric mode)
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	ecc := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+
+	r := rsRemainder(data, rsDivisor(len(ecc)))
+	if !bytes.Equal(r, ecc) {
+		t.Errorf("wrong error correction (is: %v, should: %v)", r, ecc)
+	}
+
+	// A codeword is divisible by the generator
+	r = rsRemainder(append(data, ecc...), rsDivisor(len(ecc)))
+	if !bytes.Equal(r, make([]byte, len(ecc))) {
+		t.Errorf("codeword not divisible by generator: %v", r)
+	}
+}
@@ -0,0 +1,180 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"errors"
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+// Backend counts events per key in a sliding window.
+// Keys are arbitrary strings, e.g. an IP address or an account name.
+//
+// All methods must be safe for concurrent use.
+type Backend interface {
+	// Add records one event for key and returns the number of events in the sliding window of the given length ending at now (including the new event).
+	// Events added with different window lengths are counted separately, so several users with different windows can share a backend.
+	Add(key string, now time.Time, window time.Duration) (int, error)
+	// Count returns the number of events for key in the sliding window of the given length ending at now.
+	Count(key string, now time.Time, window time.Duration) (int, error)
+	// Reset removes all events for key.
+	Reset(key string) error
+}
+
+// memoryShards is the number of independently locked shards of Memory.
+const memoryShards = 32
+
+// Memory is an in-memory Backend.
+// Keys are distributed over several shards, so concurrent access to different keys rarely blocks.
+//
+// Memory approximates the sliding window by weighting the count of the previous fixed window. It only needs constant memory per key.
+// Keys without events in the last two windows are removed automatically.
+type Memory struct {
+	shards [memoryShards]memoryShard
+}
+
+type memoryShard struct {
+	m         sync.Mutex
+	entries   map[string]map[time.Duration]*memoryEntry
+	lastPrune time.Time
+}
+
+type memoryEntry struct {
+	windowStart time.Time
+	window      time.Duration
+	current     int
+	previous    int
+}
+
+// NewMemory returns a new, empty Memory backend.
+func NewMemory() *Memory {
+	m := new(Memory)
+	for i := range m.shards {
+		m.shards[i].entries = make(map[string]map[time.Duration]*memoryEntry)
+	}
+	return m
+}
+
+// Add records one event for key. See Backend for more information.
+func (m *Memory) Add(key string, now time.Time, window time.Duration) (int, error) {
+	if window <= 0 {
+		return 0, errors.New("window must be positive")
+	}
+
+	s := m.shard(key)
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	windows, ok := s.entries[key]
+	if !ok {
+		windows = make(map[time.Duration]*memoryEntry)
+		s.entries[key] = windows
+	}
+	e, ok := windows[window]
+	if !ok {
+		e = &memoryEntry{windowStart: now, window: window}
+		windows[window] = e
+	}
+	e.rotate(now)
+	e.current++
+	count := e.count(now)
+	s.prune(now, window)
+	return count, nil
+}
+
+// Count returns the number of events for key. See Backend for more information.
+func (m *Memory) Count(key string, now time.Time, window time.Duration) (int, error) {
+	if window <= 0 {
+		return 0, errors.New("window must be positive")
+	}
+
+	s := m.shard(key)
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	e, ok := s.entries[key][window]
+	if !ok {
+		return 0, nil
+	}
+	e.rotate(now)
+	return e.count(now), nil
+}
+
+// Reset removes all events for key, regardless of the window length.
+func (m *Memory) Reset(key string) error {
+	s := m.shard(key)
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
+
+// shard returns the shard responsible for key.
+func (m *Memory) shard(key string) *memoryShard {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return &m.shards[h.Sum32()%memoryShards]
+}
+
+// prune removes entries without events in the last two windows. It runs at most once per window.
+// s.m must be held.
+func (s *memoryShard) prune(now time.Time, window time.Duration) {
+	if now.Sub(s.lastPrune) < window {
+		return
+	}
+	s.lastPrune = now
+	for k, windows := range s.entries {
+		for w, e := range windows {
+			if now.Sub(e.windowStart) >= 2*e.window {
+				delete(windows, w)
+			}
+		}
+		if len(windows) == 0 {
+			delete(s.entries, k)
+		}
+	}
+}
+
+// rotate moves the window of e so that it contains now.
+func (e *memoryEntry) rotate(now time.Time) {
+	elapsed := now.Sub(e.windowStart)
+	switch {
+	case elapsed >= 2*e.window:
+		e.previous = 0
+		e.current = 0
+		e.windowStart = now
+	case elapsed >= e.window:
+		e.previous = e.current
+		e.current = 0
+		e.windowStart = e.windowStart.Add(e.window)
+	}
+}
+
+// count returns the approximated number of events in the sliding window ending at now.
+// The previous window is weighted by how much of it is still covered by the sliding window.
+func (e *memoryEntry) count(now time.Time) int {
+	weight := 1 - float64(now.Sub(e.windowStart))/float64(e.window)
+	if weight < 0 {
+		weight = 0
+	}
+	if weight > 1 {
+		weight = 1
+	}
+	return e.current + int(float64(e.previous)*weight)
+}
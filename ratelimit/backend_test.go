@@ -0,0 +1,192 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMemory(t *testing.T) {
+	m := NewMemory()
+	testtime := time.Now()
+
+	for i := 1; i <= 5; i++ {
+		c, err := m.Add("key", testtime, 1*time.Minute)
+		if err != nil {
+			t.Logf("error occured: %s", err.Error())
+			t.FailNow()
+		}
+		if c != i {
+			t.Errorf("wrong count (is: %d, should: %d)", c, i)
+		}
+	}
+
+	c, err := m.Count("key", testtime, 1*time.Minute)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if c != 5 {
+		t.Errorf("wrong count (is: %d, should: %d)", c, 5)
+	}
+
+	c, err = m.Count("other", testtime, 1*time.Minute)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if c != 0 {
+		t.Errorf("wrong count for other key (is: %d, should: %d)", c, 0)
+	}
+
+	// Sliding window - half of the previous window is still counted
+	c, err = m.Count("key", testtime.Add(90*time.Second), 1*time.Minute)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if c != 2 {
+		t.Errorf("wrong count in sliding window (is: %d, should: %d)", c, 2)
+	}
+
+	c, err = m.Count("key", testtime.Add(3*time.Minute), 1*time.Minute)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if c != 0 {
+		t.Errorf("wrong count after window (is: %d, should: %d)", c, 0)
+	}
+
+	// Reset
+	m.Add("key", testtime, 1*time.Minute)
+	err = m.Reset("key")
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	c, _ = m.Count("key", testtime, 1*time.Minute)
+	if c != 0 {
+		t.Errorf("wrong count after reset (is: %d, should: %d)", c, 0)
+	}
+
+	// Invalid window
+	_, err = m.Add("key", testtime, 0)
+	if err == nil {
+		t.Error("no error for invalid window")
+	}
+}
+
+func TestMemoryWindows(t *testing.T) {
+	// Different windows on the same key must not influence each other (e.g. two limiters sharing a backend).
+	m := NewMemory()
+	testtime := time.Now()
+
+	for i := 1; i <= 5; i++ {
+		c, err := m.Add("key", testtime, 1*time.Minute)
+		if err != nil {
+			t.Logf("error occured: %s", err.Error())
+			t.FailNow()
+		}
+		if c != i {
+			t.Errorf("wrong count for short window (is: %d, should: %d)", c, i)
+		}
+		c, err = m.Add("key", testtime, 1*time.Hour)
+		if err != nil {
+			t.Logf("error occured: %s", err.Error())
+			t.FailNow()
+		}
+		if c != i {
+			t.Errorf("wrong count for long window (is: %d, should: %d)", c, i)
+		}
+	}
+
+	c, err := m.Count("key", testtime, 1*time.Minute)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if c != 5 {
+		t.Errorf("wrong count for short window (is: %d, should: %d)", c, 5)
+	}
+
+	// Pruning the short window keeps the long one.
+	m.Add("key", testtime.Add(5*time.Minute), 1*time.Minute)
+	c, err = m.Count("key", testtime.Add(5*time.Minute), 1*time.Hour)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if c != 5 {
+		t.Errorf("wrong count for long window after pruning (is: %d, should: %d)", c, 5)
+	}
+
+	// Reset removes all windows.
+	err = m.Reset("key")
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	c, _ = m.Count("key", testtime, 1*time.Hour)
+	if c != 0 {
+		t.Errorf("wrong count after reset (is: %d, should: %d)", c, 0)
+	}
+}
+
+func TestMemoryPrune(t *testing.T) {
+	m := NewMemory()
+	testtime := time.Now()
+
+	for i := 0; i < 1000; i++ {
+		m.Add(strconv.Itoa(i), testtime, 1*time.Minute)
+	}
+	for i := 0; i < memoryShards*10; i++ {
+		m.Add(strconv.Itoa(i), testtime.Add(5*time.Minute), 1*time.Minute)
+	}
+
+	entries := 0
+	for i := range m.shards {
+		entries += len(m.shards[i].entries)
+	}
+	if entries != memoryShards*10 {
+		t.Errorf("old entries not removed (is: %d, should: %d)", entries, memoryShards*10)
+	}
+}
+
+func TestMemoryConcurrent(t *testing.T) {
+	m := NewMemory()
+	testtime := time.Now()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for x := 0; x < 100; x++ {
+				m.Add("key", testtime, 1*time.Minute)
+			}
+		}()
+	}
+	wg.Wait()
+
+	c, _ := m.Count("key", testtime, 1*time.Minute)
+	if c != 1000 {
+		t.Errorf("wrong count (is: %d, should: %d)", c, 1000)
+	}
+}
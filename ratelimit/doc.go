@@ -0,0 +1,21 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ratelimit contains methods to count events (e.g. failed logins) per key and to escalate challenges based on them.
+// Events are counted in a sliding window. The counting is done by a Backend, an in-memory implementation is included.
+//
+// Escalation uses the counted failures to decide whether a request is allowed, needs a captcha or is blocked.
+// This way, captchas only need to be shown after suspicious behaviour. The captcha itself is verified with the functions of package captcha.
+package ratelimit
@@ -0,0 +1,189 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"encoding/base64"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/Top-Ranger/auth/captcha"
+)
+
+// Decision is the outcome of an Escalation.
+type Decision int
+
+const (
+	// Allow means that the request can be processed without a challenge.
+	Allow Decision = iota
+	// RequireCaptcha means that the request should only be processed after a captcha was solved.
+	RequireCaptcha
+	// Block means that the request should not be processed.
+	Block
+)
+
+// String returns a human readable representation of d.
+func (d Decision) String() string {
+	switch d {
+	case Allow:
+		return "allow"
+	case RequireCaptcha:
+		return "require captcha"
+	case Block:
+		return "block"
+	default:
+		return "unknown"
+	}
+}
+
+// Escalation decides on challenges based on the number of failures (e.g. failed logins) per key in a sliding window.
+// Typically, both the IP address and the account are used as keys, so that attacks on one account from many addresses are detected as well as attacks from one address on many accounts.
+//
+// Can be used concurrent.
+type Escalation struct {
+	// Backend counts the failures.
+	Backend Backend
+	// Window is the length of the sliding window.
+	Window time.Duration
+	// CaptchaAfter is the number of failures after which a captcha is required. Values smaller than 1 disable captchas.
+	CaptchaAfter int
+	// BlockAfter is the number of failures after which requests are blocked. Values smaller than 1 disable blocking.
+	BlockAfter int
+	// Captcha verifies the captcha solution contained in a request. It is used by Request. If it is nil, captchas can not be solved.
+	// See CaptchaForm and ProofOfWorkForm for implementations using package captcha.
+	// A solution should only be accepted once, or else a single solved captcha allows unlimited requests.
+	Captcha func(r *http.Request) bool
+}
+
+// Decide returns the decision for the given keys. If the keys lead to different decisions, the strictest one is returned.
+func (e *Escalation) Decide(now time.Time, keys ...string) (Decision, error) {
+	if e.Backend == nil {
+		return Block, errors.New("no backend")
+	}
+
+	d := Allow
+	for _, k := range keys {
+		c, err := e.Backend.Count(k, now, e.Window)
+		if err != nil {
+			return Block, err
+		}
+		switch {
+		case e.BlockAfter > 0 && c >= e.BlockAfter:
+			return Block, nil
+		case e.CaptchaAfter > 0 && c >= e.CaptchaAfter:
+			d = RequireCaptcha
+		}
+	}
+	return d, nil
+}
+
+// Failure records a failure for all given keys.
+func (e *Escalation) Failure(now time.Time, keys ...string) error {
+	if e.Backend == nil {
+		return errors.New("no backend")
+	}
+
+	for _, k := range keys {
+		_, err := e.Backend.Add(k, now, e.Window)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Success removes all failures of the given keys. It should only be called with keys the successful party is responsible for (e.g. the account, but not a shared IP address).
+func (e *Escalation) Success(keys ...string) error {
+	if e.Backend == nil {
+		return errors.New("no backend")
+	}
+
+	for _, k := range keys {
+		err := e.Backend.Reset(k)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Request returns the decision for an HTTP request.
+// If a captcha is required and the request contains a valid solution (as determined by e.Captcha), Allow is returned.
+// A blocked request is never allowed.
+//
+// Request does not record anything, so you have to call Failure or Success after the request was processed.
+func (e *Escalation) Request(r *http.Request, now time.Time, keys ...string) (Decision, error) {
+	d, err := e.Decide(now, keys...)
+	if err != nil {
+		return d, err
+	}
+	if d == RequireCaptcha && e.Captcha != nil && e.Captcha(r) {
+		return Allow, nil
+	}
+	return d, nil
+}
+
+// CaptchaForm returns a function verifying a timed captcha contained in the form fields idField and captchaField of a request.
+// The captcha is verified with captcha.VerifyStringsTimed, so it must be generated by captcha.GetStringsTimed.
+// Solved captchas are marked as used in store, so every captcha satisfies only one request. store must not be nil.
+func CaptchaForm(store captcha.UseStore, idField, captchaField string, validDuration time.Duration) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		now := time.Now()
+		id := r.FormValue(idField)
+		if !captcha.VerifyStringsTimed(id, r.FormValue(captchaField), now, validDuration) {
+			return false
+		}
+		return use(store, id, now, validDuration)
+	}
+}
+
+// ProofOfWorkForm returns a function verifying a proof-of-work challenge contained in the form fields idField and nonceField of a request.
+// The challenge is verified with captcha.VerifyProofOfWorkStrings, so it must be generated by captcha.GetProofOfWorkStrings.
+// Solved challenges are marked as used in store, so every challenge satisfies only one request (regardless of the nonce). store must not be nil.
+func ProofOfWorkForm(store captcha.UseStore, idField, nonceField string, validDuration time.Duration) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		now := time.Now()
+		id := r.FormValue(idField)
+		if !captcha.VerifyProofOfWorkStrings(id, r.FormValue(nonceField), now, validDuration) {
+			return false
+		}
+		return use(store, id, now, validDuration)
+	}
+}
+
+// use marks the verified id as used and returns whether it was unused before.
+// The id is valid for at most validDuration, so the entry is not needed afterwards.
+func use(store captcha.UseStore, id string, now time.Time, validDuration time.Duration) bool {
+	// Use the canonical encoding as key, so that an id can not be reused with a different encoding.
+	i, err := base64.StdEncoding.DecodeString(id)
+	if err != nil {
+		return false
+	}
+	ok, err := store.Use(base64.StdEncoding.EncodeToString(i), now, now.Add(validDuration))
+	return err == nil && ok
+}
+
+// RemoteIP returns the IP address of the client of r without the port. It can be used as a key.
+// Headers like X-Forwarded-For are not taken into account since they can be set by the client.
+func RemoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
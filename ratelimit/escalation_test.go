@@ -0,0 +1,197 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Top-Ranger/auth/captcha"
+)
+
+func TestEscalation(t *testing.T) {
+	e := Escalation{
+		Backend:      NewMemory(),
+		Window:       1 * time.Minute,
+		CaptchaAfter: 3,
+		BlockAfter:   6,
+	}
+	testtime := time.Now()
+
+	for i := 0; i < 10; i++ {
+		d, err := e.Decide(testtime, "ip", "account")
+		if err != nil {
+			t.Logf("error occured: %s", err.Error())
+			t.FailNow()
+		}
+		var should Decision
+		switch {
+		case i >= 6:
+			should = Block
+		case i >= 3:
+			should = RequireCaptcha
+		default:
+			should = Allow
+		}
+		if d != should {
+			t.Errorf("wrong decision after %d failures (is: %s, should: %s)", i, d, should)
+		}
+		err = e.Failure(testtime, "ip", "account")
+		if err != nil {
+			t.Logf("error occured: %s", err.Error())
+			t.FailNow()
+		}
+	}
+
+	// Other keys are not affected, strictest decision wins
+	d, _ := e.Decide(testtime, "other")
+	if d != Allow {
+		t.Errorf("wrong decision for other key (is: %s, should: %s)", d, Allow)
+	}
+	d, _ = e.Decide(testtime, "other", "account")
+	if d != Block {
+		t.Errorf("wrong decision for mixed keys (is: %s, should: %s)", d, Block)
+	}
+
+	// Success
+	err := e.Success("account")
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	d, _ = e.Decide(testtime, "account")
+	if d != Allow {
+		t.Errorf("wrong decision after success (is: %s, should: %s)", d, Allow)
+	}
+
+	// Window over
+	d, _ = e.Decide(testtime.Add(5*time.Minute), "ip")
+	if d != Allow {
+		t.Errorf("wrong decision after window (is: %s, should: %s)", d, Allow)
+	}
+
+	// No backend
+	e.Backend = nil
+	d, err = e.Decide(testtime, "ip")
+	if err == nil || d != Block {
+		t.Error("no error for missing backend")
+	}
+}
+
+func TestEscalationRequest(t *testing.T) {
+	e := Escalation{
+		Backend:      NewMemory(),
+		Window:       1 * time.Minute,
+		CaptchaAfter: 1,
+		BlockAfter:   3,
+		Captcha:      CaptchaForm(captcha.NewMemoryAttemptStore(), "id", "captcha", 1*time.Minute),
+	}
+	testtime := time.Now()
+
+	i, c, err := captcha.GetStringsTimed(time.Now())
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	form := url.Values{}
+	form.Set("id", i)
+	form.Set("captcha", c)
+	withCaptcha := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+	withCaptcha.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	form.Set("captcha", "wrong")
+	wrongCaptcha := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+	wrongCaptcha.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	d, _ := e.Request(wrongCaptcha, testtime, RemoteIP(wrongCaptcha))
+	if d != Allow {
+		t.Errorf("wrong decision without failures (is: %s, should: %s)", d, Allow)
+	}
+
+	e.Failure(testtime, RemoteIP(withCaptcha))
+
+	d, _ = e.Request(wrongCaptcha, testtime, RemoteIP(wrongCaptcha))
+	if d != RequireCaptcha {
+		t.Errorf("wrong decision for wrong captcha (is: %s, should: %s)", d, RequireCaptcha)
+	}
+	d, _ = e.Request(withCaptcha, testtime, RemoteIP(withCaptcha))
+	if d != Allow {
+		t.Errorf("wrong decision for solved captcha (is: %s, should: %s)", d, Allow)
+	}
+	d, _ = e.Request(withCaptcha, testtime, RemoteIP(withCaptcha))
+	if d != RequireCaptcha {
+		t.Errorf("wrong decision for reused captcha (is: %s, should: %s)", d, RequireCaptcha)
+	}
+
+	e.Failure(testtime, RemoteIP(withCaptcha))
+	e.Failure(testtime, RemoteIP(withCaptcha))
+	d, _ = e.Request(withCaptcha, testtime, RemoteIP(withCaptcha))
+	if d != Block {
+		t.Errorf("solved captcha bypasses block (is: %s, should: %s)", d, Block)
+	}
+}
+
+func TestProofOfWorkForm(t *testing.T) {
+	i, err := captcha.GetProofOfWork(time.Now(), 4)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	n, err := captcha.SolveProofOfWork(i)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+
+	form := url.Values{}
+	form.Set("id", base64.StdEncoding.EncodeToString(i))
+	form.Set("nonce", base64.StdEncoding.EncodeToString(n))
+	r := httptest.NewRequest(http.MethodGet, "/?"+form.Encode(), nil)
+
+	f := ProofOfWorkForm(captcha.NewMemoryAttemptStore(), "id", "nonce", 1*time.Minute)
+	if !f(r) {
+		t.Error("verification failed")
+	}
+	if f(r) {
+		t.Error("verification succeeded for reused challenge")
+	}
+	if ProofOfWorkForm(captcha.NewMemoryAttemptStore(), "id", "other", 1*time.Minute)(r) {
+		t.Error("verification succeeded for missing nonce")
+	}
+}
+
+func TestRemoteIP(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "192.0.2.1:1234"
+	if ip := RemoteIP(r); ip != "192.0.2.1" {
+		t.Errorf("wrong ip (is: %s, should: %s)", ip, "192.0.2.1")
+	}
+
+	r.RemoteAddr = "[2001:db8::1]:1234"
+	if ip := RemoteIP(r); ip != "2001:db8::1" {
+		t.Errorf("wrong ip (is: %s, should: %s)", ip, "2001:db8::1")
+	}
+
+	r.RemoteAddr = "invalid"
+	if ip := RemoteIP(r); ip != "invalid" {
+		t.Errorf("wrong ip (is: %s, should: %s)", ip, "invalid")
+	}
+}
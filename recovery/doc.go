@@ -0,0 +1,21 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package recovery contains recovery codes, which allow users to log in after losing their second factor.
+// A user gets a set of codes, each of which can be used once. Only salted hashes of the codes (created with package password) are stored on the server.
+//
+// Codes only contain upper case letters and digits which can not be confused (e.g. no O and 0) and are grouped for readability (e.g. ABCD-EFGH).
+// Typed codes are normalised with captcha.NormaliseAnswer, so case, spaces and dashes do not matter.
+package recovery
@@ -0,0 +1,157 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recovery
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"strings"
+
+	"github.com/Top-Ranger/auth/captcha"
+	"github.com/Top-Ranger/auth/password"
+)
+
+// Alphabet contains the characters of a code. It has 32 characters, so every character encodes 5 bits.
+const Alphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// ErrInvalid is returned if a code does not match any stored hash.
+var ErrInvalid = errors.New("recovery: invalid code")
+
+// Generator creates sets of recovery codes.
+//
+// Can be used concurrent.
+type Generator struct {
+	// Count is the number of codes in a set.
+	Count int
+	// Length is the number of characters of a code (without separators).
+	Length int
+	// GroupSize is the number of characters between two dashes. If it is zero, codes are not grouped.
+	GroupSize int
+	// Algorithm hashes the codes. Since a code has to be checked against all hashes of a set, it should be faster than the algorithm used for passwords.
+	// Codes have a high entropy, so this is acceptable.
+	Algorithm password.Algorithm
+}
+
+// GeneratorDefault returns the suggested default configuration: 10 codes with 8 characters (40 bit) each, in groups of 4.
+func GeneratorDefault() Generator {
+	return Generator{
+		Count:     10,
+		Length:    8,
+		GroupSize: 4,
+		Algorithm: password.PBKDF2{Iterations: 10000, SaltSize: 16, KeySize: 32},
+	}
+}
+
+// Generate returns a new set of codes and their hashes. hashes[i] is the hash of codes[i].
+// The codes must be shown to the user and must not be stored. The hashes must be stored.
+func (g Generator) Generate() (codes, hashes []string, err error) {
+	if g.Count < 1 {
+		err = errors.New("count must be positive")
+		return
+	}
+	if g.Length < 1 {
+		err = errors.New("length must be positive")
+		return
+	}
+	if g.Algorithm == nil {
+		err = errors.New("no algorithm")
+		return
+	}
+
+	codes = make([]string, g.Count)
+	hashes = make([]string, g.Count)
+	for i := range codes {
+		var code string
+		code, err = randomCode(g.Length)
+		if err != nil {
+			return nil, nil, err
+		}
+		hashes[i], err = password.Hash(g.Algorithm, code)
+		if err != nil {
+			return nil, nil, err
+		}
+		codes[i] = group(code, g.GroupSize)
+	}
+	return
+}
+
+// Verify checks code against all hashes and returns the index of the matching hash.
+// The caller must burn the slot afterwards (e.g. by replacing the hash with an empty string), so that the code can not be used again. Empty hashes are skipped.
+//
+// All hashes are checked, even after a match was found, so that the time does not reveal the slot.
+// ErrInvalid is returned if no hash matches. If a hash can not be verified (e.g. ErrInvalidHash of package password) and no other hash matches, the error is returned.
+//
+// Can be used concurrent.
+func Verify(code string, hashes []string) (slot int, err error) {
+	code = captcha.NormaliseAnswer(code)
+	if code == "" {
+		return -1, ErrInvalid
+	}
+
+	slot = -1
+	var hashErr error
+	for i := range hashes {
+		if hashes[i] == "" {
+			continue
+		}
+		e := password.Verify(code, hashes[i])
+		switch {
+		case e == nil:
+			slot = subtle.ConstantTimeSelect(subtle.ConstantTimeEq(int32(slot), -1), i, slot)
+		case errors.Is(e, password.ErrMismatch):
+		default:
+			hashErr = e
+		}
+	}
+
+	if slot >= 0 {
+		return slot, nil
+	}
+	if hashErr != nil {
+		return -1, hashErr
+	}
+	return -1, ErrInvalid
+}
+
+// randomCode returns a random code of the given length.
+func randomCode(length int) (string, error) {
+	b := make([]byte, length)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	for i := range b {
+		// The alphabet has 32 characters, so there is no modulo bias.
+		b[i] = Alphabet[b[i]%byte(len(Alphabet))]
+	}
+	return string(b), nil
+}
+
+// group inserts a dash after every size characters.
+func group(code string, size int) string {
+	if size < 1 {
+		return code
+	}
+	var sb strings.Builder
+	for i := range code {
+		if i != 0 && i%size == 0 {
+			sb.WriteString("-")
+		}
+		sb.WriteByte(code[i])
+	}
+	return sb.String()
+}
@@ -0,0 +1,148 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recovery
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Top-Ranger/auth/password"
+)
+
+func testGenerator() Generator {
+	g := GeneratorDefault()
+	// Keep tests fast.
+	g.Algorithm = password.PBKDF2{Iterations: 10, SaltSize: 16, KeySize: 32}
+	return g
+}
+
+func TestGenerate(t *testing.T) {
+	g := testGenerator()
+	codes, hashes, err := g.Generate()
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if len(codes) != g.Count || len(hashes) != g.Count {
+		t.Fatalf("wrong number of codes (is: %d/%d, should: %d)", len(codes), len(hashes), g.Count)
+	}
+
+	seen := make(map[string]bool)
+	for i := range codes {
+		if len(codes[i]) != 9 || codes[i][4] != '-' {
+			t.Errorf("code %d has wrong format (is: %s)", i, codes[i])
+		}
+		for _, c := range strings.ReplaceAll(codes[i], "-", "") {
+			if !strings.ContainsRune(Alphabet, c) {
+				t.Errorf("code %d contains invalid character %c", i, c)
+			}
+		}
+		if seen[codes[i]] {
+			t.Errorf("code %d is duplicated", i)
+		}
+		seen[codes[i]] = true
+		if strings.Contains(hashes[i], codes[i]) {
+			t.Errorf("hash %d contains the code", i)
+		}
+	}
+}
+
+func TestGenerateInvalid(t *testing.T) {
+	tests := map[string]Generator{
+		"count":     {Count: 0, Length: 8, Algorithm: password.PBKDF2Default()},
+		"length":    {Count: 1, Length: 0, Algorithm: password.PBKDF2Default()},
+		"algorithm": {Count: 1, Length: 8},
+	}
+	for k := range tests {
+		t.Run(k, func(t *testing.T) {
+			_, _, err := tests[k].Generate()
+			if err == nil {
+				t.Errorf("no error returned")
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	codes, hashes, err := testGenerator().Generate()
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+
+	for i := range codes {
+		for _, c := range []string{codes[i], strings.ToLower(codes[i]), strings.ReplaceAll(codes[i], "-", ""), " " + strings.ReplaceAll(codes[i], "-", " ") + " "} {
+			slot, err := Verify(c, hashes)
+			if err != nil {
+				t.Errorf("code %d (%s) not accepted: %s", i, c, err.Error())
+				continue
+			}
+			if slot != i {
+				t.Errorf("wrong slot (is: %d, should: %d)", slot, i)
+			}
+		}
+	}
+
+	for _, c := range []string{"", "-", "AAAA-AAAA", codes[0] + "A"} {
+		_, err := Verify(c, hashes)
+		if !errors.Is(err, ErrInvalid) {
+			t.Errorf("invalid code %s not rejected (is: %v, should: %v)", c, err, ErrInvalid)
+		}
+	}
+}
+
+func TestVerifyBurned(t *testing.T) {
+	codes, hashes, err := testGenerator().Generate()
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+
+	slot, err := Verify(codes[3], hashes)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	hashes[slot] = ""
+
+	_, err = Verify(codes[3], hashes)
+	if !errors.Is(err, ErrInvalid) {
+		t.Errorf("burned code not rejected (is: %v, should: %v)", err, ErrInvalid)
+	}
+	slot, err = Verify(codes[4], hashes)
+	if err != nil || slot != 4 {
+		t.Errorf("other code not accepted (is: %d %v, should: 4 <nil>)", slot, err)
+	}
+}
+
+func TestVerifyInvalidHash(t *testing.T) {
+	codes, hashes, err := testGenerator().Generate()
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	hashes[0] = "invalid"
+
+	slot, err := Verify(codes[1], hashes)
+	if err != nil || slot != 1 {
+		t.Errorf("valid code not accepted (is: %d %v, should: 1 <nil>)", slot, err)
+	}
+	_, err = Verify(codes[0], hashes)
+	if err == nil || errors.Is(err, ErrInvalid) {
+		t.Errorf("hash error not returned (is: %v)", err)
+	}
+}
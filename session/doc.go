@@ -0,0 +1,23 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package session contains a server-side session management.
+// Session ids are cryptographically random and are stored in a cookie, which is signed using package cookie (and therefore package data).
+// The session data is kept in a Store on the server. An in-memory store and a file system store are included.
+//
+// Sessions end after an idle timeout and an absolute timeout. The id of a session should be renewed whenever the privileges of the user change (e.g. after login) to prevent session fixation.
+//
+// The middleware returned by Manager.Handler loads the session into the context of the request and saves it afterwards.
+package session
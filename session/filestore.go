@@ -0,0 +1,162 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// fileStoreSuffix is the suffix of all files of a FileStore.
+const fileStoreSuffix = ".session"
+
+// FileStore is a Store keeping each session in a file.
+// The files only contain a hash of the session id and their names are derived from it, so the ids can not be read from the directory.
+//
+// Sessions survive a restart of the program. Please note that the session cookies are signed with package data by default, so they become invalid anyway unless explicit keys are configured.
+type FileStore struct {
+	dir string
+}
+
+// fileRecord is the content of a session file. It contains the hash of the session id instead of the id.
+type fileRecord struct {
+	IDHash     string
+	Created    time.Time
+	LastAccess time.Time
+	Values     map[string]string
+}
+
+// NewFileStore returns a FileStore using dir. The directory is created if it does not exist.
+func NewFileStore(dir string) (*FileStore, error) {
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// Load returns the session with the given id. See Store for more information.
+func (s *FileStore) Load(id string) (Record, error) {
+	b, err := ioutil.ReadFile(s.path(id))
+	if os.IsNotExist(err) {
+		return Record{}, ErrNotFound
+	}
+	if err != nil {
+		return Record{}, err
+	}
+
+	var f fileRecord
+	err = json.Unmarshal(b, &f)
+	if err != nil {
+		return Record{}, err
+	}
+	if subtle.ConstantTimeCompare([]byte(f.IDHash), []byte(hashID(id))) == 0 {
+		return Record{}, ErrNotFound
+	}
+	r := Record{ID: id, Created: f.Created, LastAccess: f.LastAccess, Values: f.Values}
+	if r.Values == nil {
+		r.Values = make(map[string]string)
+	}
+	return r, nil
+}
+
+// Save stores a session. See Store for more information.
+// The file is replaced atomically, so concurrent readers never see a partially written session.
+func (s *FileStore) Save(r Record) error {
+	b, err := json.Marshal(fileRecord{IDHash: hashID(r.ID), Created: r.Created, LastAccess: r.LastAccess, Values: r.Values})
+	if err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(s.dir, "tmp-")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(b)
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	err = f.Close()
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	err = os.Rename(f.Name(), s.path(r.ID))
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return nil
+}
+
+// Delete removes the session with the given id. See Store for more information.
+func (s *FileStore) Delete(id string) error {
+	err := os.Remove(s.path(id))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Prune removes all sessions which were last accessed before the given time.
+// It should be called regularly with the oldest access time still valid for the idle timeout.
+func (s *FileStore) Prune(lastAccessBefore time.Time) error {
+	files, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), fileStoreSuffix) {
+			continue
+		}
+		p := filepath.Join(s.dir, f.Name())
+		b, err := ioutil.ReadFile(p)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		var r fileRecord
+		if json.Unmarshal(b, &r) != nil || r.LastAccess.Before(lastAccessBefore) {
+			err = os.Remove(p)
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// path returns the file name of the session with the given id.
+func (s *FileStore) path(id string) string {
+	return filepath.Join(s.dir, hashID(id)+fileStoreSuffix)
+}
+
+// hashID returns the hex encoded hash of a session id.
+func hashID(id string) string {
+	h := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(h[:])
+}
@@ -0,0 +1,101 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "session")
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	defer os.RemoveAll(dir)
+
+	s, err := NewFileStore(filepath.Join(dir, "sessions"))
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	testStore(t, s)
+
+	// Ids are not visible and can not escape the directory
+	err = s.Save(Record{ID: "../secret-id"})
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	files, _ := ioutil.ReadDir(filepath.Join(dir, "sessions"))
+	if len(files) != 1 {
+		t.Fatalf("wrong number of files (is: %d, should: %d)", len(files), 1)
+	}
+	if strings.Contains(files[0].Name(), "secret") {
+		t.Errorf("id visible in file name: %s", files[0].Name())
+	}
+	b, err := ioutil.ReadFile(filepath.Join(dir, "sessions", files[0].Name()))
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if strings.Contains(string(b), "secret") {
+		t.Errorf("id visible in file: %s", string(b))
+	}
+}
+
+func TestFileStorePrune(t *testing.T) {
+	dir, err := ioutil.TempDir("", "session")
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	defer os.RemoveAll(dir)
+
+	s, err := NewFileStore(dir)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	testtime := time.Now()
+	s.Save(Record{ID: "old", LastAccess: testtime.Add(-2 * time.Hour)})
+	s.Save(Record{ID: "new", LastAccess: testtime})
+	ioutil.WriteFile(filepath.Join(dir, "other"), []byte("other"), 0600)
+
+	err = s.Prune(testtime.Add(-1 * time.Hour))
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	_, err = s.Load("old")
+	if !errors.Is(err, ErrNotFound) {
+		t.Error("old session not pruned")
+	}
+	_, err = s.Load("new")
+	if err != nil {
+		t.Error("new session pruned")
+	}
+	_, err = os.Stat(filepath.Join(dir, "other"))
+	if err != nil {
+		t.Error("other file removed")
+	}
+}
@@ -0,0 +1,236 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Top-Ranger/auth/cookie"
+)
+
+// CookieNameDefault is the default name of the session cookie.
+const CookieNameDefault = "session"
+
+type contextKey int
+
+const contextSession contextKey = iota
+
+// Manager loads and saves sessions for HTTP requests.
+//
+// Can be used concurrent.
+type Manager struct {
+	// Store stores the sessions.
+	Store Store
+	// IdleTimeout ends a session if it was not used for the given time. If it is zero, there is no idle timeout.
+	IdleTimeout time.Duration
+	// AbsoluteTimeout ends a session after the given time, regardless of its use. If it is zero, there is no absolute timeout.
+	AbsoluteTimeout time.Duration
+	// CookieName is the name of the session cookie. If it is empty, CookieNameDefault is used.
+	CookieName string
+	// Codec signs the session cookie. If it is nil, a cookie.Codec with default settings is used.
+	Codec *cookie.Codec
+	// Secure determines whether the cookie should only be sent over HTTPS.
+	Secure bool
+	// ErrorLog is called for errors which can not be returned (e.g. when saving a session fails). If it is nil, errors are written to the standard logger.
+	ErrorLog func(err error)
+}
+
+// FromContext returns the session stored in ctx by the middleware of a Manager, or nil if there is none.
+func FromContext(ctx context.Context) *Session {
+	s, ok := ctx.Value(contextSession).(*Session)
+	if !ok {
+		return nil
+	}
+	return s
+}
+
+// Handler returns a middleware which loads the session of the request into its context (see FromContext) and saves it afterwards.
+// A new session is created if the request has no valid session. New sessions are only stored once they are modified.
+//
+// The session is saved and its cookie is set before the response is written. Changes made afterwards are saved when next returns, but a renewed id can no longer be sent to the client.
+func (m *Manager) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if m.Store == nil {
+			m.logError(errors.New("session: no store"))
+			http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		now := time.Now()
+		s, err := m.load(r, now)
+		if err != nil {
+			m.logError(err)
+			http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		w := &responseWriter{ResponseWriter: rw, commit: func() {
+			m.commit(rw, s, true)
+		}}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextSession, s)))
+
+		if !w.committed {
+			w.commitOnce()
+			return
+		}
+		// Save changes made after the response was written.
+		m.commit(rw, s, false)
+	})
+}
+
+// load returns the session of r or a new session.
+func (m *Manager) load(r *http.Request, now time.Time) (*Session, error) {
+	id, err := m.codec().Cookie(r, m.cookieName())
+	if err != nil {
+		return newSession(now)
+	}
+
+	record, err := m.Store.Load(string(id))
+	if errors.Is(err, ErrNotFound) {
+		return newSession(now)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if (m.IdleTimeout > 0 && now.Sub(record.LastAccess) > m.IdleTimeout) || (m.AbsoluteTimeout > 0 && now.Sub(record.Created) > m.AbsoluteTimeout) {
+		err = m.Store.Delete(record.ID)
+		if err != nil {
+			return nil, err
+		}
+		return newSession(now)
+	}
+
+	record.LastAccess = now
+	if record.Values == nil {
+		record.Values = make(map[string]string)
+	}
+	return &Session{record: record, stored: true}, nil
+}
+
+// commit saves s and, if setCookie is true, updates the cookie.
+func (m *Manager) commit(rw http.ResponseWriter, s *Session, setCookie bool) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if s.destroyed {
+		ids := []string{s.record.ID, s.oldID}
+		for _, id := range ids {
+			if id == "" {
+				continue
+			}
+			err := m.Store.Delete(id)
+			if err != nil {
+				m.logError(err)
+			}
+		}
+		if setCookie && (s.stored || s.oldID != "") {
+			http.SetCookie(rw, &http.Cookie{Name: m.cookieName(), Path: "/", MaxAge: -1, HttpOnly: true, Secure: m.Secure})
+		}
+		s.stored = false
+		s.oldID = ""
+		return
+	}
+
+	if !s.modified && (!s.stored || !setCookie) {
+		// Do not store sessions of visitors who never used them.
+		// Unmodified sessions are only saved once per request to update the last access.
+		return
+	}
+
+	newID := !s.stored || s.oldID != ""
+	if s.oldID != "" {
+		err := m.Store.Delete(s.oldID)
+		if err != nil {
+			m.logError(err)
+			return
+		}
+	}
+	err := m.Store.Save(s.record)
+	if err != nil {
+		m.logError(err)
+		return
+	}
+	s.stored = true
+	s.oldID = ""
+	s.modified = false
+
+	if setCookie && newID {
+		err = m.codec().SetCookie(rw, &http.Cookie{Name: m.cookieName(), Path: "/", HttpOnly: true, Secure: m.Secure, SameSite: http.SameSiteLaxMode}, []byte(s.record.ID))
+		if err != nil {
+			m.logError(err)
+		}
+	}
+}
+
+func (m *Manager) codec() *cookie.Codec {
+	if m.Codec == nil {
+		return &cookie.Codec{}
+	}
+	return m.Codec
+}
+
+func (m *Manager) cookieName() string {
+	if m.CookieName == "" {
+		return CookieNameDefault
+	}
+	return m.CookieName
+}
+
+func (m *Manager) logError(err error) {
+	if m.ErrorLog != nil {
+		m.ErrorLog(err)
+		return
+	}
+	log.Printf("session: %s", err.Error())
+}
+
+// responseWriter commits the session before the response is written.
+type responseWriter struct {
+	http.ResponseWriter
+	commit    func()
+	committed bool
+}
+
+func (w *responseWriter) WriteHeader(statusCode int) {
+	w.commitOnce()
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.commitOnce()
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher if the underlying ResponseWriter supports it.
+func (w *responseWriter) Flush() {
+	w.commitOnce()
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *responseWriter) commitOnce() {
+	if w.committed {
+		return
+	}
+	w.committed = true
+	w.commit()
+}
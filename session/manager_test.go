@@ -0,0 +1,226 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Top-Ranger/auth/cookie"
+)
+
+// testRequest sends a request through the middleware of m, calling f with the session, and returns the response.
+func testRequest(m *Manager, cookies []*http.Cookie, f func(s *Session)) *http.Response {
+	h := m.Handler(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		f(FromContext(r.Context()))
+		rw.Write([]byte("ok"))
+	}))
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	for i := range cookies {
+		r.AddCookie(cookies[i])
+	}
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, r)
+	return rw.Result()
+}
+
+func TestManager(t *testing.T) {
+	store := NewMemoryStore()
+	m := &Manager{Store: store}
+
+	// Unused sessions are not stored
+	res := testRequest(m, nil, func(s *Session) {
+		if s == nil {
+			t.Fatal("no session in context")
+		}
+	})
+	if len(res.Cookies()) != 0 {
+		t.Errorf("cookie set for unused session")
+	}
+	if len(store.sessions) != 0 {
+		t.Errorf("unused session stored")
+	}
+
+	var id string
+	res = testRequest(m, nil, func(s *Session) {
+		id = s.ID()
+		s.Set("key", "value")
+	})
+	cookies := res.Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("wrong number of cookies (is: %d, should: %d)", len(cookies), 1)
+	}
+	if cookies[0].Value == id {
+		t.Errorf("cookie not signed")
+	}
+	if _, err := store.Load(id); err != nil {
+		t.Errorf("session not stored: %s", err)
+	}
+
+	res = testRequest(m, cookies, func(s *Session) {
+		if s.ID() != id {
+			t.Errorf("wrong session (is: %s, should: %s)", s.ID(), id)
+		}
+		if v, _ := s.Get("key"); v != "value" {
+			t.Errorf("wrong value (is: %s, should: %s)", v, "value")
+		}
+	})
+	if len(res.Cookies()) != 0 {
+		t.Errorf("cookie set again for known session")
+	}
+
+	// Modified cookies are rejected
+	modified := *cookies[0]
+	modified.Value = id
+	testRequest(m, []*http.Cookie{&modified}, func(s *Session) {
+		if s.ID() == id {
+			t.Errorf("session loaded from unsigned cookie")
+		}
+	})
+}
+
+func TestManagerRenew(t *testing.T) {
+	store := NewMemoryStore()
+	m := &Manager{Store: store, Codec: &cookie.Codec{Keys: [][]byte{[]byte("key")}}}
+
+	var id string
+	res := testRequest(m, nil, func(s *Session) {
+		id = s.ID()
+		s.Set("key", "value")
+	})
+	cookies := res.Cookies()
+
+	var newID string
+	res = testRequest(m, cookies, func(s *Session) {
+		err := s.Renew()
+		if err != nil {
+			t.Logf("error occured: %s", err.Error())
+			t.FailNow()
+		}
+		newID = s.ID()
+	})
+	if newID == id {
+		t.Fatal("id not renewed")
+	}
+	if _, err := store.Load(id); !errors.Is(err, ErrNotFound) {
+		t.Errorf("old session not deleted")
+	}
+	if len(res.Cookies()) != 1 {
+		t.Fatalf("no cookie set for renewed session")
+	}
+
+	testRequest(m, cookies, func(s *Session) {
+		if s.ID() == id {
+			t.Errorf("old id still valid")
+		}
+	})
+	testRequest(m, res.Cookies(), func(s *Session) {
+		if s.ID() != newID {
+			t.Errorf("wrong session (is: %s, should: %s)", s.ID(), newID)
+		}
+		if v, _ := s.Get("key"); v != "value" {
+			t.Errorf("value lost after renew")
+		}
+	})
+}
+
+func TestManagerDestroy(t *testing.T) {
+	store := NewMemoryStore()
+	m := &Manager{Store: store}
+
+	var id string
+	res := testRequest(m, nil, func(s *Session) {
+		id = s.ID()
+		s.Set("key", "value")
+	})
+	cookies := res.Cookies()
+
+	res = testRequest(m, cookies, func(s *Session) {
+		s.Destroy()
+	})
+	if _, err := store.Load(id); !errors.Is(err, ErrNotFound) {
+		t.Errorf("destroyed session not deleted")
+	}
+	if len(res.Cookies()) != 1 || res.Cookies()[0].MaxAge >= 0 {
+		t.Errorf("cookie not deleted")
+	}
+}
+
+func TestManagerTimeout(t *testing.T) {
+	store := NewMemoryStore()
+	m := &Manager{Store: store, IdleTimeout: time.Hour, AbsoluteTimeout: 24 * time.Hour}
+
+	var id string
+	res := testRequest(m, nil, func(s *Session) {
+		id = s.ID()
+		s.Set("key", "value")
+	})
+	cookies := res.Cookies()
+
+	// Last access updated
+	r, _ := store.Load(id)
+	r.LastAccess = r.LastAccess.Add(-30 * time.Minute)
+	store.Save(r)
+	testRequest(m, cookies, func(s *Session) {
+		if s.ID() != id {
+			t.Errorf("session expired too early")
+		}
+	})
+	r, _ = store.Load(id)
+	if time.Since(r.LastAccess) > time.Minute {
+		t.Errorf("last access not updated")
+	}
+
+	// Idle timeout
+	r.LastAccess = r.LastAccess.Add(-2 * time.Hour)
+	store.Save(r)
+	testRequest(m, cookies, func(s *Session) {
+		if s.ID() == id {
+			t.Errorf("idle timeout not enforced")
+		}
+	})
+	if _, err := store.Load(id); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expired session not deleted")
+	}
+
+	// Absolute timeout
+	res = testRequest(m, nil, func(s *Session) {
+		id = s.ID()
+		s.Set("key", "value")
+	})
+	cookies = res.Cookies()
+	r, _ = store.Load(id)
+	r.Created = r.Created.Add(-25 * time.Hour)
+	store.Save(r)
+	testRequest(m, cookies, func(s *Session) {
+		if s.ID() == id {
+			t.Errorf("absolute timeout not enforced")
+		}
+	})
+}
+
+func TestManagerNoStore(t *testing.T) {
+	m := &Manager{ErrorLog: func(error) {}}
+	res := testRequest(m, nil, func(s *Session) {
+		t.Error("handler called without store")
+	})
+	if res.StatusCode != http.StatusInternalServerError {
+		t.Errorf("wrong status code (is: %d, should: %d)", res.StatusCode, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,152 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"sync"
+	"time"
+)
+
+// idSize is the number of random bytes of a session id.
+const idSize = 32
+
+// Record contains the stored data of a session.
+type Record struct {
+	// ID is the id of the session.
+	ID string
+	// Created is the time the session was created.
+	Created time.Time
+	// LastAccess is the time of the last request using the session.
+	LastAccess time.Time
+	// Values contains the values stored in the session.
+	Values map[string]string
+}
+
+// copyRecord returns a deep copy of r.
+func copyRecord(r Record) Record {
+	values := make(map[string]string, len(r.Values))
+	for k, v := range r.Values {
+		values[k] = v
+	}
+	r.Values = values
+	return r
+}
+
+// Session is the session of a request.
+//
+// Can be used concurrent.
+type Session struct {
+	m         sync.Mutex
+	record    Record
+	oldID     string
+	stored    bool
+	modified  bool
+	destroyed bool
+}
+
+// newID returns a new random session id.
+func newID() (string, error) {
+	b := make([]byte, idSize)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// newSession returns a new, empty session.
+func newSession(now time.Time) (*Session, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	return &Session{
+		record: Record{
+			ID:         id,
+			Created:    now,
+			LastAccess: now,
+			Values:     make(map[string]string),
+		},
+	}, nil
+}
+
+// ID returns the id of the session.
+func (s *Session) ID() string {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.record.ID
+}
+
+// Created returns the time the session was created.
+func (s *Session) Created() time.Time {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.record.Created
+}
+
+// Get returns the value stored for key.
+func (s *Session) Get(key string) (string, bool) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	v, ok := s.record.Values[key]
+	return v, ok
+}
+
+// Set stores a value for key.
+func (s *Session) Set(key, value string) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.record.Values[key] = value
+	s.modified = true
+}
+
+// Delete removes the value stored for key.
+func (s *Session) Delete(key string) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	delete(s.record.Values, key)
+	s.modified = true
+}
+
+// Renew gives the session a new id while keeping its values. The old id becomes invalid.
+// It should be called whenever the privileges of the user change (e.g. after login).
+// Renew must be called before the response is written, or else the new id can not be sent to the client.
+func (s *Session) Renew() error {
+	id, err := newID()
+	if err != nil {
+		return err
+	}
+
+	s.m.Lock()
+	defer s.m.Unlock()
+	if s.oldID == "" && s.stored {
+		s.oldID = s.record.ID
+	}
+	s.record.ID = id
+	s.modified = true
+	return nil
+}
+
+// Destroy ends the session. Its values are removed and the cookie is deleted.
+// Destroy must be called before the response is written, or else the cookie can not be deleted.
+func (s *Session) Destroy() {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.destroyed = true
+	s.record.Values = make(map[string]string)
+}
@@ -0,0 +1,125 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+)
+
+func TestNewSession(t *testing.T) {
+	testtime := time.Now()
+	s, err := newSession(testtime)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	b, err := base64.RawURLEncoding.DecodeString(s.ID())
+	if err != nil || len(b) != idSize {
+		t.Errorf("invalid id: %s", s.ID())
+	}
+	if !s.Created().Equal(testtime) {
+		t.Errorf("wrong creation time (is: %s, should: %s)", s.Created(), testtime)
+	}
+
+	// simple random test
+	for x := 0; x < 100; x++ {
+		s2, err := newSession(testtime)
+		if err != nil {
+			t.Logf("error occured: %s", err.Error())
+			t.FailNow()
+		}
+		if s.ID() == s2.ID() {
+			t.Errorf("simple random error failed: %s == %s", s.ID(), s2.ID())
+			break
+		}
+	}
+}
+
+func TestSessionValues(t *testing.T) {
+	s, err := newSession(time.Now())
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+
+	_, ok := s.Get("key")
+	if ok {
+		t.Error("value of empty session found")
+	}
+	if s.modified {
+		t.Error("session modified by Get")
+	}
+
+	s.Set("key", "value")
+	v, ok := s.Get("key")
+	if !ok || v != "value" {
+		t.Errorf("wrong value (is: %s, should: %s)", v, "value")
+	}
+	if !s.modified {
+		t.Error("session not modified by Set")
+	}
+
+	s.Delete("key")
+	_, ok = s.Get("key")
+	if ok {
+		t.Error("deleted value found")
+	}
+
+	s.Set("key", "value")
+	s.Destroy()
+	_, ok = s.Get("key")
+	if ok {
+		t.Error("value of destroyed session found")
+	}
+}
+
+func TestSessionRenew(t *testing.T) {
+	s, err := newSession(time.Now())
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	s.Set("key", "value")
+	s.stored = true
+	id := s.ID()
+
+	err = s.Renew()
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if s.ID() == id {
+		t.Error("id not changed")
+	}
+	if s.oldID != id {
+		t.Errorf("wrong old id (is: %s, should: %s)", s.oldID, id)
+	}
+	if v, _ := s.Get("key"); v != "value" {
+		t.Error("value lost")
+	}
+
+	// The stored id is kept when renewing twice
+	err = s.Renew()
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if s.oldID != id {
+		t.Errorf("wrong old id after second renew (is: %s, should: %s)", s.oldID, id)
+	}
+}
@@ -0,0 +1,95 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned by a Store if no session with the given id exists.
+var ErrNotFound = errors.New("session: not found")
+
+// Store stores sessions on the server.
+//
+// All methods must be safe for concurrent use.
+type Store interface {
+	// Load returns the session with the given id. If it does not exist, ErrNotFound is returned.
+	Load(id string) (Record, error)
+	// Save stores a session, replacing an existing session with the same id.
+	Save(r Record) error
+	// Delete removes the session with the given id. Deleting a session which does not exist is not an error.
+	Delete(id string) error
+}
+
+// MemoryStore is an in-memory Store.
+// Sessions are lost whenever the program restarts.
+type MemoryStore struct {
+	m        sync.Mutex
+	sessions map[string]Record
+}
+
+// NewMemoryStore returns a new, empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		sessions: make(map[string]Record),
+	}
+}
+
+// Load returns the session with the given id. See Store for more information.
+func (s *MemoryStore) Load(id string) (Record, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	r, ok := s.sessions[id]
+	if !ok {
+		return Record{}, ErrNotFound
+	}
+	return copyRecord(r), nil
+}
+
+// Save stores a session. See Store for more information.
+func (s *MemoryStore) Save(r Record) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	s.sessions[r.ID] = copyRecord(r)
+	return nil
+}
+
+// Delete removes the session with the given id. See Store for more information.
+func (s *MemoryStore) Delete(id string) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	delete(s.sessions, id)
+	return nil
+}
+
+// Prune removes all sessions which were last accessed before the given time.
+// It should be called regularly with the oldest access time still valid for the idle timeout.
+func (s *MemoryStore) Prune(lastAccessBefore time.Time) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	for k, v := range s.sessions {
+		if v.LastAccess.Before(lastAccessBefore) {
+			delete(s.sessions, k)
+		}
+	}
+	return nil
+}
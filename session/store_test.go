@@ -0,0 +1,106 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// testStore runs tests common to all stores.
+func testStore(t *testing.T, s Store) {
+	testtime := time.Now().Round(0)
+	r := Record{
+		ID:         "id",
+		Created:    testtime,
+		LastAccess: testtime,
+		Values:     map[string]string{"key": "value"},
+	}
+
+	_, err := s.Load("id")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("wrong error for missing session (is: %v, should: %v)", err, ErrNotFound)
+	}
+
+	err = s.Save(r)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+
+	// Modifying the saved record must not change the store
+	r.Values["key"] = "modified"
+
+	l, err := s.Load("id")
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if l.ID != "id" || !l.Created.Equal(testtime) || !l.LastAccess.Equal(testtime) || l.Values["key"] != "value" {
+		t.Errorf("wrong record: %+v", l)
+	}
+
+	err = s.Save(r)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	l, _ = s.Load("id")
+	if l.Values["key"] != "modified" {
+		t.Errorf("record not replaced: %+v", l)
+	}
+
+	err = s.Delete("id")
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	_, err = s.Load("id")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("wrong error for deleted session (is: %v, should: %v)", err, ErrNotFound)
+	}
+
+	err = s.Delete("id")
+	if err != nil {
+		t.Errorf("error deleting missing session: %s", err.Error())
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+}
+
+func TestMemoryStorePrune(t *testing.T) {
+	s := NewMemoryStore()
+	testtime := time.Now()
+	s.Save(Record{ID: "old", LastAccess: testtime.Add(-2 * time.Hour)})
+	s.Save(Record{ID: "new", LastAccess: testtime})
+
+	err := s.Prune(testtime.Add(-1 * time.Hour))
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	_, err = s.Load("old")
+	if !errors.Is(err, ErrNotFound) {
+		t.Error("old session not pruned")
+	}
+	_, err = s.Load("new")
+	if err != nil {
+		t.Error("new session pruned")
+	}
+}
@@ -0,0 +1,22 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package signedurl contains methods to create URLs which expire and can not be modified, e.g. for downloads or unsubscribe links.
+// The path and the sorted query parameters of a URL are authenticated with the timed authentification of package data. The expiry and the signature are appended as query parameters.
+// Optionally, a signature can be bound to an HTTP method or cover all paths below a prefix.
+//
+// The host of a URL is not signed, so signed URLs keep working behind proxies.
+// Since package data is used, all signed URLs become invalid whenever the program restarts.
+package signedurl
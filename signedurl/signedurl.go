@@ -0,0 +1,206 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signedurl
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Top-Ranger/auth/data"
+)
+
+const (
+	// ExpiresParameter is the query parameter containing the expiry as Unix time.
+	ExpiresParameter = "expires"
+
+	// MethodParameter is the query parameter containing the HTTP method the URL is bound to.
+	MethodParameter = "method"
+
+	// PrefixParameter is the query parameter containing the signed path prefix.
+	PrefixParameter = "prefix"
+
+	// SignatureParameter is the query parameter containing the signature.
+	SignatureParameter = "signature"
+
+	// MaxValidDurationDefault contains the suggested default for the longest validity a verifier accepts.
+	MaxValidDurationDefault = 7 * 24 * time.Hour
+)
+
+var (
+	// ErrInvalid is returned if a URL is not signed, was modified or is used with the wrong method.
+	ErrInvalid = errors.New("signedurl: invalid signature")
+
+	// ErrExpired is returned if a URL is expired.
+	ErrExpired = errors.New("signedurl: expired")
+)
+
+type contextKey int
+
+const contextFailure contextKey = iota
+
+// Options contains optional restrictions of a signed URL.
+type Options struct {
+	// Method binds the signature to an HTTP method. If it is empty, all methods are allowed.
+	Method string
+	// Prefix signs only a path prefix, so that all paths below it are allowed. If it is empty, only the exact path is allowed.
+	// The path of the URL must be below the prefix.
+	Prefix string
+}
+
+// Sign returns a signed copy of u, which is valid from now for validDuration.
+// u must not contain any of the query parameters used by this package.
+//
+// Can be used concurrent.
+func Sign(u *url.URL, now time.Time, validDuration time.Duration, options Options) (*url.URL, error) {
+	if validDuration <= 0 {
+		return nil, errors.New("validDuration must be positive")
+	}
+
+	signed := *u
+	q := signed.Query()
+	for _, p := range []string{ExpiresParameter, MethodParameter, PrefixParameter, SignatureParameter} {
+		if _, ok := q[p]; ok {
+			return nil, errors.New("url already contains parameter " + p)
+		}
+	}
+
+	q.Set(ExpiresParameter, strconv.FormatInt(now.Add(validDuration).Unix(), 10))
+	if options.Method != "" {
+		q.Set(MethodParameter, strings.ToUpper(options.Method))
+	}
+	if options.Prefix != "" {
+		if !hasPathPrefix(u.Path, options.Prefix) {
+			return nil, errors.New("path is not below prefix")
+		}
+		q.Set(PrefixParameter, options.Prefix)
+	}
+
+	id, err := data.GetTimed(now, canonical(&signed, q))
+	if err != nil {
+		return nil, err
+	}
+	q.Set(SignatureParameter, base64.RawURLEncoding.EncodeToString(id))
+	signed.RawQuery = q.Encode()
+	return &signed, nil
+}
+
+// Verify checks whether u is signed, not modified and not expired. method is the HTTP method of the request.
+// maxValidDuration is the longest validity accepted, signatures older than it are rejected even if the URL is not expired.
+//
+// ErrInvalid is returned if the signature does not match, ErrExpired if the URL is expired.
+//
+// Can be used concurrent.
+func Verify(u *url.URL, method string, now time.Time, maxValidDuration time.Duration) error {
+	q := u.Query()
+	if len(q[SignatureParameter]) != 1 || len(q[ExpiresParameter]) != 1 {
+		return ErrInvalid
+	}
+	id, err := base64.RawURLEncoding.DecodeString(q.Get(SignatureParameter))
+	if err != nil {
+		return ErrInvalid
+	}
+	q.Del(SignatureParameter)
+
+	if !data.VerifyTimed(id, canonical(u, q), now, maxValidDuration) {
+		return ErrInvalid
+	}
+
+	if prefix, ok := q[PrefixParameter]; ok {
+		if len(prefix) != 1 || !hasPathPrefix(u.Path, prefix[0]) {
+			return ErrInvalid
+		}
+	}
+	if m, ok := q[MethodParameter]; ok {
+		if len(m) != 1 || !strings.EqualFold(m[0], method) {
+			return ErrInvalid
+		}
+	}
+
+	expires, err := strconv.ParseInt(q.Get(ExpiresParameter), 10, 64)
+	if err != nil {
+		return ErrInvalid
+	}
+	if now.Unix() > expires {
+		return ErrExpired
+	}
+	return nil
+}
+
+// Verifier is a middleware which rejects requests without a valid signed URL.
+type Verifier struct {
+	// MaxValidDuration is the longest validity accepted. If it is zero, MaxValidDurationDefault is used.
+	MaxValidDuration time.Duration
+	// ErrorHandler is called for rejected requests. The reason can be retrieved with FailureReason. If it is nil, 403 Forbidden is returned.
+	ErrorHandler http.Handler
+}
+
+// Handler returns a middleware which verifies the URL of each request before passing it to next.
+func (v *Verifier) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		maxValidDuration := v.MaxValidDuration
+		if maxValidDuration == 0 {
+			maxValidDuration = MaxValidDurationDefault
+		}
+
+		err := Verify(r.URL, r.Method, time.Now(), maxValidDuration)
+		if err != nil {
+			r = r.WithContext(context.WithValue(r.Context(), contextFailure, err))
+			if v.ErrorHandler != nil {
+				v.ErrorHandler.ServeHTTP(rw, r)
+				return
+			}
+			http.Error(rw, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// FailureReason returns the reason a request was rejected. It can be used in the ErrorHandler of a Verifier.
+func FailureReason(r *http.Request) error {
+	err, ok := r.Context().Value(contextFailure).(error)
+	if !ok {
+		return nil
+	}
+	return err
+}
+
+// canonical returns the signed representation of u with the query q (which must not contain the signature).
+// Prefix-signed URLs do not contain the path since the prefix is part of the query.
+func canonical(u *url.URL, q url.Values) []byte {
+	p := u.EscapedPath()
+	if _, ok := q[PrefixParameter]; ok {
+		p = ""
+	}
+	return []byte(strings.Join([]string{"signedurl", p, q.Encode()}, "\n"))
+}
+
+// hasPathPrefix returns whether p is equal to prefix or below it. Both paths are cleaned first, so that "/a/../b" is not below "/a".
+func hasPathPrefix(p, prefix string) bool {
+	p = path.Clean("/" + p)
+	prefix = path.Clean("/" + prefix)
+	if prefix == "/" {
+		return true
+	}
+	return p == prefix || strings.HasPrefix(p, prefix+"/")
+}
@@ -0,0 +1,219 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signedurl
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestSign(t *testing.T) {
+	testtime := time.Now()
+	u, _ := url.Parse("https://example.com/export?format=csv&id=5")
+
+	s, err := Sign(u, testtime, 1*time.Hour, Options{})
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	q := s.Query()
+	if q.Get(SignatureParameter) == "" || q.Get(ExpiresParameter) == "" {
+		t.Errorf("parameters missing: %s", s.String())
+	}
+	if u.RawQuery != "format=csv&id=5" {
+		t.Errorf("original url modified: %s", u.String())
+	}
+
+	_, err = Sign(s, testtime, 1*time.Hour, Options{})
+	if err == nil {
+		t.Error("no error for signing signed url")
+	}
+
+	_, err = Sign(u, testtime, 0, Options{})
+	if err == nil {
+		t.Error("no error for invalid duration")
+	}
+
+	_, err = Sign(u, testtime, 1*time.Hour, Options{Prefix: "/other"})
+	if err == nil {
+		t.Error("no error for path outside of prefix")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	testtime := time.Now()
+	u, _ := url.Parse("https://example.com/export?id=5&format=csv")
+	s, err := Sign(u, testtime, 1*time.Hour, Options{})
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+
+	// Correct
+	err = Verify(s, http.MethodGet, testtime.Add(2*time.Second), MaxValidDurationDefault)
+	if err != nil {
+		t.Errorf("verification failed: %s", err.Error())
+	}
+	err = Verify(s, http.MethodPost, testtime.Add(2*time.Second), MaxValidDurationDefault)
+	if err != nil {
+		t.Errorf("verification failed for other method: %s", err.Error())
+	}
+
+	// Query order does not matter
+	reordered := *s
+	q := s.Query()
+	reordered.RawQuery = "format=csv&" + SignatureParameter + "=" + q.Get(SignatureParameter) + "&id=5&" + ExpiresParameter + "=" + q.Get(ExpiresParameter)
+	err = Verify(&reordered, http.MethodGet, testtime, MaxValidDurationDefault)
+	if err != nil {
+		t.Errorf("verification failed for reordered query: %s", err.Error())
+	}
+
+	// Expired
+	err = Verify(s, http.MethodGet, testtime.Add(2*time.Hour), MaxValidDurationDefault)
+	if !errors.Is(err, ErrExpired) {
+		t.Errorf("wrong error for expired url (is: %v, should: %v)", err, ErrExpired)
+	}
+
+	// Older than maximum
+	err = Verify(s, http.MethodGet, testtime.Add(2*time.Second), 1*time.Second)
+	if !errors.Is(err, ErrInvalid) {
+		t.Errorf("wrong error for too old signature (is: %v, should: %v)", err, ErrInvalid)
+	}
+
+	// Tampered
+	tampered := []func(q url.Values){
+		func(q url.Values) { q.Set("id", "6") },
+		func(q url.Values) { q.Add("admin", "true") },
+		func(q url.Values) { q.Del("format") },
+		func(q url.Values) { q.Set(ExpiresParameter, "99999999999") },
+		func(q url.Values) { q.Del(SignatureParameter) },
+		func(q url.Values) { q.Set(SignatureParameter, "äää") },
+		func(q url.Values) { q.Add(SignatureParameter, q.Get(SignatureParameter)) },
+		func(q url.Values) { q.Set(PrefixParameter, "/") },
+		func(q url.Values) { q.Set(MethodParameter, http.MethodGet) },
+	}
+	for i := range tampered {
+		m := *s
+		q := m.Query()
+		tampered[i](q)
+		m.RawQuery = q.Encode()
+		err = Verify(&m, http.MethodGet, testtime, MaxValidDurationDefault)
+		if !errors.Is(err, ErrInvalid) {
+			t.Errorf("wrong error for tampered query %d (is: %v, should: %v)", i, err, ErrInvalid)
+		}
+	}
+
+	m := *s
+	m.Path = "/other"
+	err = Verify(&m, http.MethodGet, testtime, MaxValidDurationDefault)
+	if !errors.Is(err, ErrInvalid) {
+		t.Errorf("wrong error for tampered path (is: %v, should: %v)", err, ErrInvalid)
+	}
+}
+
+func TestVerifyMethod(t *testing.T) {
+	testtime := time.Now()
+	u, _ := url.Parse("https://example.com/unsubscribe?user=5")
+	s, err := Sign(u, testtime, 1*time.Hour, Options{Method: "post"})
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+
+	err = Verify(s, http.MethodPost, testtime, MaxValidDurationDefault)
+	if err != nil {
+		t.Errorf("verification failed: %s", err.Error())
+	}
+	err = Verify(s, http.MethodGet, testtime, MaxValidDurationDefault)
+	if !errors.Is(err, ErrInvalid) {
+		t.Errorf("wrong error for other method (is: %v, should: %v)", err, ErrInvalid)
+	}
+}
+
+func TestVerifyPrefix(t *testing.T) {
+	testtime := time.Now()
+	u, _ := url.Parse("https://example.com/exports/5/")
+	s, err := Sign(u, testtime, 1*time.Hour, Options{Prefix: "/exports/5"})
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+
+	for _, p := range []string{"/exports/5", "/exports/5/", "/exports/5/file.csv", "/exports/5/a/b"} {
+		m := *s
+		m.Path = p
+		err = Verify(&m, http.MethodGet, testtime, MaxValidDurationDefault)
+		if err != nil {
+			t.Errorf("verification failed for %s: %s", p, err.Error())
+		}
+	}
+
+	for _, p := range []string{"/exports/50", "/exports", "/exports/5/../6/file.csv", "/other"} {
+		m := *s
+		m.Path = p
+		err = Verify(&m, http.MethodGet, testtime, MaxValidDurationDefault)
+		if !errors.Is(err, ErrInvalid) {
+			t.Errorf("wrong error for %s (is: %v, should: %v)", p, err, ErrInvalid)
+		}
+	}
+}
+
+func TestVerifier(t *testing.T) {
+	u, _ := url.Parse("http://example.com/download?file=a")
+	s, err := Sign(u, time.Now(), 1*time.Hour, Options{})
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+
+	var reason error
+	v := &Verifier{
+		ErrorHandler: http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			reason = FailureReason(r)
+			rw.WriteHeader(http.StatusGone)
+		}),
+	}
+	h := v.Handler(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, s.String(), nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("valid request rejected: %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, u.String(), nil))
+	if rec.Code != http.StatusGone {
+		t.Errorf("unsigned request accepted: %d", rec.Code)
+	}
+	if !errors.Is(reason, ErrInvalid) {
+		t.Errorf("wrong reason (is: %v, should: %v)", reason, ErrInvalid)
+	}
+
+	v.ErrorHandler = nil
+	rec = httptest.NewRecorder()
+	h = v.Handler(http.NotFoundHandler())
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, u.String(), nil))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("wrong status for unsigned request (is: %d, should: %d)", rec.Code, http.StatusForbidden)
+	}
+}
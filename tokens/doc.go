@@ -0,0 +1,22 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tokens contains tokens for email verification and password reset, which are usually sent by email.
+// Tokens are URL-safe and authenticated with package data, so no state is required on the server. Each token is bound to its purpose and can not be used for another purpose.
+//
+// Password reset tokens are additionally bound to a fingerprint of the current password hash. They stop working as soon as the password is changed, so they can only be used once.
+//
+// Since package data uses a hidden value, all tokens become invalid whenever the program restarts.
+package tokens
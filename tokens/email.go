@@ -0,0 +1,55 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tokens
+
+import (
+	"time"
+)
+
+// IssueEmailVerification returns a token confirming that the user with the given id has access to email.
+// The token is valid for ttl (between one second and one year).
+//
+// Can be used concurrent.
+func IssueEmailVerification(userID, email string, ttl time.Duration) (string, error) {
+	return issueEmailVerification(userID, email, ttl, time.Now())
+}
+
+func issueEmailVerification(userID, email string, ttl time.Duration, now time.Time) (string, error) {
+	return issue(purposeEmailVerification, []string{userID, email}, "", ttl, now)
+}
+
+// VerifyEmailVerification verifies a token issued by IssueEmailVerification and returns the user id and email it was issued for.
+// You should check whether the email is still the one the user wants to verify.
+//
+// ErrInvalid is returned if the token is not valid, ErrExpired if it is expired.
+//
+// Can be used concurrent.
+func VerifyEmailVerification(token string) (userID, email string, err error) {
+	return verifyEmailVerification(token, time.Now())
+}
+
+func verifyEmailVerification(token string, now time.Time) (userID, email string, err error) {
+	fields, ttl, id, err := parse(token, 2)
+	if err != nil {
+		return
+	}
+	err = verify(purposeEmailVerification, fields, ttl, "", id, now)
+	if err != nil {
+		return
+	}
+	userID, email = fields[0], fields[1]
+	return
+}
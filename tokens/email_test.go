@@ -0,0 +1,116 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tokens
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEmailVerification(t *testing.T) {
+	token, err := IssueEmailVerification("user 1", "alice@example.com", time.Hour)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if url.QueryEscape(token) != token {
+		t.Errorf("token not URL-safe: %s", token)
+	}
+
+	userID, email, err := VerifyEmailVerification(token)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if userID != "user 1" {
+		t.Errorf("wrong user id (is: %s, should: %s)", userID, "user 1")
+	}
+	if email != "alice@example.com" {
+		t.Errorf("wrong email (is: %s, should: %s)", email, "alice@example.com")
+	}
+}
+
+func TestEmailVerificationExpired(t *testing.T) {
+	testtime := time.Now()
+	token, err := issueEmailVerification("user", "alice@example.com", time.Hour, testtime)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+
+	_, _, err = verifyEmailVerification(token, testtime.Add(59*time.Minute))
+	if err != nil {
+		t.Errorf("token not valid before expiry: %s", err.Error())
+	}
+	_, _, err = verifyEmailVerification(token, testtime.Add(61*time.Minute))
+	if !errors.Is(err, ErrExpired) {
+		t.Errorf("wrong error after expiry (is: %v, should: %v)", err, ErrExpired)
+	}
+	_, _, err = verifyEmailVerification(token, testtime.Add(-time.Minute))
+	if !errors.Is(err, ErrInvalid) {
+		t.Errorf("wrong error before issue time (is: %v, should: %v)", err, ErrInvalid)
+	}
+}
+
+func TestEmailVerificationModified(t *testing.T) {
+	token, err := IssueEmailVerification("user", "alice@example.com", time.Hour)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	parts := strings.Split(token, separator)
+
+	modified := []string{
+		"",
+		token + "a",
+		strings.Join([]string{encoding.EncodeToString([]byte("admin")), parts[1], parts[2], parts[3]}, separator),
+		strings.Join([]string{parts[0], encoding.EncodeToString([]byte("mallory@example.com")), parts[2], parts[3]}, separator),
+		strings.Join([]string{parts[0], parts[1], "31536000", parts[3]}, separator),
+		strings.Join([]string{parts[0], parts[1], parts[3]}, separator),
+	}
+	for _, m := range modified {
+		_, _, err = VerifyEmailVerification(m)
+		if !errors.Is(err, ErrInvalid) {
+			t.Errorf("wrong error for %s (is: %v, should: %v)", m, err, ErrInvalid)
+		}
+	}
+}
+
+func TestEmailVerificationPurpose(t *testing.T) {
+	token, err := IssuePasswordReset("user", "fingerprint", time.Hour)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	_, _, err = VerifyEmailVerification(token)
+	if !errors.Is(err, ErrInvalid) {
+		t.Errorf("password reset token accepted (is: %v, should: %v)", err, ErrInvalid)
+	}
+
+	// Same number of fields
+	token, err = issue(purposePasswordReset, []string{"user", "alice@example.com"}, "", time.Hour, time.Now())
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	_, _, err = VerifyEmailVerification(token)
+	if !errors.Is(err, ErrInvalid) {
+		t.Errorf("token of other purpose accepted (is: %v, should: %v)", err, ErrInvalid)
+	}
+}
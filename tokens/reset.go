@@ -0,0 +1,69 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tokens
+
+import (
+	"errors"
+	"time"
+)
+
+// IssuePasswordReset returns a token allowing the user with the given id to reset the password.
+// passwordHashFingerprint must be derived from the current password hash of the user (see Fingerprint). It is not contained in the token.
+// The token is valid for ttl (between one second and one year).
+//
+// Can be used concurrent.
+func IssuePasswordReset(userID, passwordHashFingerprint string, ttl time.Duration) (string, error) {
+	return issuePasswordReset(userID, passwordHashFingerprint, ttl, time.Now())
+}
+
+func issuePasswordReset(userID, passwordHashFingerprint string, ttl time.Duration, now time.Time) (string, error) {
+	if passwordHashFingerprint == "" {
+		return "", errors.New("empty fingerprint")
+	}
+	return issue(purposePasswordReset, []string{userID}, passwordHashFingerprint, ttl, now)
+}
+
+// VerifyPasswordReset verifies a token issued by IssuePasswordReset and returns the user id it was issued for.
+// fingerprint is called with the user id contained in the token and must return the fingerprint of the current password hash of the user.
+// Errors returned by fingerprint are passed through.
+//
+// ErrInvalid is returned if the token is not valid (including tokens issued before the password was changed), ErrExpired if it is expired.
+//
+// Can be used concurrent.
+func VerifyPasswordReset(token string, fingerprint func(userID string) (string, error)) (userID string, err error) {
+	return verifyPasswordReset(token, fingerprint, time.Now())
+}
+
+func verifyPasswordReset(token string, fingerprint func(userID string) (string, error), now time.Time) (userID string, err error) {
+	fields, ttl, id, err := parse(token, 1)
+	if err != nil {
+		return
+	}
+	f, err := fingerprint(fields[0])
+	if err != nil {
+		return
+	}
+	if f == "" {
+		err = ErrInvalid
+		return
+	}
+	err = verify(purposePasswordReset, fields, ttl, f, id, now)
+	if err != nil {
+		return
+	}
+	userID = fields[0]
+	return
+}
@@ -0,0 +1,113 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tokens
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPasswordReset(t *testing.T) {
+	hashes := map[string]string{"user": "$pbkdf2-sha256$i=1000$c2FsdA$aGFzaA"}
+	fingerprint := func(userID string) (string, error) {
+		h, ok := hashes[userID]
+		if !ok {
+			return "", errors.New("unknown user")
+		}
+		return Fingerprint(h), nil
+	}
+
+	token, err := IssuePasswordReset("user", Fingerprint(hashes["user"]), time.Hour)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+
+	userID, err := VerifyPasswordReset(token, fingerprint)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	if userID != "user" {
+		t.Errorf("wrong user id (is: %s, should: %s)", userID, "user")
+	}
+
+	// Token is valid until the password changes
+	_, err = VerifyPasswordReset(token, fingerprint)
+	if err != nil {
+		t.Errorf("token not valid a second time: %s", err.Error())
+	}
+	hashes["user"] = "$pbkdf2-sha256$i=1000$c2FsdA$bmV3"
+	_, err = VerifyPasswordReset(token, fingerprint)
+	if !errors.Is(err, ErrInvalid) {
+		t.Errorf("wrong error after password change (is: %v, should: %v)", err, ErrInvalid)
+	}
+
+	// Errors of the lookup are passed through
+	delete(hashes, "user")
+	_, err = VerifyPasswordReset(token, fingerprint)
+	if err == nil || errors.Is(err, ErrInvalid) {
+		t.Errorf("lookup error not passed through: %v", err)
+	}
+}
+
+func TestPasswordResetExpired(t *testing.T) {
+	fingerprint := func(string) (string, error) { return "fingerprint", nil }
+	testtime := time.Now()
+	token, err := issuePasswordReset("user", "fingerprint", 15*time.Minute, testtime)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+
+	_, err = verifyPasswordReset(token, fingerprint, testtime.Add(14*time.Minute))
+	if err != nil {
+		t.Errorf("token not valid before expiry: %s", err.Error())
+	}
+	_, err = verifyPasswordReset(token, fingerprint, testtime.Add(16*time.Minute))
+	if !errors.Is(err, ErrExpired) {
+		t.Errorf("wrong error after expiry (is: %v, should: %v)", err, ErrExpired)
+	}
+}
+
+func TestPasswordResetInvalid(t *testing.T) {
+	_, err := IssuePasswordReset("user", "", time.Hour)
+	if err == nil {
+		t.Error("no error for empty fingerprint")
+	}
+
+	token, err := IssuePasswordReset("user", "fingerprint", time.Hour)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	_, err = VerifyPasswordReset(token, func(string) (string, error) { return "", nil })
+	if !errors.Is(err, ErrInvalid) {
+		t.Errorf("wrong error for empty fingerprint (is: %v, should: %v)", err, ErrInvalid)
+	}
+
+	// Email verification tokens can not be used
+	token, err = issue(purposeEmailVerification, []string{"user"}, "fingerprint", time.Hour, time.Now())
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	_, err = VerifyPasswordReset(token, func(string) (string, error) { return "fingerprint", nil })
+	if !errors.Is(err, ErrInvalid) {
+		t.Errorf("token of other purpose accepted (is: %v, should: %v)", err, ErrInvalid)
+	}
+}
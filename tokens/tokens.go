@@ -0,0 +1,134 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tokens
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Top-Ranger/auth/data"
+)
+
+const (
+	// purposeEmailVerification is the purpose of email verification tokens.
+	purposeEmailVerification = "email verification"
+
+	// purposePasswordReset is the purpose of password reset tokens.
+	purposePasswordReset = "password reset"
+
+	// separator separates the parts of a token.
+	separator = "."
+
+	// maxTTL limits the time to live of a token.
+	maxTTL = 365 * 24 * time.Hour
+)
+
+var (
+	// ErrInvalid is returned if a token was not issued for the purpose, was modified or is bound to other data.
+	ErrInvalid = errors.New("tokens: invalid token")
+
+	// ErrExpired is returned if a valid token is expired.
+	ErrExpired = errors.New("tokens: token expired")
+)
+
+var encoding = base64.RawURLEncoding
+
+// Fingerprint returns a fingerprint of a password hash (e.g. created by package password), which can be used with IssuePasswordReset.
+// The fingerprint changes whenever the hash changes.
+func Fingerprint(passwordHash string) string {
+	sum := sha256.Sum256([]byte(passwordHash))
+	return encoding.EncodeToString(sum[:])
+}
+
+// issue returns a token containing fields. The token is bound to purpose, fields and secret, but secret is not contained in the token.
+func issue(purpose string, fields []string, secret string, ttl time.Duration, now time.Time) (string, error) {
+	if ttl < time.Second || ttl > maxTTL {
+		return "", errors.New("invalid ttl")
+	}
+	ttlString := strconv.FormatInt(int64(ttl/time.Second), 10)
+
+	id, err := data.GetTimed(now, binding(purpose, fields, ttlString, secret))
+	if err != nil {
+		return "", err
+	}
+
+	parts := make([]string, 0, len(fields)+2)
+	for i := range fields {
+		parts = append(parts, encoding.EncodeToString([]byte(fields[i])))
+	}
+	parts = append(parts, ttlString, encoding.EncodeToString(id))
+	return strings.Join(parts, separator), nil
+}
+
+// parse returns the fields of a token issued by issue. numFields is the number of fields expected.
+func parse(token string, numFields int) (fields []string, ttl string, id []byte, err error) {
+	parts := strings.Split(token, separator)
+	if len(parts) != numFields+2 {
+		err = ErrInvalid
+		return
+	}
+
+	fields = make([]string, numFields)
+	for i := range fields {
+		var b []byte
+		b, err = encoding.DecodeString(parts[i])
+		if err != nil {
+			err = ErrInvalid
+			return
+		}
+		fields[i] = string(b)
+	}
+	ttl = parts[numFields]
+	id, err = encoding.DecodeString(parts[numFields+1])
+	if err != nil {
+		err = ErrInvalid
+	}
+	return
+}
+
+// verify checks a token containing fields parsed by parse.
+func verify(purpose string, fields []string, ttlString, secret string, id []byte, now time.Time) error {
+	seconds, err := strconv.ParseInt(ttlString, 10, 64)
+	if err != nil || seconds < 1 || seconds > int64(maxTTL/time.Second) || strconv.FormatInt(seconds, 10) != ttlString {
+		return ErrInvalid
+	}
+
+	b := binding(purpose, fields, ttlString, secret)
+	if data.VerifyTimed(id, b, now, time.Duration(seconds)*time.Second) {
+		return nil
+	}
+	// Check whether the token is valid but expired. The ttl can not be extended since it is part of the binding.
+	if data.VerifyTimed(id, b, now, maxTTL*2) {
+		return ErrExpired
+	}
+	return ErrInvalid
+}
+
+// binding returns the data a token is bound to. All parts are length prefixed so that they can not be shifted.
+func binding(purpose string, fields []string, ttl, secret string) []byte {
+	var sb strings.Builder
+	parts := append([]string{"tokens", purpose, ttl, secret}, fields...)
+	for i := range parts {
+		sb.WriteString(strconv.Itoa(len(parts[i])))
+		sb.WriteString(":")
+		sb.WriteString(parts[i])
+	}
+	return []byte(sb.String())
+}
@@ -0,0 +1,78 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2020 Marcus Soll
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	  http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tokens
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFingerprint(t *testing.T) {
+	f := Fingerprint("$pbkdf2-sha256$i=1000$c2FsdA$aGFzaA")
+	if f != Fingerprint("$pbkdf2-sha256$i=1000$c2FsdA$aGFzaA") {
+		t.Error("fingerprint not deterministic")
+	}
+	if f == Fingerprint("$pbkdf2-sha256$i=1000$c2FsdA$aGFzaB") {
+		t.Error("fingerprint did not change")
+	}
+	if len(f) == 0 {
+		t.Error("empty fingerprint")
+	}
+}
+
+func TestBinding(t *testing.T) {
+	a := binding("p", []string{"ab", "c"}, "1", "")
+	b := binding("p", []string{"a", "bc"}, "1", "")
+	if bytes.Equal(a, b) {
+		t.Errorf("fields can be shifted: %s == %s", a, b)
+	}
+}
+
+func TestIssueInvalidTTL(t *testing.T) {
+	for _, ttl := range []time.Duration{0, -time.Hour, time.Millisecond, 2 * maxTTL} {
+		_, err := issue("test", []string{"field"}, "", ttl, time.Now())
+		if err == nil {
+			t.Errorf("no error for ttl %s", ttl)
+		}
+	}
+}
+
+func TestVerifyInvalidTTL(t *testing.T) {
+	testtime := time.Now()
+	token, err := issue("test", []string{"field"}, "", time.Minute, testtime)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+	fields, _, id, err := parse(token, 1)
+	if err != nil {
+		t.Logf("error occured: %s", err.Error())
+		t.FailNow()
+	}
+
+	for _, ttl := range []string{"120", "060", "+60", "-60", "a", ""} {
+		err = verify("test", fields, ttl, "", id, testtime)
+		if !errors.Is(err, ErrInvalid) {
+			t.Errorf("wrong error for ttl %s (is: %v, should: %v)", ttl, err, ErrInvalid)
+		}
+	}
+	err = verify("test", fields, "60", "", id, testtime)
+	if err != nil {
+		t.Errorf("valid token not accepted: %s", err.Error())
+	}
+}